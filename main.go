@@ -23,18 +23,23 @@ import (
 	"go.uber.org/automaxprocs/maxprocs"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/baseline"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/cache/simple"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/cleantemp"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/config"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/dispatchers"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/engine"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/engine/defaults"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/feature"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/prereceive"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/reverify"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/updater"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/verificationcache"
@@ -53,6 +58,7 @@ var (
 	jsonOut             = cli.Flag("json", "Output in JSON format.").Short('j').Bool()
 	jsonLegacy          = cli.Flag("json-legacy", "Use the pre-v3.0 JSON format. Only works with git, gitlab, and github sources.").Bool()
 	gitHubActionsFormat = cli.Flag("github-actions", "Output in GitHub Actions format.").Bool()
+	sarifOut            = cli.Flag("sarif", "Output in SARIF 2.1.0 format once the scan is done.").Bool()
 	concurrency         = cli.Flag("concurrency", "Number of concurrent workers.").Default(strconv.Itoa(runtime.NumCPU())).Int()
 	noVerification      = cli.Flag("no-verification", "Don't verify the results.").Bool()
 	onlyVerified        = cli.Flag("only-verified", "Only output verified results.").Hidden().Bool()
@@ -66,20 +72,30 @@ var (
 	compareDetectionStrategies = cli.Flag("compare-detection-strategies", "Compare different detection strategies for matching spans").Hidden().Default("false").Bool()
 	configFilename             = cli.Flag("config", "Path to configuration file.").ExistingFile()
 	// rules = cli.Flag("rules", "Path to file with custom rules.").String()
-	printAvgDetectorTime = cli.Flag("print-avg-detector-time", "Print the average time spent on each detector.").Bool()
-	noUpdate             = cli.Flag("no-update", "Don't check for updates.").Bool()
-	fail                 = cli.Flag("fail", "Exit with code 183 if results are found.").Bool()
-	verifiers            = cli.Flag("verifier", "Set custom verification endpoints.").StringMap()
-	customVerifiersOnly  = cli.Flag("custom-verifiers-only", "Only use custom verification endpoints.").Bool()
-	detectorTimeout      = cli.Flag("detector-timeout", "Maximum time to spend scanning chunks per detector (e.g., 30s).").Duration()
-	archiveMaxSize       = cli.Flag("archive-max-size", "Maximum size of archive to scan. (Byte units eg. 512B, 2KB, 4MB)").Bytes()
-	archiveMaxDepth      = cli.Flag("archive-max-depth", "Maximum depth of archive to scan.").Int()
-	archiveTimeout       = cli.Flag("archive-timeout", "Maximum time to spend extracting an archive.").Duration()
-	includeDetectors     = cli.Flag("include-detectors", "Comma separated list of detector types to include. Protobuf name or IDs may be used, as well as ranges.").Default("all").String()
-	excludeDetectors     = cli.Flag("exclude-detectors", "Comma separated list of detector types to exclude. Protobuf name or IDs may be used, as well as ranges. IDs defined here take precedence over the include list.").String()
-	jobReportFile        = cli.Flag("output-report", "Write a scan report to the provided path.").Hidden().OpenFile(os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
-	printOnce            = cli.Flag("print-once", "Only print the first occurrence of a result.").Bool()
-	noVerificationCache  = cli.Flag("no-verification-cache", "Disable verification caching").Bool()
+	printAvgDetectorTime  = cli.Flag("print-avg-detector-time", "Print the average time spent on each detector.").Bool()
+	noUpdate              = cli.Flag("no-update", "Don't check for updates.").Bool()
+	fail                  = cli.Flag("fail", "Exit with code 183 if results are found.").Bool()
+	verifiers             = cli.Flag("verifier", "Set custom verification endpoints.").StringMap()
+	customVerifiersOnly   = cli.Flag("custom-verifiers-only", "Only use custom verification endpoints.").Bool()
+	detectorTimeout       = cli.Flag("detector-timeout", "Maximum time to spend scanning chunks per detector (e.g., 30s).").Duration()
+	archiveMaxSize        = cli.Flag("archive-max-size", "Maximum size of archive to scan. (Byte units eg. 512B, 2KB, 4MB)").Bytes()
+	archiveMaxDepth       = cli.Flag("archive-max-depth", "Maximum depth of archive to scan.").Int()
+	archiveTimeout        = cli.Flag("archive-timeout", "Maximum time to spend extracting an archive.").Duration()
+	includeDetectors      = cli.Flag("include-detectors", "Comma separated list of detector types to include. Protobuf name or IDs may be used, as well as ranges.").Default("all").String()
+	excludeDetectors      = cli.Flag("exclude-detectors", "Comma separated list of detector types to exclude. Protobuf name or IDs may be used, as well as ranges. IDs defined here take precedence over the include list.").String()
+	jobReportFile         = cli.Flag("output-report", "Write a scan report to the provided path.").Hidden().OpenFile(os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	printOnce             = cli.Flag("print-once", "Only print the first occurrence of a result.").Bool()
+	noVerificationCache   = cli.Flag("no-verification-cache", "Disable verification caching").Bool()
+	verificationCachePath = cli.Flag("verification-cache-path", "Persist the verification cache to the provided file so that it is shared across scans.").String()
+	verificationCacheTTL  = cli.Flag("verification-cache-ttl", "How long persisted verification results are valid for, either a duration or per outcome, e.g. verified=24h,unverified=24h,unknown=0s. A zero duration is not persisted.").Default("verified=24h,unverified=24h,unknown=0s").String()
+	baselinePath          = cli.Flag("baseline", "Suppress findings recorded in the provided baseline file.").String()
+	updateBaseline        = cli.Flag("update-baseline", "Write every finding of this scan to the --baseline file instead of suppressing them.").Bool()
+	analyzeVerified       = cli.Flag("analyze-verified", "Analyze the permissions of verified credentials and include them in the output.").Bool()
+	webhookURL            = cli.Flag("webhook-url", "Also post each result as JSON to the provided HTTP(S) URL.").String()
+	webhookSecret         = cli.Flag("webhook-secret", "Sign webhook requests with HMAC-SHA256 in the X-TruffleHog-Signature-256 header. Can be provided with environment variable TRUFFLEHOG_WEBHOOK_SECRET.").Envar("TRUFFLEHOG_WEBHOOK_SECRET").String()
+	outputFile            = cli.Flag("output-file", "Also append each result as a line of JSON to the provided file.").String()
+	outputFileMaxSize     = cli.Flag("output-file-max-size", "Rotate the --output-file once it reaches this size. (Byte units eg. 512B, 2KB, 4MB)").Bytes()
+	outputFileMaxBackups  = cli.Flag("output-file-max-backups", "Number of rotated --output-file files to keep.").Default("5").Int()
 
 	// Add feature flags
 	forceSkipBinaries  = cli.Flag("force-skip-binaries", "Force skipping binaries.").Bool()
@@ -88,7 +104,9 @@ var (
 	userAgentSuffix    = cli.Flag("user-agent-suffix", "Suffix to add to User-Agent.").String()
 
 	gitScan             = cli.Command("git", "Find credentials in git repositories.")
-	gitScanURI          = gitScan.Arg("uri", "Git repository URL. https://, file://, or ssh:// schema expected.").Required().String()
+	gitScanRepo         = gitScan.Command("repo", "Find credentials in a git repository. This is the default git command.").Default()
+	gitScanURI          = gitScanRepo.Arg("uri", "Git repository URL. https://, file://, or ssh:// schema expected.").Required().String()
+	gitPreReceive       = gitScan.Command("pre-receive", "Find credentials in the commits of a push, as a pre-receive hook. Reads the ref updates from stdin and exits with code 183 to reject the push if results are found.")
 	gitScanIncludePaths = gitScan.Flag("include-paths", "Path to file with newline separated regexes for files to include in scan.").Short('i').String()
 	gitScanExcludePaths = gitScan.Flag("exclude-paths", "Path to file with newline separated regexes for files to exclude in scan.").Short('x').String()
 	gitScanExcludeGlobs = gitScan.Flag("exclude-globs", "Comma separated list of globs to exclude in scan. This option filters at the `git log` level, resulting in faster scans.").String()
@@ -96,6 +114,13 @@ var (
 	gitScanBranch       = gitScan.Flag("branch", "Branch to scan.").String()
 	gitScanMaxDepth     = gitScan.Flag("max-depth", "Maximum depth of commits to scan.").Int()
 	gitScanBare         = gitScan.Flag("bare", "Scan bare repository (e.g. useful while using in pre-receive hooks)").Bool()
+	gitScanLFS          = gitScan.Flag("lfs", "Scan the objects referenced by Git LFS pointers that are in the local LFS store.").Bool()
+	gitScanLFSEndpoint  = gitScan.Flag("lfs-endpoint", "Download LFS objects that are missing from the local LFS store from this LFS server URL, authenticated with the credentials in the repository URL, if any. Implies --lfs.").String()
+	gitScanStashes      = gitScan.Flag("stashes", "Scan every stash entry.").Bool()
+	gitScanReflog       = gitScan.Flag("reflog", "Scan commits that are only referenced by reflogs, e.g. after a rebase or reset.").Bool()
+	gitScanDangling     = gitScan.Flag("dangling", "Scan dangling commits and blobs, e.g. after a force-push.").Bool()
+	gitScanSubmodules   = gitScan.Flag("submodules", "Clone and scan the submodules referenced by the scanned commits.").Bool()
+	gitScanSnapshot     = gitScan.Flag("snapshot", "Scan every file in the tree of this ref, e.g. HEAD, instead of the history.").String()
 	_                   = gitScan.Flag("allow", "No-op flag for backwards compat.").Bool()
 	_                   = gitScan.Flag("entropy", "No-op flag for backwards compat.").Bool()
 	_                   = gitScan.Flag("regex", "No-op flag for backwards compat.").Bool()
@@ -118,6 +143,7 @@ var (
 	githubCommentsTimeframeDays = githubScan.Flag("comments-timeframe", "Number of days in the past to review when scanning issue, PR, and gist comments.").Uint32()
 	githubAuthInUrl             = githubScan.Flag("auth-in-url", "Embed authentication credentials in repository URLs instead of using secure HTTP headers").Bool()
 	githubCloneDir              = githubScan.Flag("clone-dir", "The directory to clone repositories to.").String()
+	githubScanSubmodules        = githubScan.Flag("submodules", "Clone and scan the submodules referenced by the scanned commits.").Bool()
 
 	// GitHub Cross Fork Object Reference Experimental Feature
 	githubExperimentalScan = cli.Command("github-experimental", "Run an experimental GitHub scan. Must specify at least one experimental sub-module to run: object-discovery.")
@@ -139,6 +165,92 @@ var (
 	gitlabScanIncludeRepos = gitlabScan.Flag("include-repos", `Repositories to include in an org scan. This can also be a glob pattern. You can repeat this flag. Must use Gitlab repo full name. Example: "trufflesecurity/trufflehog", "trufflesecurity/t*"`).Strings()
 	gitlabScanExcludeRepos = gitlabScan.Flag("exclude-repos", `Repositories to exclude in an org scan. This can also be a glob pattern. You can repeat this flag. Must use Gitlab repo full name. Example: "trufflesecurity/driftwood", "trufflesecurity/d*"`).Strings()
 	gitlabAuthInUrl        = gitlabScan.Flag("auth-in-url", "Embed authentication credentials in repository URLs instead of using secure HTTP headers").Bool()
+	gitlabScanSubmodules   = gitlabScan.Flag("submodules", "Clone and scan the submodules referenced by the scanned commits.").Bool()
+
+	bitbucketScan             = cli.Command("bitbucket", "Find credentials in Bitbucket Cloud or Data Center repositories.")
+	bitbucketScanEndpoint     = bitbucketScan.Flag("endpoint", "Bitbucket endpoint. Leave empty for Bitbucket Cloud, e.g. https://bitbucket.example.com for Data Center.").String()
+	bitbucketScanInstallation = bitbucketScan.Flag("installation-type", "Bitbucket installation type: autodetect, cloud or data-center.").Default("autodetect").Enum("autodetect", "cloud", "data-center")
+	bitbucketScanToken        = bitbucketScan.Flag("token", "Bitbucket access token. Can be provided with environment variable BITBUCKET_TOKEN.").Envar("BITBUCKET_TOKEN").String()
+	bitbucketScanUsername     = bitbucketScan.Flag("username", "Bitbucket username. Can be provided with environment variable BITBUCKET_USERNAME.").Envar("BITBUCKET_USERNAME").String()
+	bitbucketScanPassword     = bitbucketScan.Flag("password", "Bitbucket password or app password. Can be provided with environment variable BITBUCKET_PASSWORD.").Envar("BITBUCKET_PASSWORD").String()
+	bitbucketScanRepos        = bitbucketScan.Flag("repo", "Bitbucket repository URL. You can repeat this flag. Leave empty to scan all repositories accessible with the provided credential. Example: https://bitbucket.org/workspace/repo.git").Strings()
+	bitbucketScanExcludeRepos = bitbucketScan.Flag("exclude-repos", `Repositories to exclude when enumerating. This can also be a glob pattern. You can repeat this flag. Must use the "workspace/repo" or "PROJECT/repo" full name. Example: "trufflesecurity/d*"`).Strings()
+	bitbucketScanIncludePaths = bitbucketScan.Flag("include-paths", "Path to file with newline separated regexes for files to include in scan.").Short('i').String()
+	bitbucketScanExcludePaths = bitbucketScan.Flag("exclude-paths", "Path to file with newline separated regexes for files to exclude in scan.").Short('x').String()
+
+	azureReposScan                = cli.Command("azure-repos", "Find credentials in Azure Repos (Azure DevOps) repositories.")
+	azureReposScanEndpoint        = azureReposScan.Flag("endpoint", "Azure DevOps endpoint. Leave empty for Azure DevOps Services, e.g. https://tfs.example.com/tfs for Azure DevOps Server.").String()
+	azureReposScanToken           = azureReposScan.Flag("token", "Azure DevOps personal access token. Can be provided with environment variable AZURE_DEVOPS_TOKEN.").Envar("AZURE_DEVOPS_TOKEN").Required().String()
+	azureReposScanRepos           = azureReposScan.Flag("repo", "Azure Repos repository URL. You can repeat this flag. Example: https://dev.azure.com/org/project/_git/repo").Strings()
+	azureReposScanOrgs            = azureReposScan.Flag("organization", "Azure DevOps organization (or project collection) to scan. You can repeat this flag. Leave empty to scan every organization the token belongs to.").Strings()
+	azureReposScanProjects        = azureReposScan.Flag("project", `Azure DevOps project to scan, in the form "org/project". You can repeat this flag.`).Strings()
+	azureReposScanIncludeRepos    = azureReposScan.Flag("include-repos", `Repositories to include when enumerating. This can also be a glob pattern. You can repeat this flag. Must use the "org/project/repo" full name.`).Strings()
+	azureReposScanExcludeRepos    = azureReposScan.Flag("exclude-repos", `Repositories to exclude when enumerating. This can also be a glob pattern. You can repeat this flag. Must use the "org/project/repo" full name.`).Strings()
+	azureReposScanIncludeProjects = azureReposScan.Flag("include-projects", `Projects to include when enumerating. This can also be a glob pattern. You can repeat this flag. Must use the "org/project" full name.`).Strings()
+	azureReposScanExcludeProjects = azureReposScan.Flag("exclude-projects", `Projects to exclude when enumerating. This can also be a glob pattern. You can repeat this flag. Must use the "org/project" full name.`).Strings()
+	azureReposScanIncludeForks    = azureReposScan.Flag("include-forks", "Include forked repositories when enumerating.").Bool()
+	azureReposScanIncludePaths    = azureReposScan.Flag("include-paths", "Path to file with newline separated regexes for files to include in scan.").Short('i').String()
+	azureReposScanExcludePaths    = azureReposScan.Flag("exclude-paths", "Path to file with newline separated regexes for files to exclude in scan.").Short('x').String()
+
+	confluenceScan                   = cli.Command("confluence", "Find credentials in Confluence pages, page history and attachments.")
+	confluenceScanEndpoint           = confluenceScan.Flag("endpoint", "Confluence base URL, e.g. https://example.atlassian.net/wiki").Required().String()
+	confluenceScanUsername           = confluenceScan.Flag("username", "Confluence username or email. Can be provided with environment variable CONFLUENCE_USERNAME.").Envar("CONFLUENCE_USERNAME").String()
+	confluenceScanPassword           = confluenceScan.Flag("password", "Confluence password or API token used with --username. Can be provided with environment variable CONFLUENCE_PASSWORD.").Envar("CONFLUENCE_PASSWORD").String()
+	confluenceScanToken              = confluenceScan.Flag("token", "Confluence personal access token. Can be provided with environment variable CONFLUENCE_TOKEN.").Envar("CONFLUENCE_TOKEN").String()
+	confluenceScanSpaces             = confluenceScan.Flag("space", "Confluence space key to scan. You can repeat this flag. Leave empty to scan all spaces.").Strings()
+	confluenceScanIgnoreSpaces       = confluenceScan.Flag("ignore-space", "Confluence space key to skip. You can repeat this flag.").Strings()
+	confluenceScanSpacesScope        = confluenceScan.Flag("spaces-scope", "Which spaces to enumerate when none are given: all, global or personal.").Default("all").Enum("all", "global", "personal")
+	confluenceScanIncludeAttachments = confluenceScan.Flag("include-attachments", "Scan page attachments.").Bool()
+	confluenceScanSkipHistory        = confluenceScan.Flag("skip-history", "Only scan the latest version of each page.").Bool()
+	confluenceScanInsecureSkipTLS    = confluenceScan.Flag("insecure-skip-verify-tls", "Skip TLS verification.").Bool()
+
+	jiraScan                   = cli.Command("jira", "Find credentials in Jira issues, comments, history and attachments.")
+	jiraScanEndpoint           = jiraScan.Flag("endpoint", "Jira base URL, e.g. https://example.atlassian.net").Required().String()
+	jiraScanUsername           = jiraScan.Flag("username", "Jira username or email. Can be provided with environment variable JIRA_USERNAME.").Envar("JIRA_USERNAME").String()
+	jiraScanPassword           = jiraScan.Flag("password", "Jira password or API token used with --username. Can be provided with environment variable JIRA_PASSWORD.").Envar("JIRA_PASSWORD").String()
+	jiraScanToken              = jiraScan.Flag("token", "Jira personal access token. Can be provided with environment variable JIRA_TOKEN.").Envar("JIRA_TOKEN").String()
+	jiraScanProjects           = jiraScan.Flag("project", "Jira project key to scan. You can repeat this flag. Leave empty to scan all projects.").Strings()
+	jiraScanIgnoreProjects     = jiraScan.Flag("ignore-project", "Jira project key to skip. You can repeat this flag.").Strings()
+	jiraScanInsecureSkipVerify = jiraScan.Flag("insecure-skip-verify-tls", "Skip TLS verification.").Bool()
+
+	slackScan           = cli.Command("slack", "Find credentials in Slack channels, threads and files.")
+	slackScanToken      = slackScan.Flag("token", "Slack bot or user token. Can be provided with environment variable SLACK_TOKEN.").Envar("SLACK_TOKEN").Required().String()
+	slackScanEndpoint   = slackScan.Flag("endpoint", "Slack Web API base URL.").Default("https://slack.com/api").String()
+	slackScanChannels   = slackScan.Flag("channel", "Slack channel ID or name to scan. You can repeat this flag. Leave empty to scan all channels the token can read.").Strings()
+	slackScanIgnoreList = slackScan.Flag("ignore-channel", "Slack channel ID or name to skip. You can repeat this flag.").Strings()
+
+	gerritScan             = cli.Command("gerrit", "Find credentials in Gerrit projects, including unmerged changes and patchsets.")
+	gerritScanEndpoint     = gerritScan.Flag("endpoint", "Gerrit base URL, e.g. https://review.example.com").Required().String()
+	gerritScanUsername     = gerritScan.Flag("username", "Gerrit username. Can be provided with environment variable GERRIT_USERNAME. Leave empty to scan anonymously.").Envar("GERRIT_USERNAME").String()
+	gerritScanPassword     = gerritScan.Flag("password", "Gerrit HTTP password. Can be provided with environment variable GERRIT_PASSWORD.").Envar("GERRIT_PASSWORD").String()
+	gerritScanProjects     = gerritScan.Flag("project", "Gerrit project to scan. You can repeat this flag. Leave empty to scan all visible projects.").Strings()
+	gerritScanIncludePaths = gerritScan.Flag("include-paths", "Path to file with newline separated regexes for files to include in scan.").Short('i').String()
+	gerritScanExcludePaths = gerritScan.Flag("exclude-paths", "Path to file with newline separated regexes for files to exclude in scan.").Short('x').String()
+
+	artifactoryScan             = cli.Command("artifactory", "Find credentials in JFrog Artifactory repositories.")
+	artifactoryScanEndpoint     = artifactoryScan.Flag("endpoint", "Artifactory base URL, e.g. https://example.jfrog.io/artifactory").Required().String()
+	artifactoryScanToken        = artifactoryScan.Flag("token", "Artifactory access token. Can be provided with environment variable ARTIFACTORY_TOKEN.").Envar("ARTIFACTORY_TOKEN").String()
+	artifactoryScanUsername     = artifactoryScan.Flag("username", "Artifactory username. Can be provided with environment variable ARTIFACTORY_USERNAME.").Envar("ARTIFACTORY_USERNAME").String()
+	artifactoryScanPassword     = artifactoryScan.Flag("password", "Artifactory password. Can be provided with environment variable ARTIFACTORY_PASSWORD.").Envar("ARTIFACTORY_PASSWORD").String()
+	artifactoryScanRepos        = artifactoryScan.Flag("repo", "Artifactory repository key to scan. You can repeat this flag. Leave empty to scan all local and federated repositories.").Strings()
+	artifactoryScanIncludePaths = artifactoryScan.Flag("include-path", `Artifact path glob to include, relative to the repository root. You can repeat this flag. Example: "com/example/**"`).Strings()
+	artifactoryScanIgnorePaths  = artifactoryScan.Flag("exclude-path", `Artifact path glob to exclude, relative to the repository root. You can repeat this flag. Example: "**/*.pom"`).Strings()
+
+	npmScan            = cli.Command("npm", "Find credentials in every published version of npm packages.")
+	npmScanRegistry    = npmScan.Flag("registry", "npm registry URL.").Default("https://registry.npmjs.org").String()
+	npmScanPackages    = npmScan.Flag("package", "npm package name to scan. You can repeat this flag.").Strings()
+	npmScanMaintainers = npmScan.Flag("maintainer", "npm user whose packages should be scanned. You can repeat this flag.").Strings()
+
+	pypiScan         = cli.Command("pypi", "Find credentials in every published release of PyPI packages.")
+	pypiScanRegistry = pypiScan.Flag("registry", "Python package index URL.").Default("https://pypi.org").String()
+	pypiScanPackages = pypiScan.Flag("package", "PyPI project name to scan. You can repeat this flag.").Strings()
+	pypiScanUsers    = pypiScan.Flag("user", "PyPI user whose projects should be scanned. You can repeat this flag.").Strings()
+
+	ecrScan           = cli.Command("ecr", "Find credentials in Amazon ECR images.")
+	ecrScanKey        = ecrScan.Flag("key", "AWS access key ID used to authenticate. Can be provided with environment variable AWS_ACCESS_KEY_ID.").Envar("AWS_ACCESS_KEY_ID").String()
+	ecrScanSecret     = ecrScan.Flag("secret", "AWS secret access key used to authenticate. Can be provided with environment variable AWS_SECRET_ACCESS_KEY.").Envar("AWS_SECRET_ACCESS_KEY").String()
+	ecrScanRegistries = ecrScan.Flag("registry", "ECR registry to scan, e.g. 123456789012.dkr.ecr.us-east-1.amazonaws.com. You can repeat this flag.").Required().Strings()
+	ecrScanEndpoint   = ecrScan.Flag("endpoint", "ECR API endpoint. Defaults to the regional endpoint of each registry.").String()
 
 	filesystemScan  = cli.Command("filesystem", "Find credentials in a filesystem.")
 	filesystemPaths = filesystemScan.Arg("path", "Path to file or directory to scan.").Strings()
@@ -178,6 +290,11 @@ var (
 	syslogTLSKey   = syslogScan.Flag("key", "Path to TLS key.").String()
 	syslogFormat   = syslogScan.Flag("format", "Log format. Can be rfc3164 or rfc5424").String()
 
+	webhookScan          = cli.Command("webhook", "Listen for HTTP POST requests and scan their bodies. Add ?sync=true to a request to wait for its findings.")
+	webhookListenAddress = webhookScan.Flag("listen-address", "Address and port to listen on.").Default("127.0.0.1:8080").String()
+	webhookHeaderName    = webhookScan.Flag("header-name", "Name of the header required to authenticate requests.").String()
+	webhookHeaderValue   = webhookScan.Flag("header-value", "Value of the header required to authenticate requests. Can be provided with environment variable WEBHOOK_HEADER_VALUE.").Envar("WEBHOOK_HEADER_VALUE").String()
+
 	circleCiScan      = cli.Command("circleci", "Scan CircleCI")
 	circleCiScanToken = circleCiScan.Flag("token", "CircleCI token. Can also be provided with environment variable").Envar("CIRCLECI_TOKEN").Required().String()
 
@@ -256,6 +373,9 @@ var (
 	stdinInputScan = cli.Command("stdin", "Find credentials from stdin.")
 	multiScanScan  = cli.Command("multi-scan", "Find credentials in multiple sources defined in configuration.")
 
+	reverifyCmd     = cli.Command("reverify", "Re-verify the findings of a previous scan's JSON output, without scanning its sources again.")
+	reverifyResults = reverifyCmd.Arg("results", "File of JSON output from a previous scan, or - for stdin.").Default("-").String()
+
 	analyzeCmd = analyzer.Command(cli)
 )
 
@@ -452,6 +572,8 @@ func run(state overseer.State) {
 		printer = new(output.JSONPrinter)
 	case *gitHubActionsFormat:
 		printer = new(output.GitHubActionsPrinter)
+	case *sarifOut:
+		printer = new(output.SARIFPrinter)
 	default:
 		printer = new(output.PlainPrinter)
 	}
@@ -472,6 +594,19 @@ func run(state overseer.State) {
 
 	verificationCacheMetrics := verificationcache.InMemoryMetrics{}
 
+	dispatcher, err := newDispatcher(printer)
+	if err != nil {
+		logFatal(err, "failed to configure result dispatchers")
+	}
+	// The dispatchers are closed explicitly once the scan is done, as run may
+	// exit before deferred calls run. Closing them more than once is fine.
+	closeDispatcher := func() {
+		if err := dispatcher.Close(); err != nil {
+			logger.Error(err, "error closing result dispatchers")
+		}
+	}
+	defer closeDispatcher()
+
 	engConf := engine.Config{
 		Concurrency:       *concurrency,
 		ConfiguredSources: conf.Sources,
@@ -485,7 +620,7 @@ func run(state overseer.State) {
 		ExcludeDetectors:         *excludeDetectors,
 		CustomVerifiersOnly:      *customVerifiersOnly,
 		VerifierEndpoints:        *verifiers,
-		Dispatcher:               engine.NewPrinterDispatcher(printer),
+		Dispatcher:               dispatcher,
 		FilterUnverified:         *filterUnverified,
 		FilterEntropy:            *filterEntropy,
 		Results:                  parsedResults,
@@ -493,10 +628,42 @@ func run(state overseer.State) {
 		PrintOnce:                *printOnce,
 		ShouldScanEntireChunk:    *scanEntireChunk,
 		VerificationCacheMetrics: &verificationCacheMetrics,
+		AnalyzeVerified:          *analyzeVerified,
+		Allowlist:                conf.Allowlist,
 	}
 
+	if *updateBaseline && *baselinePath == "" {
+		logFatal(fmt.Errorf("missing required flag: --baseline"), "--update-baseline requires a baseline file")
+	}
+	if *baselinePath != "" {
+		engConf.UpdateBaseline = *updateBaseline
+		if *updateBaseline {
+			// The baseline is rewritten from this scan's findings.
+			engConf.Baseline = baseline.New()
+		} else {
+			b, err := baseline.Load(*baselinePath)
+			if err != nil {
+				logFatal(err, "failed to load baseline", "path", *baselinePath)
+			}
+			engConf.Baseline = b
+		}
+	}
+
+	var fileResultCache *verificationcache.FileResultCache
 	if !*noVerificationCache {
-		engConf.VerificationResultCache = simple.NewCache[detectors.Result]()
+		if *verificationCachePath != "" {
+			ttls, err := verificationcache.ParseTTLs(*verificationCacheTTL)
+			if err != nil {
+				logFatal(err, "failed to configure verification cache")
+			}
+			fileResultCache, err = verificationcache.NewFileResultCache(*verificationCachePath, ttls)
+			if err != nil {
+				logFatal(err, "failed to load verification cache", "path", *verificationCachePath)
+			}
+			engConf.VerificationResultCache = fileResultCache
+		} else {
+			engConf.VerificationResultCache = simple.NewCache[detectors.Result]()
+		}
 	}
 
 	// Check that there are no sources defined for non-scan subcommands. If
@@ -519,12 +686,40 @@ func run(state overseer.State) {
 	topLevelSubCommand, _, _ := strings.Cut(cmd, " ")
 	switch topLevelSubCommand {
 	case analyzeCmd.FullCommand():
-		analyzer.Run(cmd)
+		if err := analyzer.Run(ctx, cmd, *jsonOut, engConf.Detectors); err != nil {
+			logFatal(err, "error running analysis")
+		}
+	case reverifyCmd.FullCommand():
+		if err := runReverify(ctx, engConf.Detectors, *reverifyResults); err != nil {
+			logFatal(err, "error re-verifying findings")
+		}
 	default:
 		metrics, err := runSingleScan(ctx, cmd, engConf)
 		if err != nil {
 			logFatal(err, "error running scan")
 		}
+		// Wait for the queued results to be dispatched.
+		closeDispatcher()
+
+		if *updateBaseline {
+			if err := engConf.Baseline.Save(*baselinePath); err != nil {
+				logFatal(err, "failed to write baseline", "path", *baselinePath)
+			}
+			logger.Info("wrote baseline", "path", *baselinePath, "findings", engConf.Baseline.Len())
+		}
+
+		if fileResultCache != nil {
+			if err := fileResultCache.Save(); err != nil {
+				logger.Error(err, "failed to save verification cache", "path", *verificationCachePath)
+			}
+		}
+
+		// Print results that are buffered until the scan is done.
+		if flusher, ok := printer.(interface{ Flush() error }); ok {
+			if err := flusher.Flush(); err != nil {
+				logFatal(err, "error printing results")
+			}
+		}
 
 		verificationCacheMetricsSnapshot := struct {
 			Hits                    int32
@@ -551,6 +746,14 @@ func run(state overseer.State) {
 			"verification_caching", verificationCacheMetricsSnapshot,
 		)
 
+		if metrics.rejection != nil && metrics.rejection.Rejected() {
+			if _, err := metrics.rejection.WriteTo(os.Stderr); err != nil {
+				logger.Error(err, "error writing the push rejection")
+			}
+			logger.V(2).Info("exiting with code 183 to reject the push")
+			os.Exit(183)
+		}
+
 		if metrics.hasFoundResults && *fail {
 			logger.V(2).Info("exiting with code 183 because results were found")
 			os.Exit(183)
@@ -609,6 +812,55 @@ func compareMetrics(customMetrics, entireMetrics engine.Metrics) error {
 type metrics struct {
 	engine.Metrics
 	hasFoundResults bool
+	// rejection explains why a push is rejected, when running as a
+	// pre-receive hook.
+	rejection *prereceive.Rejection
+}
+
+// runReverify re-verifies the findings in the JSON output of a previous scan
+// and prints a JSON line report for each.
+func runReverify(ctx context.Context, dets []detectors.Detector, path string) error {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("error opening findings: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	summary, err := reverify.New(dets, *concurrency).Run(ctx, in, os.Stdout)
+	if err != nil {
+		return err
+	}
+	ctx.Logger().Info("finished re-verifying findings",
+		"live", summary.Live,
+		"rotated", summary.Rotated,
+		"unknown", summary.Unknown,
+	)
+	return nil
+}
+
+// newDispatcher returns the dispatcher that sends results to the printer and
+// to any sinks configured by flags.
+func newDispatcher(printer engine.Printer) (*dispatchers.Multi, error) {
+	sinks := []engine.ResultsDispatcher{engine.NewPrinterDispatcher(printer)}
+	if *webhookURL != "" {
+		webhook, err := dispatchers.NewWebhook(*webhookURL, dispatchers.WithWebhookSecret(*webhookSecret))
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, webhook)
+	}
+	if *outputFile != "" {
+		file, err := dispatchers.NewJSONLFile(*outputFile, int64(*outputFileMaxSize), *outputFileMaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, file)
+	}
+	return dispatchers.NewMulti(sinks...), nil
 }
 
 func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics, error) {
@@ -664,6 +916,22 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 
 	cfg.SourceManager = sources.NewManager(opts...)
 
+	// A pre-receive hook only scans the commits that the push adds, and
+	// collects the results to tell the pusher why the push is rejected.
+	var (
+		push                *prereceive.Push
+		rejectionDispatcher *dispatchers.Multi
+	)
+	if cmd == gitPreReceive.FullCommand() {
+		var err error
+		if push, err = readPush(ctx); err != nil {
+			return scanMetrics, err
+		}
+		scanMetrics.rejection = prereceive.NewRejection(push)
+		rejectionDispatcher = dispatchers.NewMulti(cfg.Dispatcher, scanMetrics.rejection)
+		cfg.Dispatcher = rejectionDispatcher
+	}
+
 	eng, err := engine.NewEngine(ctx, &cfg)
 	if err != nil {
 		return scanMetrics, fmt.Errorf("error initializing engine: %v", err)
@@ -679,13 +947,16 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 
 	var refs []sources.JobProgressRef
 	switch cmd {
-	case gitScan.FullCommand():
+	case gitScanRepo.FullCommand():
 		// validate the commit for local repository only
 		if *gitScanSinceCommit != "" && strings.HasPrefix(*gitScanURI, "file") {
 			if !isValidCommit(*gitScanURI, *gitScanSinceCommit) {
 				ctx.Logger().Info("Warning: The provided commit hash appears to be invalid.")
 			}
 		}
+		if *gitScanSnapshot != "" && (*gitScanSinceCommit != "" || *gitScanBranch != "" || *gitScanMaxDepth != 0) {
+			return scanMetrics, fmt.Errorf("invalid config: --snapshot cannot be used with --since-commit, --branch or --max-depth")
+		}
 
 		gitCfg := sources.GitConfig{
 			URI:              *gitScanURI,
@@ -696,6 +967,45 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 			MaxDepth:         *gitScanMaxDepth,
 			Bare:             *gitScanBare,
 			ExcludeGlobs:     *gitScanExcludeGlobs,
+			LFS:              *gitScanLFS || *gitScanLFSEndpoint != "",
+			LFSEndpoint:      *gitScanLFSEndpoint,
+			Stashes:          *gitScanStashes,
+			Reflog:           *gitScanReflog,
+			Dangling:         *gitScanDangling,
+			Submodules:       *gitScanSubmodules,
+			Snapshot:         *gitScanSnapshot,
+		}
+		if ref, err := eng.ScanGit(ctx, gitCfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Git: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case gitPreReceive.FullCommand():
+		if *gitScanSinceCommit != "" || *gitScanBranch != "" || *gitScanMaxDepth != 0 || *gitScanSnapshot != "" ||
+			*gitScanStashes || *gitScanReflog || *gitScanDangling {
+			return scanMetrics, fmt.Errorf("invalid config: pre-receive only scans the pushed commits, and cannot be used with --since-commit, --branch, --max-depth, --snapshot, --stashes, --reflog or --dangling")
+		}
+		revisions := push.Revisions()
+		if len(revisions) == 0 {
+			ctx.Logger().Info("no new commits to scan", "refs", len(push.Updates))
+			break
+		}
+		gitDir, err := os.Getwd()
+		if err != nil {
+			return scanMetrics, fmt.Errorf("could not get the git directory: %v", err)
+		}
+		ctx.Logger().Info("scanning pushed commits", "refs", len(push.Updates), "commits", push.Commits())
+
+		gitCfg := sources.GitConfig{
+			URI:              "file://" + gitDir,
+			IncludePathsFile: *gitScanIncludePaths,
+			ExcludePathsFile: *gitScanExcludePaths,
+			Bare:             true,
+			ExcludeGlobs:     *gitScanExcludeGlobs,
+			LFS:              *gitScanLFS || *gitScanLFSEndpoint != "",
+			LFSEndpoint:      *gitScanLFSEndpoint,
+			Submodules:       *gitScanSubmodules,
+			PushedRevisions:  revisions,
 		}
 		if ref, err := eng.ScanGit(ctx, gitCfg); err != nil {
 			return scanMetrics, fmt.Errorf("failed to scan Git: %v", err)
@@ -732,6 +1042,7 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 			CloneDirectory:             *githubCloneDir,
 			Filter:                     filter,
 			AuthInUrl:                  *githubAuthInUrl,
+			Submodules:                 *githubScanSubmodules,
 		}
 		if ref, err := eng.ScanGitHub(ctx, cfg); err != nil {
 			return scanMetrics, fmt.Errorf("failed to scan Github: %v", err)
@@ -765,12 +1076,196 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 			ExcludeRepos: *gitlabScanExcludeRepos,
 			Filter:       filter,
 			AuthInUrl:    *gitlabAuthInUrl,
+			Submodules:   *gitlabScanSubmodules,
 		}
 		if ref, err := eng.ScanGitLab(ctx, cfg); err != nil {
 			return scanMetrics, fmt.Errorf("failed to scan GitLab: %v", err)
 		} else {
 			refs = []sources.JobProgressRef{ref}
 		}
+	case bitbucketScan.FullCommand():
+		filter, err := common.FilterFromFiles(*bitbucketScanIncludePaths, *bitbucketScanExcludePaths)
+		if err != nil {
+			return scanMetrics, fmt.Errorf("could not create filter: %v", err)
+		}
+
+		installationType := sourcespb.BitbucketInstallationType_AUTODETECT
+		switch *bitbucketScanInstallation {
+		case "cloud":
+			installationType = sourcespb.BitbucketInstallationType_CLOUD
+		case "data-center":
+			installationType = sourcespb.BitbucketInstallationType_DATA_CENTER
+		}
+
+		cfg := engine.BitbucketConfig{
+			Endpoint:         *bitbucketScanEndpoint,
+			InstallationType: installationType,
+			Token:            *bitbucketScanToken,
+			Username:         *bitbucketScanUsername,
+			Password:         *bitbucketScanPassword,
+			Repos:            *bitbucketScanRepos,
+			IgnoreRepos:      *bitbucketScanExcludeRepos,
+			Filter:           filter,
+			Concurrency:      *concurrency,
+		}
+		if ref, err := eng.ScanBitbucket(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Bitbucket: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case azureReposScan.FullCommand():
+		filter, err := common.FilterFromFiles(*azureReposScanIncludePaths, *azureReposScanExcludePaths)
+		if err != nil {
+			return scanMetrics, fmt.Errorf("could not create filter: %v", err)
+		}
+
+		cfg := engine.AzureReposConfig{
+			Endpoint:        *azureReposScanEndpoint,
+			Token:           *azureReposScanToken,
+			Repos:           *azureReposScanRepos,
+			Organizations:   *azureReposScanOrgs,
+			Projects:        *azureReposScanProjects,
+			IncludeRepos:    *azureReposScanIncludeRepos,
+			IgnoreRepos:     *azureReposScanExcludeRepos,
+			IncludeProjects: *azureReposScanIncludeProjects,
+			IgnoreProjects:  *azureReposScanExcludeProjects,
+			IncludeForks:    *azureReposScanIncludeForks,
+			Filter:          filter,
+			Concurrency:     *concurrency,
+		}
+		if ref, err := eng.ScanAzureRepos(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Azure Repos: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case confluenceScan.FullCommand():
+		spacesScope := sourcespb.Confluence_ALL
+		switch *confluenceScanSpacesScope {
+		case "global":
+			spacesScope = sourcespb.Confluence_GLOBAL
+		case "personal":
+			spacesScope = sourcespb.Confluence_PERSONAL
+		}
+
+		cfg := engine.ConfluenceConfig{
+			Endpoint:              *confluenceScanEndpoint,
+			Username:              *confluenceScanUsername,
+			Password:              *confluenceScanPassword,
+			Token:                 *confluenceScanToken,
+			Spaces:                *confluenceScanSpaces,
+			IgnoreSpaces:          *confluenceScanIgnoreSpaces,
+			SpacesScope:           spacesScope,
+			IncludeAttachments:    *confluenceScanIncludeAttachments,
+			SkipHistory:           *confluenceScanSkipHistory,
+			InsecureSkipVerifyTLS: *confluenceScanInsecureSkipTLS,
+			Concurrency:           *concurrency,
+		}
+		if ref, err := eng.ScanConfluence(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Confluence: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case jiraScan.FullCommand():
+		cfg := engine.JiraConfig{
+			Endpoint:              *jiraScanEndpoint,
+			Username:              *jiraScanUsername,
+			Password:              *jiraScanPassword,
+			Token:                 *jiraScanToken,
+			Projects:              *jiraScanProjects,
+			IgnoreProjects:        *jiraScanIgnoreProjects,
+			InsecureSkipVerifyTLS: *jiraScanInsecureSkipVerify,
+			Concurrency:           *concurrency,
+		}
+		if ref, err := eng.ScanJira(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Jira: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case slackScan.FullCommand():
+		cfg := engine.SlackConfig{
+			Token:       *slackScanToken,
+			Endpoint:    *slackScanEndpoint,
+			Channels:    *slackScanChannels,
+			IgnoreList:  *slackScanIgnoreList,
+			Concurrency: *concurrency,
+		}
+		if ref, err := eng.ScanSlack(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Slack: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case gerritScan.FullCommand():
+		filter, err := common.FilterFromFiles(*gerritScanIncludePaths, *gerritScanExcludePaths)
+		if err != nil {
+			return scanMetrics, fmt.Errorf("could not create filter: %v", err)
+		}
+
+		cfg := engine.GerritConfig{
+			Endpoint:    *gerritScanEndpoint,
+			Username:    *gerritScanUsername,
+			Password:    *gerritScanPassword,
+			Projects:    *gerritScanProjects,
+			Filter:      filter,
+			Concurrency: *concurrency,
+		}
+		if ref, err := eng.ScanGerrit(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Gerrit: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case artifactoryScan.FullCommand():
+		cfg := engine.ArtifactoryConfig{
+			Endpoint:     *artifactoryScanEndpoint,
+			Token:        *artifactoryScanToken,
+			Username:     *artifactoryScanUsername,
+			Password:     *artifactoryScanPassword,
+			Repositories: *artifactoryScanRepos,
+			IncludePaths: *artifactoryScanIncludePaths,
+			IgnorePaths:  *artifactoryScanIgnorePaths,
+			Concurrency:  *concurrency,
+		}
+		if ref, err := eng.ScanArtifactory(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan Artifactory: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case npmScan.FullCommand():
+		cfg := engine.NPMConfig{
+			Registry:    *npmScanRegistry,
+			Packages:    *npmScanPackages,
+			Maintainers: *npmScanMaintainers,
+			Concurrency: *concurrency,
+		}
+		if ref, err := eng.ScanNPM(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan npm: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case pypiScan.FullCommand():
+		cfg := engine.PyPIConfig{
+			Registry:    *pypiScanRegistry,
+			Packages:    *pypiScanPackages,
+			Users:       *pypiScanUsers,
+			Concurrency: *concurrency,
+		}
+		if ref, err := eng.ScanPyPI(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan PyPI: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
+	case ecrScan.FullCommand():
+		cfg := engine.ECRConfig{
+			Key:         *ecrScanKey,
+			Secret:      *ecrScanSecret,
+			Registries:  *ecrScanRegistries,
+			Endpoint:    *ecrScanEndpoint,
+			Concurrency: *concurrency,
+		}
+		if ref, err := eng.ScanECR(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan ECR: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
 	case filesystemScan.FullCommand():
 		if len(*filesystemDirectories) > 0 {
 			ctx.Logger().Info("--directory flag is deprecated, please pass directories as arguments")
@@ -818,6 +1313,17 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 		} else {
 			refs = []sources.JobProgressRef{ref}
 		}
+	case webhookScan.FullCommand():
+		cfg := engine.WebhookConfig{
+			ListenAddress: *webhookListenAddress,
+			HeaderName:    *webhookHeaderName,
+			HeaderValue:   *webhookHeaderValue,
+		}
+		if ref, err := eng.ScanWebhook(ctx, cfg); err != nil {
+			return scanMetrics, fmt.Errorf("failed to scan webhook: %v", err)
+		} else {
+			refs = []sources.JobProgressRef{ref}
+		}
 	case circleCiScan.FullCommand():
 		if ref, err := eng.ScanCircleCI(ctx, *circleCiScanToken); err != nil {
 			return scanMetrics, fmt.Errorf("failed to scan CircleCI: %v", err)
@@ -989,6 +1495,12 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 	if err = eng.Finish(ctx); err != nil {
 		return scanMetrics, fmt.Errorf("engine failed to finish execution: %v", err)
 	}
+	// The rejection is only complete once every queued result is dispatched.
+	if rejectionDispatcher != nil {
+		if err := rejectionDispatcher.Close(); err != nil {
+			ctx.Logger().Error(err, "error closing result dispatchers")
+		}
+	}
 
 	// Print any non-fatal errors reported during the scan.
 	for _, ref := range refs {
@@ -1009,7 +1521,21 @@ func runSingleScan(ctx context.Context, cmd string, cfg engine.Config) (metrics,
 		printAverageDetectorTime(eng)
 	}
 
-	return metrics{Metrics: eng.GetMetrics(), hasFoundResults: eng.HasFoundResults()}, nil
+	return metrics{Metrics: eng.GetMetrics(), hasFoundResults: eng.HasFoundResults(), rejection: scanMetrics.rejection}, nil
+}
+
+// readPush reads the ref updates of a push from stdin, as a pre-receive hook,
+// which git runs in the git directory of the repository.
+func readPush(ctx context.Context) (*prereceive.Push, error) {
+	updates, err := prereceive.ParseUpdates(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	gitDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the git directory: %v", err)
+	}
+	return prereceive.NewPush(ctx, gitDir, updates)
 }
 
 // parseResults ensures that users provide valid CSV input to `--results`.
@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/alecthomas/kingpin/v2"
@@ -51,6 +52,10 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/stripe"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/twilio"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/config"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/reverify"
 )
 
 type SecretInfo struct {
@@ -61,7 +66,10 @@ type SecretInfo struct {
 var (
 	// TODO: Add list of supported key types.
 	analyzeKeyType *string
-	analyzeKey     *string
+	// analyzeType is the deprecated name of analyzeKeyType.
+	analyzeType *string
+	analyzeKey  *string
+	batchPath   *string
 
 	showAll *bool
 	log     *bool
@@ -81,21 +89,38 @@ func Command(app *kingpin.Application) *kingpin.CmdClause {
 	for i, a := range analyzers.AvailableAnalyzers() {
 		availableAnalyzers[i] = strings.ToLower(a)
 	}
-	analyzeKeyType = cli.Flag("type", keyTypeHelp).Enum(availableAnalyzers...)
-	analyzeKey = cli.Flag("key", "The key to analyze.").String()
+	analyzeKeyType = cli.Flag("key-type", keyTypeHelp).Enum(availableAnalyzers...)
+	analyzeType = cli.Flag("type", keyTypeHelp).Hidden().Enum(availableAnalyzers...)
+	analyzeKey = cli.Flag("key", "The key to analyze. Not supported with --json, which reads the key from stdin or $"+keyEnvVar+", either as a JSON object of its parts or with its parts joined by colons.").String()
+	batchPath = cli.Flag("batch", "Analyze every verified result in a file of JSON output from a scan, or - for stdin, and print the analyses as JSON lines.").PlaceHolder("FILE").String()
 
 	return cli
 }
 
-func Run(cmd string) {
+// Run runs the analyze command. With jsonOut, the analysis is printed as JSON
+// instead of tables. In batch mode, the detectors find the credential parts of
+// each result again, as the scan output doesn't include them.
+func Run(ctx context.Context, cmd string, jsonOut bool, dets []detectors.Detector) error {
 	// Initialize configuration
 	cfg := &config.Config{
 		LoggingEnabled: *log,
 		ShowAll:        *showAll,
 	}
 
+	keyTypeName := strings.ToLower(*analyzeKeyType)
+	if keyTypeName == "" {
+		keyTypeName = strings.ToLower(*analyzeType)
+	}
+
+	if *batchPath != "" {
+		return runBatch(ctx, cfg, *batchPath, dets)
+	}
+	if jsonOut {
+		return runJSON(ctx, cfg, keyTypeName)
+	}
+
 	key := *analyzeKey
-	switch strings.ToLower(*analyzeKeyType) {
+	switch keyTypeName {
 	case "github":
 		github.AnalyzeAndPrintPermissions(cfg, key)
 	case "sendgrid":
@@ -192,4 +217,51 @@ func Run(cmd string) {
 		parts := strings.SplitN(key, ":", 3)
 		jira.AnalyzeAndPrintPermissions(cfg, parts[0], parts[1], parts[2])
 	}
+	return nil
+}
+
+// runJSON analyzes the key read from stdin or the environment and prints the
+// analysis as JSON.
+func runJSON(ctx context.Context, cfg *config.Config, keyTypeName string) error {
+	if *analyzeKey != "" {
+		return fmt.Errorf("--key is not supported with --json, pass the key on stdin or in $%s", keyEnvVar)
+	}
+	kt, ok := keyTypes[keyTypeName]
+	if !ok {
+		return fmt.Errorf("--key-type is required with --json")
+	}
+	key, err := readKey(os.Stdin)
+	if err != nil {
+		return err
+	}
+	credInfo, err := kt.credentialInfo(key)
+	if err != nil {
+		return err
+	}
+	return analyzeJSON(ctx, kt.newAnalyzer(cfg), credInfo, os.Stdout)
+}
+
+// runBatch analyzes the verified results in the JSON output of a scan and
+// prints the analyses as JSON lines.
+func runBatch(ctx context.Context, cfg *config.Config, path string, dets []detectors.Detector) error {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("error opening results: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	reverifier := reverify.New(dets, 1)
+	analysisInfo := func(ctx context.Context, finding reverify.Finding) map[string]string {
+		result, ok := reverifier.Rediscover(ctx, finding)
+		if !ok {
+			return nil
+		}
+		return result.AnalysisInfo
+	}
+	return analyzeBatch(ctx, in, os.Stdout, analysisInfo, func(detectorType detectorspb.DetectorType, info map[string]string) analyzers.Analyzer {
+		return ForDetector(cfg, detectorType, info)
+	})
 }
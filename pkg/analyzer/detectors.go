@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/airtable/airtableoauth"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/airtable/airtablepat"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/anthropic"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/asana"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/databricks"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/datadog"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/digitalocean"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/dockerhub"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/dropbox"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/elevenlabs"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/fastly"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/figma"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/github"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/gitlab"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/groq"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/huggingface"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/launchdarkly"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/mailchimp"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/mailgun"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/monday"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/mux"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/mysql"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/netlify"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/ngrok"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/notion"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/openai"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/opsgenie"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/plaid"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/planetscale"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/postgres"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/posthog"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/postman"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/privatekey"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/sendgrid"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/shopify"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/slack"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/sourcegraph"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/square"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/stripe"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/twilio"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/config"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// ForDetector returns the analyzer for credentials found by the detector type,
+// or nil if there is none. The detector's analysis info is the credential
+// info the analyzer expects; it is used to pick the analyzer for detectors
+// that find credentials of several services.
+func ForDetector(cfg *config.Config, detectorType detectorspb.DetectorType, analysisInfo map[string]string) analyzers.Analyzer {
+	switch detectorType {
+	case detectorspb.DetectorType_AirtableOAuth:
+		return airtableoauth.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_AirtablePersonalAccessToken:
+		return airtablepat.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Anthropic:
+		return anthropic.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_AsanaOauth:
+		return asana.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_DatabricksToken:
+		return databricks.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_DatadogToken:
+		return datadog.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_DigitalOceanToken, detectorspb.DetectorType_DigitalOceanV2:
+		return digitalocean.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Dockerhub:
+		return dockerhub.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Dropbox:
+		return dropbox.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_ElevenLabs:
+		return elevenlabs.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_FastlyPersonalToken:
+		return fastly.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_FigmaPersonalAccessToken:
+		return figma.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Github:
+		return github.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Gitlab:
+		return gitlab.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Groq:
+		return groq.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_HuggingFace:
+		return huggingface.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_JDBC:
+		// JDBC connection strings name their driver, e.g. jdbc:mysql://.
+		conn := strings.ToLower(analysisInfo["connection_string"])
+		switch {
+		case strings.HasPrefix(conn, "jdbc:mysql:"):
+			return mysql.Analyzer{Cfg: cfg}
+		case strings.HasPrefix(conn, "jdbc:postgresql:"):
+			return postgres.Analyzer{Cfg: cfg}
+		}
+		return nil
+	case detectorspb.DetectorType_LaunchDarkly:
+		return launchdarkly.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Mailchimp:
+		return mailchimp.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Mailgun:
+		return mailgun.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Monday:
+		return monday.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Mux:
+		return mux.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Netlify:
+		return netlify.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Ngrok:
+		return ngrok.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Notion:
+		return notion.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_OpenAI:
+		return openai.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Opsgenie:
+		return opsgenie.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_PlaidKey:
+		return plaid.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_PlanetScale:
+		return planetscale.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Postgres:
+		return postgres.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_PosthogApp:
+		return posthog.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Postman:
+		return postman.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_PrivateKey:
+		return privatekey.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_SendGrid:
+		return sendgrid.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Shopify:
+		return shopify.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Slack:
+		return slack.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Sourcegraph:
+		return sourcegraph.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Square:
+		return square.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Stripe:
+		return stripe.Analyzer{Cfg: cfg}
+	case detectorspb.DetectorType_Twilio, detectorspb.DetectorType_TwilioApiKey:
+		return &twilio.Analyzer{Cfg: cfg}
+	default:
+		return nil
+	}
+}
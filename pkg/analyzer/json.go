@@ -0,0 +1,281 @@
+package analyzer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/airbrake"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/airtable/airtableoauth"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/airtable/airtablepat"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/anthropic"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/asana"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/bitbucket"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/databricks"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/datadog"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/digitalocean"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/dockerhub"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/dropbox"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/elevenlabs"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/fastly"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/figma"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/github"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/gitlab"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/groq"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/huggingface"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/jira"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/launchdarkly"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/mailchimp"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/mailgun"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/monday"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/mux"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/mysql"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/netlify"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/ngrok"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/notion"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/openai"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/opsgenie"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/plaid"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/planetscale"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/postgres"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/posthog"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/postman"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/privatekey"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/sendgrid"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/shopify"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/slack"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/sourcegraph"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/square"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/stripe"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers/twilio"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/config"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/reverify"
+)
+
+// keyEnvVar holds the key to analyze, so it doesn't have to be passed as an
+// argument where other users of the machine could see it.
+const keyEnvVar = "TRUFFLEHOG_ANALYZE_KEY"
+
+// keyType describes the credential of a key type for non-interactive analysis.
+type keyType struct {
+	// parts are the names of the credential info the analyzer expects, in the
+	// order they are joined with colons in a key.
+	parts       []string
+	newAnalyzer func(cfg *config.Config) analyzers.Analyzer
+}
+
+var (
+	keyParts   = []string{"key"}
+	tokenParts = []string{"token"}
+)
+
+// keyTypes are the key types by their lowercase name.
+var keyTypes = map[string]keyType{
+	"airbrake":      {keyParts, func(cfg *config.Config) analyzers.Analyzer { return airbrake.Analyzer{Cfg: cfg} }},
+	"airtableoauth": {tokenParts, func(cfg *config.Config) analyzers.Analyzer { return airtableoauth.Analyzer{Cfg: cfg} }},
+	"airtablepat":   {tokenParts, func(cfg *config.Config) analyzers.Analyzer { return airtablepat.Analyzer{Cfg: cfg} }},
+	"anthropic":     {keyParts, func(cfg *config.Config) analyzers.Analyzer { return anthropic.Analyzer{Cfg: cfg} }},
+	"asana":         {keyParts, func(cfg *config.Config) analyzers.Analyzer { return asana.Analyzer{Cfg: cfg} }},
+	"bitbucket":     {keyParts, func(cfg *config.Config) analyzers.Analyzer { return bitbucket.Analyzer{Cfg: cfg} }},
+	"databricks":    {[]string{"domain", "token"}, func(cfg *config.Config) analyzers.Analyzer { return databricks.Analyzer{Cfg: cfg} }},
+	"datadog":       {[]string{"apiKey", "appKey"}, func(cfg *config.Config) analyzers.Analyzer { return datadog.Analyzer{Cfg: cfg} }},
+	"digitalocean":  {keyParts, func(cfg *config.Config) analyzers.Analyzer { return digitalocean.Analyzer{Cfg: cfg} }},
+	"dockerhub":     {[]string{"username", "pat"}, func(cfg *config.Config) analyzers.Analyzer { return dockerhub.Analyzer{Cfg: cfg} }},
+	"dropbox":       {tokenParts, func(cfg *config.Config) analyzers.Analyzer { return dropbox.Analyzer{Cfg: cfg} }},
+	"elevenlabs":    {keyParts, func(cfg *config.Config) analyzers.Analyzer { return elevenlabs.Analyzer{Cfg: cfg} }},
+	"fastly":        {keyParts, func(cfg *config.Config) analyzers.Analyzer { return fastly.Analyzer{Cfg: cfg} }},
+	"figma":         {tokenParts, func(cfg *config.Config) analyzers.Analyzer { return figma.Analyzer{Cfg: cfg} }},
+	"github":        {keyParts, func(cfg *config.Config) analyzers.Analyzer { return github.Analyzer{Cfg: cfg} }},
+	"gitlab":        {keyParts, func(cfg *config.Config) analyzers.Analyzer { return gitlab.Analyzer{Cfg: cfg} }},
+	"groq":          {keyParts, func(cfg *config.Config) analyzers.Analyzer { return groq.Analyzer{Cfg: cfg} }},
+	"huggingface":   {keyParts, func(cfg *config.Config) analyzers.Analyzer { return huggingface.Analyzer{Cfg: cfg} }},
+	"jira":          {[]string{"domain", "email", "token"}, func(cfg *config.Config) analyzers.Analyzer { return jira.Analyzer{Cfg: cfg} }},
+	"launchdarkly":  {keyParts, func(cfg *config.Config) analyzers.Analyzer { return launchdarkly.Analyzer{Cfg: cfg} }},
+	"mailchimp":     {keyParts, func(cfg *config.Config) analyzers.Analyzer { return mailchimp.Analyzer{Cfg: cfg} }},
+	"mailgun":       {keyParts, func(cfg *config.Config) analyzers.Analyzer { return mailgun.Analyzer{Cfg: cfg} }},
+	"monday":        {keyParts, func(cfg *config.Config) analyzers.Analyzer { return monday.Analyzer{Cfg: cfg} }},
+	"mux":           {[]string{"key", "secret"}, func(cfg *config.Config) analyzers.Analyzer { return mux.Analyzer{Cfg: cfg} }},
+	"mysql":         {[]string{"connection_string"}, func(cfg *config.Config) analyzers.Analyzer { return mysql.Analyzer{Cfg: cfg} }},
+	"netlify":       {keyParts, func(cfg *config.Config) analyzers.Analyzer { return netlify.Analyzer{Cfg: cfg} }},
+	"ngrok":         {keyParts, func(cfg *config.Config) analyzers.Analyzer { return ngrok.Analyzer{Cfg: cfg} }},
+	"notion":        {keyParts, func(cfg *config.Config) analyzers.Analyzer { return notion.Analyzer{Cfg: cfg} }},
+	"openai":        {keyParts, func(cfg *config.Config) analyzers.Analyzer { return openai.Analyzer{Cfg: cfg} }},
+	"opsgenie":      {keyParts, func(cfg *config.Config) analyzers.Analyzer { return opsgenie.Analyzer{Cfg: cfg} }},
+	"plaid":         {[]string{"secret", "id", "token"}, func(cfg *config.Config) analyzers.Analyzer { return plaid.Analyzer{Cfg: cfg} }},
+	"planetscale":   {[]string{"id", "token"}, func(cfg *config.Config) analyzers.Analyzer { return planetscale.Analyzer{Cfg: cfg} }},
+	"postgres":      {[]string{"connection_string"}, func(cfg *config.Config) analyzers.Analyzer { return postgres.Analyzer{Cfg: cfg} }},
+	"posthog":       {keyParts, func(cfg *config.Config) analyzers.Analyzer { return posthog.Analyzer{Cfg: cfg} }},
+	"postman":       {keyParts, func(cfg *config.Config) analyzers.Analyzer { return postman.Analyzer{Cfg: cfg} }},
+	"privatekey":    {tokenParts, func(cfg *config.Config) analyzers.Analyzer { return privatekey.Analyzer{Cfg: cfg} }},
+	"sendgrid":      {keyParts, func(cfg *config.Config) analyzers.Analyzer { return sendgrid.Analyzer{Cfg: cfg} }},
+	"shopify":       {[]string{"key", "store_url"}, func(cfg *config.Config) analyzers.Analyzer { return shopify.Analyzer{Cfg: cfg} }},
+	"slack":         {keyParts, func(cfg *config.Config) analyzers.Analyzer { return slack.Analyzer{Cfg: cfg} }},
+	"sourcegraph":   {keyParts, func(cfg *config.Config) analyzers.Analyzer { return sourcegraph.Analyzer{Cfg: cfg} }},
+	"square":        {keyParts, func(cfg *config.Config) analyzers.Analyzer { return square.Analyzer{Cfg: cfg} }},
+	"stripe":        {keyParts, func(cfg *config.Config) analyzers.Analyzer { return stripe.Analyzer{Cfg: cfg} }},
+	"twilio":        {[]string{"sid", "key"}, func(cfg *config.Config) analyzers.Analyzer { return &twilio.Analyzer{Cfg: cfg} }},
+}
+
+// readKey returns the key to analyze from the environment, or else from r.
+func readKey(r io.Reader) (string, error) {
+	if key := os.Getenv(keyEnvVar); key != "" {
+		return key, nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("error reading key: %w", err)
+	}
+	key := strings.TrimRight(string(data), "\r\n")
+	if key == "" {
+		return "", fmt.Errorf("no key provided: pass it on stdin or in $%s", keyEnvVar)
+	}
+	return key, nil
+}
+
+// credentialInfo parses a key into the credential info of the key type. The
+// key is either a JSON object of the parts, e.g. {"sid": "...", "key": "..."},
+// or the parts joined with colons in the order of the key type.
+func (kt keyType) credentialInfo(key string) (map[string]string, error) {
+	info := make(map[string]string, len(kt.parts))
+	if strings.HasPrefix(strings.TrimSpace(key), "{") {
+		if err := json.Unmarshal([]byte(key), &info); err != nil {
+			return nil, fmt.Errorf("error decoding key: %w", err)
+		}
+	} else {
+		values := strings.SplitN(key, ":", len(kt.parts))
+		for i, value := range values {
+			info[kt.parts[i]] = value
+		}
+	}
+
+	for _, part := range kt.parts {
+		if info[part] == "" {
+			return nil, fmt.Errorf("key is missing %q, expected %s", part, strings.Join(kt.parts, ":"))
+		}
+	}
+	return info, nil
+}
+
+// analyzeJSON analyzes the key and writes the result to w as JSON.
+func analyzeJSON(ctx context.Context, a analyzers.Analyzer, credInfo map[string]string, w io.Writer) error {
+	result, err := a.Analyze(ctx, credInfo)
+	if err != nil {
+		return fmt.Errorf("error analyzing %s key: %w", a.Type(), err)
+	}
+	return json.NewEncoder(w).Encode(output.NewAnalysis(result))
+}
+
+// batchAnalysis is the analysis of a verified result. It identifies the result by
+// its detector, redacted secret and source metadata, never the secret itself.
+type batchAnalysis struct {
+	DetectorName   string
+	Redacted       string           `json:",omitempty"`
+	SourceMetadata json.RawMessage  `json:",omitempty"`
+	Analysis       *output.Analysis `json:",omitempty"`
+	AnalysisError  string           `json:",omitempty"`
+}
+
+// maxBatchLineSize bounds a line of JSON output, which contains the chunk
+// metadata and may contain large secrets such as private keys.
+const maxBatchLineSize = 16 * 1024 * 1024
+
+// analyzeBatch reads the JSON lines output of a scan from r and writes a JSON
+// line to w with the analysis of each verified result. Unverified results are
+// skipped. The credential parts of each result are recovered with
+// analysisInfo, and each credential is only analyzed once, however often it
+// was found.
+func analyzeBatch(
+	ctx context.Context,
+	r io.Reader,
+	w io.Writer,
+	analysisInfo func(context.Context, reverify.Finding) map[string]string,
+	analyzerFor func(detectorspb.DetectorType, map[string]string) analyzers.Analyzer,
+) error {
+	type analyzed struct {
+		analysis *output.Analysis
+		err      error
+	}
+	cache := make(map[string]analyzed)
+	enc := json.NewEncoder(w)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBatchLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var result reverify.Finding
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return fmt.Errorf("error decoding result on line %d: %w", line, err)
+		}
+		if !result.Verified {
+			continue
+		}
+
+		out := batchAnalysis{
+			DetectorName:   result.DetectorName,
+			Redacted:       result.Redacted,
+			SourceMetadata: result.SourceMetadata,
+		}
+		info := analysisInfo(ctx, result)
+		var a analyzers.Analyzer
+		if info != nil {
+			a = analyzerFor(result.DetectorType, info)
+		}
+		if a == nil {
+			out.AnalysisError = fmt.Sprintf("%s results can't be analyzed", result.DetectorName)
+			if err := enc.Encode(out); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := credentialKey(result.DetectorType, info)
+		cached, ok := cache[key]
+		if !ok {
+			analyzerResult, err := a.Analyze(ctx, info)
+			cached = analyzed{analysis: output.NewAnalysis(analyzerResult), err: err}
+			cache[key] = cached
+		}
+		out.Analysis = cached.analysis
+		if cached.err != nil {
+			out.AnalysisError = cached.err.Error()
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("error reading results: line exceeds %d bytes", maxBatchLineSize)
+		}
+		return fmt.Errorf("error reading results: %w", err)
+	}
+	return nil
+}
+
+// credentialKey identifies a credential by its detector and credential info.
+func credentialKey(detectorType detectorspb.DetectorType, info map[string]string) string {
+	names := make([]string, 0, len(info))
+	for name := range info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(detectorType.String())
+	for _, name := range names {
+		// Prefix each value with its length so that values can't run together.
+		fmt.Fprintf(&b, "\x00%s=%d:%s", name, len(info[name]), info[name])
+	}
+	return b.String()
+}
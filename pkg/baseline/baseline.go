@@ -0,0 +1,173 @@
+// Package baseline records known findings so that scans only report new ones.
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+// version is the version of the baseline file format.
+const version = 1
+
+// Finding is a known finding. It never contains the secret itself, only a
+// fingerprint derived from it.
+type Finding struct {
+	Fingerprint string `json:"fingerprint"`
+	Detector    string `json:"detector"`
+	Location    string `json:"location,omitempty"`
+}
+
+type file struct {
+	Version  int       `json:"version"`
+	Findings []Finding `json:"findings"`
+}
+
+// Baseline is a set of known findings. It is safe for concurrent use.
+type Baseline struct {
+	mu       sync.RWMutex
+	findings map[string]Finding
+}
+
+// New returns an empty baseline.
+func New() *Baseline {
+	return &Baseline{findings: make(map[string]Finding)}
+}
+
+// Load reads a baseline from the file at path.
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("error decoding baseline %s: %w", path, err)
+	}
+	if f.Version != version {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", f.Version, path)
+	}
+
+	b := New()
+	for _, finding := range f.Findings {
+		if finding.Fingerprint == "" {
+			return nil, errors.New("baseline finding is missing a fingerprint")
+		}
+		b.findings[finding.Fingerprint] = finding
+	}
+	return b, nil
+}
+
+// Save writes the baseline to the file at path, sorted by fingerprint so that
+// changes to it are easy to review.
+func (b *Baseline) Save(path string) error {
+	b.mu.RLock()
+	f := file{Version: version, Findings: make([]Finding, 0, len(b.findings))}
+	for _, finding := range b.findings {
+		f.Findings = append(f.Findings, finding)
+	}
+	b.mu.RUnlock()
+	sort.Slice(f.Findings, func(i, j int) bool { return f.Findings[i].Fingerprint < f.Findings[j].Fingerprint })
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error writing baseline: %w", err)
+	}
+	return nil
+}
+
+// Add records the result as a known finding.
+func (b *Baseline) Add(result detectors.ResultWithMetadata) {
+	finding := NewFinding(result)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.findings[finding.Fingerprint] = finding
+}
+
+// Contains reports whether the result is a known finding.
+func (b *Baseline) Contains(result detectors.ResultWithMetadata) bool {
+	fingerprint := NewFinding(result).Fingerprint
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.findings[fingerprint]
+	return ok
+}
+
+// Len returns the number of known findings.
+func (b *Baseline) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.findings)
+}
+
+// NewFinding returns the finding of a result. Its fingerprint is a hash of the
+// detector type, the secret and where it was found. Line numbers are not part
+// of the location, so that unrelated edits to a file don't resurface its
+// known findings.
+func NewFinding(result detectors.ResultWithMetadata) Finding {
+	secret := result.RawV2
+	if len(secret) == 0 {
+		secret = result.Raw
+	}
+	location := Location(result.SourceMetadata)
+
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(result.DetectorType.String()), secret, []byte(location)} {
+		// Prefix each part with its length so that parts can't run together.
+		_, _ = fmt.Fprintf(h, "%d:", len(part))
+		_, _ = h.Write(part)
+	}
+
+	return Finding{
+		Fingerprint: hex.EncodeToString(h.Sum(nil)),
+		Detector:    result.DetectorType.String(),
+		Location:    location,
+	}
+}
+
+// Location returns where a result was found, using the repository and file of
+// its source metadata, or its link if there is no file.
+func Location(meta *source_metadatapb.MetaData) string {
+	if meta == nil {
+		return ""
+	}
+	m := meta.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("data"))
+	if field == nil || field.Kind() != protoreflect.MessageKind {
+		return ""
+	}
+	data := m.Get(field).Message()
+
+	get := func(name protoreflect.Name) string {
+		fd := data.Descriptor().Fields().ByName(name)
+		if fd == nil || fd.Kind() != protoreflect.StringKind {
+			return ""
+		}
+		return data.Get(fd).String()
+	}
+
+	file := get("file")
+	if file == "" {
+		return get("link")
+	}
+	if repo := get("repository"); repo != "" {
+		return repo + ":" + file
+	}
+	return file
+}
@@ -0,0 +1,108 @@
+package baseline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+func gitResult(file string, line int64, raw, rawV2 string) detectors.ResultWithMetadata {
+	return detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Git{
+			Git: &source_metadatapb.Git{Repository: "https://github.com/org/repo.git", File: file, Line: line},
+		}},
+		Result: detectors.Result{DetectorType: detectorspb.DetectorType_AWS, Raw: []byte(raw), RawV2: []byte(rawV2)},
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		meta *source_metadatapb.MetaData
+		want string
+	}{
+		{name: "nil", meta: nil, want: ""},
+		{name: "repository and file", meta: gitResult("a.txt", 1, "", "").SourceMetadata, want: "https://github.com/org/repo.git:a.txt"},
+		{
+			name: "file",
+			meta: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Filesystem{
+				Filesystem: &source_metadatapb.Filesystem{File: "dir/a.txt", Line: 3},
+			}},
+			want: "dir/a.txt",
+		},
+		{
+			name: "link",
+			meta: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Slack{
+				Slack: &source_metadatapb.Slack{Link: "https://example.slack.com/archives/C1/p1"},
+			}},
+			want: "https://example.slack.com/archives/C1/p1",
+		},
+		{
+			name: "no location",
+			meta: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Stdin{Stdin: &source_metadatapb.Stdin{}}},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Location(tt.meta))
+		})
+	}
+}
+
+func TestNewFinding(t *testing.T) {
+	finding := NewFinding(gitResult("a.txt", 1, "AKIAEXAMPLE", "AKIAEXAMPLEsecret"))
+	assert.Equal(t, "AWS", finding.Detector)
+	assert.Equal(t, "https://github.com/org/repo.git:a.txt", finding.Location)
+	assert.Len(t, finding.Fingerprint, 64)
+
+	// Line numbers are ignored.
+	assert.Equal(t, finding, NewFinding(gitResult("a.txt", 10, "AKIAEXAMPLE", "AKIAEXAMPLEsecret")))
+	// RawV2 is preferred over Raw.
+	assert.Equal(t, finding, NewFinding(gitResult("a.txt", 1, "other", "AKIAEXAMPLEsecret")))
+	assert.NotEqual(t, finding, NewFinding(gitResult("a.txt", 1, "AKIAEXAMPLE", "")))
+	assert.NotEqual(t, finding, NewFinding(gitResult("b.txt", 1, "AKIAEXAMPLE", "AKIAEXAMPLEsecret")))
+}
+
+func TestBaseline_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	known := gitResult("a.txt", 1, "AKIAEXAMPLE", "AKIAEXAMPLEsecret")
+
+	b := New()
+	b.Add(known)
+	b.Add(known)
+	assert.Equal(t, 1, b.Len())
+	require.NoError(t, b.Save(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "AKIAEXAMPLE")
+
+	b, err = Load(path)
+	require.NoError(t, err)
+	assert.True(t, b.Contains(known))
+	assert.False(t, b.Contains(gitResult("a.txt", 1, "AKIANEW", "")))
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := Load(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "baseline.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2, "findings": []}`), 0o644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "unsupported baseline version")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 1, "findings": [{"detector": "AWS"}]}`), 0o644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "missing a fingerprint")
+}
@@ -0,0 +1,320 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/configpb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+// Allowlist suppresses findings that match any of its rules. A nil Allowlist
+// matches nothing.
+type Allowlist struct {
+	rules []allowlistRule
+}
+
+// allowlistRule matches a finding if all of its non-empty conditions match.
+// A condition matches if any of its values match.
+type allowlistRule struct {
+	sourceTypes  map[sourcespb.SourceType]struct{}
+	paths        []glob.Glob
+	detectors    []DetectorID
+	secretHashes map[string]struct{}
+	regexes      []*regexp.Regexp
+}
+
+// NewAllowlist compiles the allowlist rules of a config file. It returns nil
+// if there are no rules.
+func NewAllowlist(rules []*configpb.AllowlistRule) (*Allowlist, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	allowlist := &Allowlist{rules: make([]allowlistRule, 0, len(rules))}
+	for i, pbRule := range rules {
+		name := pbRule.GetDescription()
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		rule, err := newAllowlistRule(pbRule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist rule %s: %w", name, err)
+		}
+		allowlist.rules = append(allowlist.rules, rule)
+	}
+	return allowlist, nil
+}
+
+func newAllowlistRule(pbRule *configpb.AllowlistRule) (allowlistRule, error) {
+	var rule allowlistRule
+
+	for _, sourceType := range pbRule.GetSourceTypes() {
+		st, err := parseSourceType(sourceType)
+		if err != nil {
+			return rule, err
+		}
+		if rule.sourceTypes == nil {
+			rule.sourceTypes = make(map[sourcespb.SourceType]struct{})
+		}
+		rule.sourceTypes[st] = struct{}{}
+	}
+
+	for _, path := range pbRule.GetPaths() {
+		g, err := glob.Compile(path, '/')
+		if err != nil {
+			return rule, fmt.Errorf("invalid path glob %q: %w", path, err)
+		}
+		rule.paths = append(rule.paths, g)
+	}
+
+	for _, detector := range pbRule.GetDetectors() {
+		id, err := ParseDetector(detector)
+		if err != nil {
+			return rule, err
+		}
+		rule.detectors = append(rule.detectors, id)
+	}
+
+	for _, hash := range pbRule.GetSecretHashes() {
+		hash = strings.ToLower(strings.TrimSpace(hash))
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return rule, fmt.Errorf("invalid secret hash %q: must be a hex encoded SHA-256 hash", hash)
+		}
+		if rule.secretHashes == nil {
+			rule.secretHashes = make(map[string]struct{})
+		}
+		rule.secretHashes[hash] = struct{}{}
+	}
+
+	for _, pattern := range pbRule.GetRegexes() {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return rule, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		rule.regexes = append(rule.regexes, re)
+	}
+
+	if rule.sourceTypes == nil && rule.paths == nil && rule.detectors == nil &&
+		rule.secretHashes == nil && rule.regexes == nil {
+		return rule, fmt.Errorf("no conditions")
+	}
+	return rule, nil
+}
+
+// parseSourceType parses a case-insensitive source type, with or without its
+// SOURCE_TYPE_ prefix, e.g. "git" or "SOURCE_TYPE_GITHUB".
+func parseSourceType(input string) (sourcespb.SourceType, error) {
+	name := strings.ToUpper(strings.TrimSpace(input))
+	if !strings.HasPrefix(name, "SOURCE_TYPE_") {
+		name = "SOURCE_TYPE_" + name
+	}
+	v, ok := sourcespb.SourceType_value[name]
+	if !ok {
+		return 0, fmt.Errorf("unrecognized source type: %s", input)
+	}
+	return sourcespb.SourceType(v), nil
+}
+
+// Matches reports whether the result, found by the detector in data, matches
+// any of the allowlist rules.
+func (a *Allowlist) Matches(detector DetectorID, result detectors.ResultWithMetadata, data []byte) bool {
+	if a == nil {
+		return false
+	}
+	for _, rule := range a.rules {
+		if rule.matches(detector, result, data) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesChunk reports whether every finding of the detector in a chunk from
+// the source type, with the metadata, is allowlisted by a rule that has no
+// conditions on the secret. The detector doesn't need to scan such chunks.
+func (a *Allowlist) MatchesChunk(detector DetectorID, sourceType sourcespb.SourceType, meta *source_metadatapb.MetaData) bool {
+	if a == nil {
+		return false
+	}
+	path := filePath(meta)
+	for _, rule := range a.rules {
+		if rule.secretHashes == nil && rule.regexes == nil && rule.matchesChunk(detector, sourceType, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasSecretRules reports whether the findings of the detector in a chunk from
+// the source type, with the metadata, could be allowlisted by their secret
+// hash alone. Only then is it worth detecting them before verification.
+func (a *Allowlist) HasSecretRules(detector DetectorID, sourceType sourcespb.SourceType, meta *source_metadatapb.MetaData) bool {
+	if a == nil {
+		return false
+	}
+	path := filePath(meta)
+	for _, rule := range a.rules {
+		if rule.secretHashes != nil && rule.regexes == nil && rule.matchesChunk(detector, sourceType, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesSecret reports whether the result matches any of the rules without
+// context regexes. These don't depend on the data the result was found in, so
+// they can be checked before the result is verified.
+func (a *Allowlist) MatchesSecret(detector DetectorID, result detectors.ResultWithMetadata) bool {
+	if a == nil {
+		return false
+	}
+	for _, rule := range a.rules {
+		if rule.regexes == nil && rule.matches(detector, result, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesContext reports whether the result, found by the detector in data,
+// matches any of the rules with context regexes.
+func (a *Allowlist) MatchesContext(detector DetectorID, result detectors.ResultWithMetadata, data []byte) bool {
+	if a == nil {
+		return false
+	}
+	for _, rule := range a.rules {
+		if rule.regexes != nil && rule.matches(detector, result, data) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesChunk reports whether the conditions of the rule that don't depend
+// on the secret match.
+func (r *allowlistRule) matchesChunk(detector DetectorID, sourceType sourcespb.SourceType, path string) bool {
+	if r.sourceTypes != nil {
+		if _, ok := r.sourceTypes[sourceType]; !ok {
+			return false
+		}
+	}
+
+	if r.paths != nil && !matchesAnyGlob(r.paths, path) {
+		return false
+	}
+
+	if r.detectors != nil && !matchesAnyDetector(r.detectors, detector) {
+		return false
+	}
+
+	return true
+}
+
+func (r *allowlistRule) matches(detector DetectorID, result detectors.ResultWithMetadata, data []byte) bool {
+	if !r.matchesChunk(detector, result.SourceType, filePath(result.SourceMetadata)) {
+		return false
+	}
+
+	if r.secretHashes != nil && !r.matchesSecretHash(result) {
+		return false
+	}
+
+	if r.regexes != nil {
+		line := matchContext(data, result.Raw)
+		matched := false
+		for _, re := range r.regexes {
+			if re.Match(line) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesAnyGlob(globs []glob.Glob, path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, g := range globs {
+		if g.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyDetector reports whether the detector is one of ids. An ID without
+// a version matches every version of its detector.
+func matchesAnyDetector(ids []DetectorID, detector DetectorID) bool {
+	for _, id := range ids {
+		if id.ID == detector.ID && (id.Version == 0 || id.Version == detector.Version) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSecretHash reports whether the SHA-256 hash of either form of the
+// result's secret is allowlisted.
+func (r *allowlistRule) matchesSecretHash(result detectors.ResultWithMetadata) bool {
+	for _, secret := range [][]byte{result.Raw, result.RawV2} {
+		if len(secret) == 0 {
+			continue
+		}
+		sum := sha256.Sum256(secret)
+		if _, ok := r.secretHashes[hex.EncodeToString(sum[:])]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchContext returns the line of data that the secret was found on, or
+// the lines if it spans several. It returns the secret itself if it is not
+// in data, e.g. because it was found in decoded data.
+func matchContext(data, secret []byte) []byte {
+	idx := bytes.Index(data, secret)
+	if len(secret) == 0 || idx < 0 {
+		return secret
+	}
+	start := bytes.LastIndexByte(data[:idx], '\n') + 1
+	end := len(data)
+	if i := bytes.IndexByte(data[idx+len(secret):], '\n'); i >= 0 {
+		end = idx + len(secret) + i
+	}
+	return data[start:end]
+}
+
+// filePath returns the path of the file a result was found in, if its source
+// metadata has one.
+func filePath(meta *source_metadatapb.MetaData) string {
+	if meta == nil {
+		return ""
+	}
+	m := meta.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("data"))
+	if field == nil || field.Kind() != protoreflect.MessageKind {
+		return ""
+	}
+	data := m.Get(field).Message()
+	fd := data.Descriptor().Fields().ByName("file")
+	if fd == nil || fd.Kind() != protoreflect.StringKind {
+		return ""
+	}
+	return data.Get(fd).String()
+}
@@ -10,21 +10,34 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/protoyaml"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/artifactory"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/azure_repos"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/bitbucket"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/confluence"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/docker"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/ecr"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/filesystem"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/gcs"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/gerrit"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/github"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/gitlab"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/jenkins"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/jira"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/npm"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/postman"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/pypi"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/s3"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/slack"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/webhook"
 )
 
 // Config holds user supplied configuration.
 type Config struct {
 	Sources   []sources.ConfiguredSource
 	Detectors []detectors.Detector
+	// Allowlist suppresses known findings. It is nil if there are no rules.
+	Allowlist *Allowlist
 }
 
 // Read parses a given filename into a Config.
@@ -66,9 +79,15 @@ func NewYAML(input []byte) (*Config, error) {
 		sourceConfigs = append(sourceConfigs, src)
 	}
 
+	allowlist, err := NewAllowlist(inputYAML.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		Detectors: detectorConfigs,
 		Sources:   sourceConfigs,
+		Allowlist: allowlist,
 	}, nil
 }
 
@@ -103,6 +122,28 @@ func instantiateSourceFromType(sourceType string) (sources.Source, error) {
 		source = new(gcs.Source)
 	case sourcespb.SourceType_SOURCE_TYPE_DOCKER.String():
 		source = new(docker.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_BITBUCKET.String():
+		source = new(bitbucket.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_CONFLUENCE.String():
+		source = new(confluence.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_JIRA.String():
+		source = new(jira.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_SLACK.String():
+		source = new(slack.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_AZURE_REPOS.String():
+		source = new(azure_repos.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_GERRIT.String():
+		source = new(gerrit.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_JFROG_ARTIFACTORY.String():
+		source = new(artifactory.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_NPM_UNAUTHD_PACKAGES.String():
+		source = new(npm.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_PYPI_UNAUTHD_PACKAGES.String():
+		source = new(pypi.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_ECR.String():
+		source = new(ecr.Source)
+	case sourcespb.SourceType_SOURCE_TYPE_WEBHOOK.String():
+		source = new(webhook.Source)
 	default:
 		return nil, fmt.Errorf("got unexpected source type: %q", sourceType)
 	}
@@ -1,11 +1,10 @@
 package custom_detectors
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
-	"net/http"
 	"regexp"
 	"strings"
 
@@ -22,6 +21,11 @@ import (
 // for poorly defined regexps.
 const maxTotalMatches = 100
 
+// maxResponseSize is the largest verification response body that is read.
+// Larger responses can't be checked by the response matchers, so their
+// verification is indeterminate.
+const maxResponseSize = 1 << 20 // 1 MiB
+
 // CustomRegexWebhook is a CustomRegex with webhook validation that is
 // guaranteed to be valid (assuming the data is not changed after
 // initialization).
@@ -53,6 +57,21 @@ func NewWebhookCustomRegex(pb *custom_detectorspb.CustomRegex) (*CustomRegexWebh
 		if err := ValidateVerifyHeaders(verify.Headers); err != nil {
 			return nil, err
 		}
+		if err := ValidateVerifyRanges(verify.SuccessRanges); err != nil {
+			return nil, err
+		}
+		if err := ValidateResponseMatchers(verify.ResponseMatchers); err != nil {
+			return nil, err
+		}
+		if err := ValidateVerifyMethod(verify.Method, verify.Body); err != nil {
+			return nil, err
+		}
+		if verify.Method != "" {
+			templates := append([]string{verify.Endpoint, verify.Body}, verify.Headers...)
+			if err := ValidateRegexVars(pb.Regex, templates...); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// TODO: Copy only necessary data out of pb.
@@ -194,6 +213,7 @@ func (c *CustomRegexWebhook) createResults(ctx context.Context, match map[string
 	}
 
 	var raw string
+	secrets := make([]string, 0, len(match))
 	for key, values := range match {
 		// values[0] contains the entire regex match.
 		secret := values[0]
@@ -201,6 +221,7 @@ func (c *CustomRegexWebhook) createResults(ctx context.Context, match map[string
 			secret = values[1]
 		}
 		raw += secret
+		secrets = append(secrets, secret)
 
 		// if the match is of the primary regex, set it's value as primary secret value in result
 		if c.PrimaryRegexName == key {
@@ -218,7 +239,7 @@ func (c *CustomRegexWebhook) createResults(ctx context.Context, match map[string
 			return nil
 		}
 	}
-	// Verify via webhook.
+	// Verify via webhook or templated request.
 	jsonBody, err := json.Marshal(map[string]map[string][]string{
 		c.GetName(): match,
 	})
@@ -227,42 +248,23 @@ func (c *CustomRegexWebhook) createResults(ctx context.Context, match map[string
 		// disrupt other verification.
 		return nil
 	}
-	// Try each config until we successfully verify.
+	// Try each config until we successfully verify. If none do and any of
+	// them could not determine the secret's validity, the result is unknown.
+	var verificationErr error
 	for _, verifyConfig := range c.GetVerify() {
 		if common.IsDone(ctx) {
 			// TODO: Log we're possibly leaving out results.
 			return ctx.Err()
 		}
-		req, err := http.NewRequestWithContext(ctx, "POST", verifyConfig.GetEndpoint(), bytes.NewReader(jsonBody))
-		if err != nil {
-			continue
-		}
-		for _, header := range verifyConfig.GetHeaders() {
-			key, value, found := strings.Cut(header, ":")
-			if !found {
-				// Should be unreachable due to validation.
-				continue
-			}
-			req.Header.Add(key, strings.TrimLeft(value, "\t\n\v\f\r "))
-		}
-		resp, err := httpClient.Do(req)
+		verified, body, err := verifyMatch(ctx, verifyConfig, match, jsonBody)
 		if err != nil {
+			verificationErr = err
 			continue
 		}
-		defer func() {
-			_, _ = io.Copy(io.Discard, resp.Body)
-			_ = resp.Body.Close()
-		}()
-
-		if resp.StatusCode == http.StatusOK {
+		if verified {
 			// mark the result as verified
 			result.Verified = true
 
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				continue
-			}
-
 			// TODO: handle different content-type responses seperatly when implement custom detector configurations
 			responseStr := string(body)
 			// truncate to 200 characters if response length exceeds 200
@@ -276,6 +278,9 @@ func (c *CustomRegexWebhook) createResults(ctx context.Context, match map[string
 			break
 		}
 	}
+	if !result.Verified {
+		result.SetVerificationError(verificationErr, secrets...)
+	}
 
 	select {
 	case <-ctx.Done():
@@ -285,6 +290,34 @@ func (c *CustomRegexWebhook) createResults(ctx context.Context, match map[string
 	}
 }
 
+// verifyMatch sends the verification request for the match and checks its
+// response. Timeouts, connection failures and 5xx responses are returned as
+// errors because they do not tell us whether the secret is valid.
+func verifyMatch(ctx context.Context, verifyConfig *custom_detectorspb.VerifierConfig, match map[string][]string, jsonBody []byte) (bool, []byte, error) {
+	req, err := newVerifyRequest(ctx, verifyConfig, match, jsonBody)
+	if err != nil {
+		return false, nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+		_ = resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return false, nil, err
+	}
+	if len(body) > maxResponseSize {
+		return false, nil, fmt.Errorf("verification response is larger than %d bytes", maxResponseSize)
+	}
+	verified, err := checkResponse(verifyConfig, resp.StatusCode, body)
+	return verified, body, err
+}
+
 func (c *CustomRegexWebhook) Keywords() []string {
 	return c.GetKeywords()
 }
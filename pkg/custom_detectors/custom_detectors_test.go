@@ -1,11 +1,18 @@
 package custom_detectors
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/custom_detectorspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/protoyaml"
 )
@@ -54,7 +61,12 @@ verify:
 - endpoint: http://localhost:8000/
   unsafe: true
   headers:
-  - 'Authorization: Bearer token'`
+  - 'Authorization: Bearer token'
+  successRanges:
+  - 200-299
+  response_matchers:
+  - json_path: data.active
+  - regex: '"scope":\s*"admin"'`
 
 	var got custom_detectorspb.CustomRegex
 	assert.NoError(t, protoyaml.UnmarshalStrict([]byte(testCustomRegexWebhookYaml), &got))
@@ -68,6 +80,10 @@ verify:
 	assert.Equal(t, "http://localhost:8000/", got.Verify[0].Endpoint)
 	assert.Equal(t, true, got.Verify[0].Unsafe)
 	assert.Equal(t, []string{"Authorization: Bearer token"}, got.Verify[0].Headers)
+	assert.Equal(t, []string{"200-299"}, got.Verify[0].SuccessRanges)
+	assert.Equal(t, 2, len(got.Verify[0].ResponseMatchers))
+	assert.Equal(t, "data.active", got.Verify[0].ResponseMatchers[0].JsonPath)
+	assert.Equal(t, `"scope":\s*"admin"`, got.Verify[0].ResponseMatchers[1].Regex)
 }
 
 // TestCustomDetectorsParsing tests the full `detectors` configuration.
@@ -227,6 +243,355 @@ func TestDetectorPrimarySecret(t *testing.T) {
 	assert.Equal(t, "secret_YI7C90ACY1_yy", results[0].GetPrimarySecretValue())
 }
 
+func TestDetectorVerification(t *testing.T) {
+	client := httpClient
+	httpClient = common.SaneHttpClientTimeOut(500 * time.Millisecond)
+	t.Cleanup(func() { httpClient = client })
+
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		delay        time.Duration
+		config       *custom_detectorspb.VerifierConfig
+		wantVerified bool
+		wantErr      bool
+	}{
+		{
+			name:         "default success range",
+			status:       http.StatusOK,
+			config:       &custom_detectorspb.VerifierConfig{},
+			wantVerified: true,
+		},
+		{
+			name:   "outside default success range",
+			status: http.StatusNoContent,
+			config: &custom_detectorspb.VerifierConfig{},
+		},
+		{
+			name:         "configured success range",
+			status:       http.StatusNoContent,
+			config:       &custom_detectorspb.VerifierConfig{SuccessRanges: []string{"201-204"}},
+			wantVerified: true,
+		},
+		{
+			name:   "outside configured success range",
+			status: http.StatusOK,
+			config: &custom_detectorspb.VerifierConfig{SuccessRanges: []string{"201-204"}},
+		},
+		{
+			name:   "unauthorized",
+			status: http.StatusUnauthorized,
+			config: &custom_detectorspb.VerifierConfig{},
+		},
+		{
+			name:    "server error",
+			status:  http.StatusBadGateway,
+			config:  &custom_detectorspb.VerifierConfig{},
+			wantErr: true,
+		},
+		{
+			name:         "server error in success range",
+			status:       http.StatusServiceUnavailable,
+			config:       &custom_detectorspb.VerifierConfig{SuccessRanges: []string{"200", "503"}},
+			wantVerified: true,
+		},
+		{
+			name:    "timeout",
+			status:  http.StatusOK,
+			delay:   time.Second,
+			config:  &custom_detectorspb.VerifierConfig{},
+			wantErr: true,
+		},
+		{
+			name:   "json path matches",
+			status: http.StatusOK,
+			body:   `{"active": true, "token": {"scopes": ["read", "write"]}}`,
+			config: &custom_detectorspb.VerifierConfig{ResponseMatchers: []*custom_detectorspb.ResponseMatcher{
+				{JsonPath: "active"},
+				{JsonPath: "token.scopes.1", Regex: "^write$"},
+			}},
+			wantVerified: true,
+		},
+		{
+			name:   "json path is false",
+			status: http.StatusOK,
+			body:   `{"active": false}`,
+			config: &custom_detectorspb.VerifierConfig{ResponseMatchers: []*custom_detectorspb.ResponseMatcher{
+				{JsonPath: "active"},
+			}},
+		},
+		{
+			name:   "json path is missing",
+			status: http.StatusOK,
+			body:   `{"error": "invalid_token"}`,
+			config: &custom_detectorspb.VerifierConfig{ResponseMatchers: []*custom_detectorspb.ResponseMatcher{
+				{JsonPath: "active"},
+			}},
+		},
+		{
+			name:   "json path on invalid json",
+			status: http.StatusOK,
+			body:   `<html>maintenance</html>`,
+			config: &custom_detectorspb.VerifierConfig{ResponseMatchers: []*custom_detectorspb.ResponseMatcher{
+				{JsonPath: "active"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:   "body regex does not match",
+			status: http.StatusOK,
+			body:   `status=revoked`,
+			config: &custom_detectorspb.VerifierConfig{ResponseMatchers: []*custom_detectorspb.ResponseMatcher{
+				{Regex: "status=(active|valid)"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(tt.delay):
+				case <-r.Context().Done():
+					return
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			tt.config.Endpoint = ts.URL
+			tt.config.Unsafe = true
+			detector, err := NewWebhookCustomRegex(&custom_detectorspb.CustomRegex{
+				Name:     "test",
+				Keywords: []string{"token"},
+				Regex:    map[string]string{"token": "token_([a-z0-9]{8})"},
+				Verify:   []*custom_detectorspb.VerifierConfig{tt.config},
+			})
+			require.NoError(t, err)
+
+			results, err := detector.FromData(context.Background(), true, []byte("token_abcd1234"))
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, tt.wantVerified, results[0].Verified)
+			assert.Equal(t, tt.wantErr, results[0].VerificationError() != nil, results[0].VerificationError())
+			if tt.wantErr {
+				assert.NotContains(t, results[0].VerificationError().Error(), "abcd1234")
+			}
+		})
+	}
+}
+
+func TestDetectorVerificationFallback(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"active": true}`))
+	}))
+	defer up.Close()
+
+	detector, err := NewWebhookCustomRegex(&custom_detectorspb.CustomRegex{
+		Name:     "test",
+		Keywords: []string{"token"},
+		Regex:    map[string]string{"token": "token_([a-z0-9]{8})"},
+		Verify: []*custom_detectorspb.VerifierConfig{
+			{Endpoint: down.URL, Unsafe: true},
+			{Endpoint: up.URL, Unsafe: true, ResponseMatchers: []*custom_detectorspb.ResponseMatcher{{JsonPath: "active"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	results, err := detector.FromData(context.Background(), true, []byte("token_abcd1234"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Verified)
+	assert.NoError(t, results[0].VerificationError())
+	assert.Equal(t, `{"active": true}`, results[0].ExtraData["response"])
+}
+
+func TestDetectorVerificationLargeResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"active": true, "padding": "`))
+		_, _ = w.Write(bytes.Repeat([]byte("a"), maxResponseSize))
+		_, _ = w.Write([]byte(`"}`))
+	}))
+	defer ts.Close()
+
+	detector, err := NewWebhookCustomRegex(&custom_detectorspb.CustomRegex{
+		Name:     "test",
+		Keywords: []string{"token"},
+		Regex:    map[string]string{"token": "token_([a-z0-9]{8})"},
+		Verify: []*custom_detectorspb.VerifierConfig{
+			{Endpoint: ts.URL, Unsafe: true, ResponseMatchers: []*custom_detectorspb.ResponseMatcher{{JsonPath: "active"}}},
+		},
+	})
+	require.NoError(t, err)
+
+	results, err := detector.FromData(context.Background(), true, []byte("token_abcd1234"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Verified)
+	assert.Error(t, results[0].VerificationError())
+}
+
+func TestDetectorTemplatedVerification(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPut ||
+			r.URL.Path != "/users/ALPHA10100/tokens" ||
+			r.Header.Get("Authorization") != "Bearer secret_YI7C90ACY1_yy" ||
+			string(body) != `{"id": "ALPHA10100"}` {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"valid": true}`))
+	}))
+	defer ts.Close()
+
+	detector, err := NewWebhookCustomRegex(&custom_detectorspb.CustomRegex{
+		Name:             "test",
+		Keywords:         []string{"secret"},
+		Regex:            map[string]string{"id": "id_([A-Z0-9]{10})_yy", "secret": "secret_[A-Z0-9]{10}_yy"},
+		PrimaryRegexName: "id",
+		Verify: []*custom_detectorspb.VerifierConfig{{
+			Endpoint:         ts.URL + "/users/{id.1}/tokens",
+			Unsafe:           true,
+			Method:           http.MethodPut,
+			Headers:          []string{"Authorization: Bearer {secret}"},
+			Body:             `{"id": "{id.1}"}`,
+			SuccessRanges:    []string{"200-299"},
+			ResponseMatchers: []*custom_detectorspb.ResponseMatcher{{JsonPath: "valid"}},
+		}},
+	})
+	require.NoError(t, err)
+
+	results, err := detector.FromData(context.Background(), true, []byte(`
+	id_ALPHA10100_yy secret_YI7C90ACY1_yy
+	id_BRAVO20200_yy
+	`))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	verified := map[string]bool{}
+	for _, r := range results {
+		verified[r.GetPrimarySecretValue()] = r.Verified
+	}
+	assert.Equal(t, map[string]bool{"ALPHA10100": true, "BRAVO20200": false}, verified)
+}
+
+func TestNewVerifyRequest_HostileCaptures(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *custom_detectorspb.VerifierConfig
+		capture  string
+		wantURL  string
+		wantBody string
+		wantErr  bool
+	}{
+		{
+			name:    "path",
+			config:  &custom_detectorspb.VerifierConfig{Method: http.MethodGet, Endpoint: "https://api.example.com/users/{id}"},
+			capture: "x@evil.com/../admin?a=1&b=2;c+d #e",
+			wantURL: "https://api.example.com/users/x%40evil.com%2F..%2Fadmin%3Fa%3D1%26b%3D2%3Bc%2Bd%20%23e",
+		},
+		{
+			name:    "host",
+			config:  &custom_detectorspb.VerifierConfig{Method: http.MethodGet, Endpoint: "https://{id}.example.com/"},
+			capture: "evil.com@tenant",
+			wantErr: true,
+		},
+		{
+			name:    "query",
+			config:  &custom_detectorspb.VerifierConfig{Method: http.MethodGet, Endpoint: "https://api.example.com/check?key={id}&v=1"},
+			capture: "abc&admin=true;x=y+z",
+			wantURL: "https://api.example.com/check?key=abc%26admin%3Dtrue%3Bx%3Dy%2Bz&v=1",
+		},
+		{
+			name: "json body",
+			config: &custom_detectorspb.VerifierConfig{
+				Method:   http.MethodPost,
+				Endpoint: "https://api.example.com/check",
+				Headers:  []string{"Content-Type: application/json"},
+				Body:     `{"key": "{id}"}`,
+			},
+			capture:  `abc", "admin": true, "x": "\`,
+			wantURL:  "https://api.example.com/check",
+			wantBody: `{"key": "abc\", \"admin\": true, \"x\": \"\\"}`,
+		},
+		{
+			name: "form body",
+			config: &custom_detectorspb.VerifierConfig{
+				Method:   http.MethodPost,
+				Endpoint: "https://api.example.com/check",
+				Headers:  []string{"Content-Type: application/x-www-form-urlencoded"},
+				Body:     "key={id}",
+			},
+			capture:  "abc&admin=true",
+			wantURL:  "https://api.example.com/check",
+			wantBody: "key=abc%26admin%3Dtrue",
+		},
+		{
+			name: "header",
+			config: &custom_detectorspb.VerifierConfig{
+				Method:   http.MethodGet,
+				Endpoint: "https://api.example.com/check",
+				Headers:  []string{"Authorization: Bearer {id}"},
+			},
+			capture: "abc\r\nX-Admin: true",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := newVerifyRequest(context.Background(), tt.config, map[string][]string{"id": {tt.capture}}, nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantURL, req.URL.String())
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body))
+		})
+	}
+}
+
+func TestNewWebhookCustomRegex_InvalidVerifier(t *testing.T) {
+	_, err := NewWebhookCustomRegex(&custom_detectorspb.CustomRegex{
+		Name:     "test",
+		Keywords: []string{"token"},
+		Regex:    map[string]string{"token": "token_([a-z0-9]{8})"},
+		Verify: []*custom_detectorspb.VerifierConfig{
+			{Endpoint: "https://localhost", SuccessRanges: []string{"299-200"}},
+		},
+	})
+	assert.Error(t, err)
+
+	_, err = NewWebhookCustomRegex(&custom_detectorspb.CustomRegex{
+		Name:     "test",
+		Keywords: []string{"token"},
+		Regex:    map[string]string{"token": "token_([a-z0-9]{8})"},
+		Verify: []*custom_detectorspb.VerifierConfig{
+			{Endpoint: "https://localhost", ResponseMatchers: []*custom_detectorspb.ResponseMatcher{{}}},
+		},
+	})
+	assert.Error(t, err)
+
+	_, err = NewWebhookCustomRegex(&custom_detectorspb.CustomRegex{
+		Name:     "test",
+		Keywords: []string{"token"},
+		Regex:    map[string]string{"token": "token_([a-z0-9]{8})"},
+		Verify: []*custom_detectorspb.VerifierConfig{
+			{Endpoint: "https://localhost/{unknown}", Method: http.MethodGet},
+		},
+	})
+	assert.Error(t, err)
+}
+
 func BenchmarkProductIndices(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		_ = productIndices(3, 2, 6)
@@ -42,3 +42,33 @@ func NewRegexVarString(original string) RegexVarString {
 		variables: variables,
 	}
 }
+
+// Render replaces each {name.group} variable with the group of the named
+// match, passing it through escape if it is not nil. Variables without a
+// match are left untouched and groups out of range are replaced with an
+// empty string.
+func (r RegexVarString) Render(match map[string][]string, escape func(string) string) string {
+	return nameGroupRegex.ReplaceAllStringFunc(r.original, func(variable string) string {
+		submatch := nameGroupRegex.FindStringSubmatch(variable)
+		name, group := submatch[1], 0
+		if len(submatch[2]) > 1 {
+			g, err := strconv.Atoi(strings.TrimSpace(submatch[2][1:]))
+			if err != nil {
+				return variable
+			}
+			group = g
+		}
+
+		values, ok := match[name]
+		if !ok {
+			return variable
+		}
+		if group >= len(values) {
+			return ""
+		}
+		if escape == nil {
+			return values[group]
+		}
+		return escape(values[group])
+	})
+}
@@ -1,6 +1,7 @@
 package custom_detectors
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,3 +75,53 @@ func TestVarString(t *testing.T) {
 		})
 	}
 }
+
+func TestVarStringRender(t *testing.T) {
+	match := map[string][]string{
+		"id":     {"id=abc", "abc"},
+		"secret": {"s3cr3t/+"},
+	}
+	tests := []struct {
+		name   string
+		input  string
+		escape func(string) string
+		want   string
+	}{
+		{
+			name:  "no variables",
+			input: `{"static": true}`,
+			want:  `{"static": true}`,
+		},
+		{
+			name:  "default group",
+			input: "Bearer {secret}",
+			want:  "Bearer s3cr3t/+",
+		},
+		{
+			name:  "explicit groups",
+			input: "{ id . 0 } {id.1}",
+			want:  "id=abc abc",
+		},
+		{
+			name:  "group out of range",
+			input: "[{secret.1}]",
+			want:  "[]",
+		},
+		{
+			name:  "unknown name",
+			input: "{other}",
+			want:  "{other}",
+		},
+		{
+			name:   "escaped",
+			input:  "https://example.com/{id.1}/{secret}",
+			escape: url.PathEscape,
+			want:   "https://example.com/abc/s3cr3t%2F+",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewRegexVarString(tt.input).Render(match, tt.escape))
+		})
+	}
+}
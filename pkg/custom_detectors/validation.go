@@ -2,9 +2,12 @@ package custom_detectors
 
 import (
 	"fmt"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/custom_detectorspb"
 )
 
 func ValidateKeywords(keywords []string) error {
@@ -43,6 +46,20 @@ func ValidateVerifyEndpoint(endpoint string, unsafe bool) error {
 	return nil
 }
 
+func ValidateVerifyMethod(method, body string) error {
+	switch method {
+	case "":
+		if body != "" {
+			return fmt.Errorf("body requires a method")
+		}
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return fmt.Errorf("invalid http method %q", method)
+	}
+	return nil
+}
+
 func ValidateVerifyHeaders(headers []string) error {
 	for _, header := range headers {
 		if !strings.Contains(header, ":") {
@@ -96,6 +113,25 @@ func ValidateVerifyRanges(ranges []string) error {
 	return nil
 }
 
+func ValidateResponseMatchers(matchers []*custom_detectorspb.ResponseMatcher) error {
+	for i, matcher := range matchers {
+		if matcher.GetJsonPath() == "" && matcher.GetRegex() == "" {
+			return fmt.Errorf("response matcher %d must have a json_path or a regex", i)
+		}
+		if path := matcher.GetJsonPath(); path != "" {
+			for _, key := range strings.Split(path, ".") {
+				if key == "" {
+					return fmt.Errorf("json_path %q contains an empty key", path)
+				}
+			}
+		}
+		if _, err := regexp.Compile(matcher.GetRegex()); err != nil {
+			return fmt.Errorf("response matcher %d regex: %w", i, err)
+		}
+	}
+	return nil
+}
+
 func ValidateRegexVars(regex map[string]string, body ...string) error {
 	for _, b := range body {
 		matches := NewRegexVarString(b).variables
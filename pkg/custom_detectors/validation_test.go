@@ -1,6 +1,10 @@
 package custom_detectors
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/custom_detectorspb"
+)
 
 func TestCustomDetectorsKeywordValidation(t *testing.T) {
 	tests := []struct {
@@ -153,6 +157,50 @@ func TestCustomDetectorsVerifyHeadersValidation(t *testing.T) {
 	}
 }
 
+func TestCustomDetectorsVerifyMethodValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		wantErr bool
+	}{
+		{
+			name:    "Test webhook",
+			wantErr: false,
+		},
+		{
+			name:    "Test templated GET",
+			method:  "GET",
+			wantErr: false,
+		},
+		{
+			name:    "Test templated POST with body",
+			method:  "POST",
+			body:    `{"token": "{token}"}`,
+			wantErr: false,
+		},
+		{
+			name:    "Test body without method",
+			body:    `{"token": "{token}"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Test invalid method",
+			method:  "get",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateVerifyMethod(tt.method, tt.body)
+
+			if (got != nil && !tt.wantErr) || (got == nil && tt.wantErr) {
+				t.Errorf("ValidateVerifyMethod() error = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestCustomDetectorsVerifyRangeValidation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -196,6 +244,48 @@ func TestCustomDetectorsVerifyRangeValidation(t *testing.T) {
 	}
 }
 
+func TestCustomDetectorsResponseMatchersValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchers []*custom_detectorspb.ResponseMatcher
+		wantErr  bool
+	}{
+		{
+			name: "Test json path and regex",
+			matchers: []*custom_detectorspb.ResponseMatcher{
+				{JsonPath: "active"},
+				{JsonPath: "data.scopes.0", Regex: "^read$"},
+				{Regex: "valid"},
+			},
+			wantErr: false,
+		},
+		{
+			name:     "Test empty matcher",
+			matchers: []*custom_detectorspb.ResponseMatcher{{}},
+			wantErr:  true,
+		},
+		{
+			name:     "Test empty json path key",
+			matchers: []*custom_detectorspb.ResponseMatcher{{JsonPath: "data..active"}},
+			wantErr:  true,
+		},
+		{
+			name:     "Test invalid regex",
+			matchers: []*custom_detectorspb.ResponseMatcher{{Regex: "(unclosed"}},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateResponseMatchers(tt.matchers)
+
+			if (got != nil && !tt.wantErr) || (got == nil && tt.wantErr) {
+				t.Errorf("ValidateResponseMatchers() error = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestCustomDetectorsVerifyRegexVarsValidation(t *testing.T) {
 	tests := []struct {
 		name    string
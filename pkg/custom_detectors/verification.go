@@ -0,0 +1,237 @@
+package custom_detectors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/custom_detectorspb"
+)
+
+// defaultSuccessRanges are used when a verifier does not configure any.
+var defaultSuccessRanges = []string{"200"}
+
+// newVerifyRequest builds the request to verify the match. Verifiers with a
+// method send a request templated from the named regex matches; the endpoint,
+// headers and body may contain {name.group} variables. Others POST the JSON
+// encoded matches to a webhook.
+func newVerifyRequest(ctx context.Context, verifyConfig *custom_detectorspb.VerifierConfig, match map[string][]string, jsonBody []byte) (*http.Request, error) {
+	method, endpoint, body := http.MethodPost, verifyConfig.GetEndpoint(), jsonBody
+	headers := verifyConfig.GetHeaders()
+	if verifyConfig.GetMethod() != "" {
+		method = verifyConfig.GetMethod()
+		endpoint = renderEndpoint(endpoint, match)
+		headers = make([]string, 0, len(verifyConfig.GetHeaders()))
+		for _, header := range verifyConfig.GetHeaders() {
+			header = NewRegexVarString(header).Render(match, nil)
+			// A line break in a captured value would end the header early.
+			if strings.ContainsAny(header, "\r\n") {
+				return nil, fmt.Errorf("verification header %q contains a line break", strings.SplitN(header, ":", 2)[0])
+			}
+			headers = append(headers, header)
+		}
+		body = []byte(NewRegexVarString(verifyConfig.GetBody()).Render(match, bodyEscaper(headers)))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range headers {
+		key, value, found := strings.Cut(header, ":")
+		if !found {
+			// Should be unreachable due to validation.
+			continue
+		}
+		req.Header.Add(key, strings.TrimLeft(value, "\t\n\v\f\r "))
+	}
+	return req, nil
+}
+
+// renderEndpoint renders the endpoint template, escaping the values so they
+// can't change the structure of the URL, e.g. add query parameters or user
+// info.
+func renderEndpoint(endpoint string, match map[string][]string) string {
+	base, query, hasQuery := strings.Cut(endpoint, "?")
+	rendered := NewRegexVarString(base).Render(match, pathEscape)
+	if hasQuery {
+		rendered += "?" + NewRegexVarString(query).Render(match, url.QueryEscape)
+	}
+	return rendered
+}
+
+// pathEscape escapes everything but the unreserved characters, unlike
+// url.PathEscape, which keeps characters such as '@', '&' and '='.
+func pathEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// bodyEscaper returns the escaper for values rendered in the body, based on
+// its content type: values are escaped as JSON string contents or form
+// values, and left as is for other content types.
+func bodyEscaper(headers []string) func(string) string {
+	var contentType string
+	for _, header := range headers {
+		if key, value, found := strings.Cut(header, ":"); found && strings.EqualFold(strings.TrimSpace(key), "Content-Type") {
+			contentType = strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	switch {
+	case strings.Contains(contentType, "json"):
+		return jsonEscape
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		return url.QueryEscape
+	default:
+		return nil
+	}
+}
+
+// jsonEscape escapes s to be used inside a JSON string.
+func jsonEscape(s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	}
+	return string(b[1 : len(b)-1])
+}
+
+// checkResponse decides whether the verifier's response proves the secret is
+// valid. A secret is valid if the status code is within the success ranges
+// and the body satisfies every response matcher. An error is returned when
+// the response is indeterminate, e.g. the server failed with a 5xx status.
+func checkResponse(verifyConfig *custom_detectorspb.VerifierConfig, statusCode int, body []byte) (bool, error) {
+	ranges := verifyConfig.GetSuccessRanges()
+	if len(ranges) == 0 {
+		ranges = defaultSuccessRanges
+	}
+	if !statusInRanges(statusCode, ranges) {
+		if statusCode >= http.StatusInternalServerError {
+			return false, fmt.Errorf("unexpected HTTP response status %d", statusCode)
+		}
+		return false, nil
+	}
+
+	for _, matcher := range verifyConfig.GetResponseMatchers() {
+		ok, err := matchResponse(matcher, body)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// statusInRanges reports whether the status code is within any of the ranges,
+// which are either a single code ("200") or an inclusive range ("200-299").
+// Malformed ranges never match; they are rejected by ValidateVerifyRanges.
+func statusInRanges(statusCode int, ranges []string) bool {
+	for _, r := range ranges {
+		lower, upper, found := strings.Cut(r, "-")
+		if !found {
+			upper = lower
+		}
+		lowerBound, err := strconv.Atoi(strings.TrimSpace(lower))
+		if err != nil {
+			continue
+		}
+		upperBound, err := strconv.Atoi(strings.TrimSpace(upper))
+		if err != nil {
+			continue
+		}
+		if statusCode >= lowerBound && statusCode <= upperBound {
+			return true
+		}
+	}
+	return false
+}
+
+// matchResponse applies a single matcher to the response body. With a JSON
+// path, the value at the path must be present and, without a regex, truthy.
+// With a regex, the value at the path (or the whole body if there is no path)
+// must match it. An error is returned if the body is not the expected JSON.
+func matchResponse(matcher *custom_detectorspb.ResponseMatcher, body []byte) (bool, error) {
+	subject := string(body)
+	if path := matcher.GetJsonPath(); path != "" {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return false, fmt.Errorf("response body is not valid JSON: %w", err)
+		}
+		value, ok := lookupJSONPath(doc, path)
+		if !ok {
+			return false, nil
+		}
+		if matcher.GetRegex() == "" {
+			return isTruthy(value), nil
+		}
+		subject = jsonValueString(value)
+	}
+
+	regex, err := regexp.Compile(matcher.GetRegex())
+	if err != nil {
+		// This will only happen if the regex is invalid.
+		return false, err
+	}
+	return regex.MatchString(subject), nil
+}
+
+// lookupJSONPath walks a decoded JSON document along a dot separated path of
+// object keys and array indices, e.g. "data.tokens.0.active".
+func lookupJSONPath(doc any, path string) (any, bool) {
+	value := doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func isTruthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// jsonValueString returns strings as-is and every other JSON value encoded,
+// so a regex can match e.g. "true" or "42".
+func jsonValueString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
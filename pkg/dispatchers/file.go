@@ -0,0 +1,129 @@
+package dispatchers
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+// JSONLFile appends each result as a line of JSON to a file. Once the file
+// would grow beyond its maximum size, it is rotated: it is renamed to
+// "<path>.1", older files are shifted to "<path>.2" and so on, and the oldest
+// is removed.
+type JSONLFile struct {
+	path string
+	// maxSize is the size in bytes the file is rotated at. Zero disables
+	// rotation.
+	maxSize int64
+	// maxBackups is the number of rotated files that are kept.
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewJSONLFile opens the file at path for appending results, rotating it at
+// maxSize bytes and keeping maxBackups rotated files.
+func NewJSONLFile(path string, maxSize int64, maxBackups int) (*JSONLFile, error) {
+	if maxSize < 0 || maxBackups < 0 {
+		return nil, fmt.Errorf("invalid rotation settings for %s: max size and backups must not be negative", path)
+	}
+	f := &JSONLFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Dispatch appends the result to the file.
+func (f *JSONLFile) Dispatch(_ context.Context, result detectors.ResultWithMetadata) error {
+	line, err := output.MarshalJSON(&result)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return fmt.Errorf("error writing result to %s: file is closed", f.path)
+	}
+	// A result larger than the maximum size is written to an empty file
+	// rather than rotated forever.
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(line)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("error writing result to %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// Close closes the file.
+func (f *JSONLFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *JSONLFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening results file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error opening results file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate moves the current file to the first backup and opens a new one. It
+// must be called with the lock held.
+func (f *JSONLFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("error closing results file: %w", err)
+	}
+	f.file = nil
+
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error rotating results file: %w", err)
+		}
+		return f.open()
+	}
+
+	if err := os.Remove(f.backup(f.maxBackups)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error rotating results file: %w", err)
+	}
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(f.backup(i), f.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error rotating results file: %w", err)
+		}
+	}
+	if err := os.Rename(f.path, f.backup(1)); err != nil {
+		return fmt.Errorf("error rotating results file: %w", err)
+	}
+	return f.open()
+}
+
+func (f *JSONLFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
@@ -0,0 +1,60 @@
+package dispatchers
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines++
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestJSONLFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	result := testResult()
+	line, err := output.MarshalJSON(&result)
+	require.NoError(t, err)
+	lineSize := int64(len(line) + 1)
+
+	// Fit two results per file and keep two backups.
+	file, err := NewJSONLFile(path, 2*lineSize, 2)
+	require.NoError(t, err)
+	for i := 0; i < 7; i++ {
+		require.NoError(t, file.Dispatch(ctx, result))
+	}
+	require.NoError(t, file.Close())
+
+	assert.Equal(t, 1, countLines(t, path))
+	assert.Equal(t, 2, countLines(t, path+".1"))
+	assert.Equal(t, 2, countLines(t, path+".2"))
+	assert.NoFileExists(t, path+".3")
+
+	// Results are appended to an existing file.
+	file, err = NewJSONLFile(path, 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, file.Dispatch(ctx, result))
+	require.NoError(t, file.Close())
+	assert.Equal(t, 2, countLines(t, path))
+
+	assert.Error(t, file.Dispatch(ctx, result))
+}
@@ -0,0 +1,97 @@
+// Package dispatchers provides engine.ResultsDispatcher implementations that
+// send results somewhere other than the console.
+package dispatchers
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/engine"
+)
+
+// queueSize is the number of results buffered for each dispatcher of a Multi.
+const queueSize = 1024
+
+// Multi fans each result out to several dispatchers. Each dispatcher is fed
+// from its own queue by its own goroutine, so that a slow sink, e.g. a webhook
+// retrying with backoff, doesn't hold up the others until its queue is full.
+type Multi struct {
+	dispatchers []engine.ResultsDispatcher
+	queues      []chan queuedResult
+	wg          sync.WaitGroup
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+type queuedResult struct {
+	ctx    context.Context
+	result detectors.ResultWithMetadata
+}
+
+// NewMulti creates a Multi that dispatches results to all of the dispatchers.
+// A single dispatcher is called directly, without a queue.
+func NewMulti(dispatchers ...engine.ResultsDispatcher) *Multi {
+	m := &Multi{dispatchers: dispatchers}
+	if len(dispatchers) == 1 {
+		return m
+	}
+
+	m.queues = make([]chan queuedResult, len(dispatchers))
+	for i, d := range dispatchers {
+		queue := make(chan queuedResult, queueSize)
+		m.queues[i] = queue
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for item := range queue {
+				if err := d.Dispatch(item.ctx, item.result); err != nil {
+					item.ctx.Logger().Error(err, "error dispatching result")
+				}
+			}
+		}()
+	}
+	return m
+}
+
+// Dispatch queues the result for every dispatcher. Their errors are logged,
+// as they happen after Dispatch returns, unless there's a single dispatcher,
+// whose error is returned. Dispatch must not be called after Close.
+func (m *Multi) Dispatch(ctx context.Context, result detectors.ResultWithMetadata) error {
+	if m.queues == nil {
+		return m.dispatchers[0].Dispatch(ctx, result)
+	}
+
+	for _, queue := range m.queues {
+		select {
+		case queue <- queuedResult{ctx: ctx, result: result}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Close waits for the queued results to be dispatched, and then closes the
+// dispatchers that need closing, e.g. files. Only the first call has an
+// effect.
+func (m *Multi) Close() error {
+	m.closeOnce.Do(func() {
+		for _, queue := range m.queues {
+			close(queue)
+		}
+		m.wg.Wait()
+
+		var errs []error
+		for _, d := range m.dispatchers {
+			if closer, ok := d.(io.Closer); ok {
+				errs = append(errs, closer.Close())
+			}
+		}
+		m.closeErr = errors.Join(errs...)
+	})
+	return m.closeErr
+}
@@ -0,0 +1,83 @@
+package dispatchers
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+type fakeDispatcher struct {
+	mu      sync.Mutex
+	results []detectors.ResultWithMetadata
+	err     error
+	closed  bool
+	// release, if set, blocks every dispatch until it's closed.
+	release chan struct{}
+}
+
+func (f *fakeDispatcher) Dispatch(_ context.Context, result detectors.ResultWithMetadata) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.results = append(f.results, result)
+	return f.err
+}
+
+func (f *fakeDispatcher) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestMulti(t *testing.T) {
+	ctx := context.Background()
+	ok, failing := &fakeDispatcher{}, &fakeDispatcher{err: errors.New("failed")}
+	multi := NewMulti(ok, failing)
+
+	assert.NoError(t, multi.Dispatch(ctx, testResult()))
+
+	assert.NoError(t, multi.Close())
+	// Every dispatcher receives the result, even if another one fails.
+	assert.Len(t, ok.results, 1)
+	assert.Len(t, failing.results, 1)
+	assert.True(t, ok.closed)
+	assert.True(t, failing.closed)
+	// Closing again has no effect.
+	assert.NoError(t, multi.Close())
+}
+
+func TestMulti_SlowDispatcher(t *testing.T) {
+	ctx := context.Background()
+	fast, slow := &fakeDispatcher{}, &fakeDispatcher{release: make(chan struct{})}
+	multi := NewMulti(fast, slow)
+
+	// Results are dispatched to the fast dispatcher while the slow one is stuck.
+	for range 3 {
+		assert.NoError(t, multi.Dispatch(ctx, testResult()))
+	}
+	assert.Eventually(t, func() bool {
+		fast.mu.Lock()
+		defer fast.mu.Unlock()
+		return len(fast.results) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	// Close waits for the slow dispatcher to catch up.
+	close(slow.release)
+	assert.NoError(t, multi.Close())
+	assert.Len(t, slow.results, 3)
+}
+
+func TestMulti_Single(t *testing.T) {
+	errFailed := errors.New("failed")
+	multi := NewMulti(&fakeDispatcher{err: errFailed})
+	// A single dispatcher's error is returned.
+	assert.ErrorIs(t, multi.Dispatch(context.Background(), testResult()), errFailed)
+	assert.NoError(t, multi.Close())
+}
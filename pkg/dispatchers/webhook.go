@@ -0,0 +1,105 @@
+package dispatchers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+)
+
+// SignatureHeader is the header that holds the HMAC-SHA256 signature of a
+// webhook request's body, in the form "sha256=<hex digest>".
+const SignatureHeader = "X-TruffleHog-Signature-256"
+
+// webhookTimeout bounds each attempt to deliver a result.
+const webhookTimeout = 30 * time.Second
+
+// Webhook posts each result as JSON to an HTTP endpoint. Failed requests are
+// retried with backoff.
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// WebhookOption configures a Webhook.
+type WebhookOption func(*Webhook)
+
+// WithWebhookSecret signs the requests with the secret, so that the receiver
+// can verify that they were sent by the scanner.
+func WithWebhookSecret(secret string) WebhookOption {
+	return func(w *Webhook) { w.secret = []byte(secret) }
+}
+
+// WithWebhookClient sets the HTTP client used to post results.
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return func(w *Webhook) { w.client = client }
+}
+
+// NewWebhook creates a Webhook that posts results to the HTTP(S) URL.
+func NewWebhook(rawURL string, opts ...WebhookOption) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url %q: %w", rawURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook url must be http or https: %q", rawURL)
+	}
+
+	w := &Webhook{url: u.String()}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.client == nil {
+		w.client = common.RetryableHTTPClient(common.WithTimeout(webhookTimeout))
+	}
+	return w, nil
+}
+
+// Dispatch posts the result to the webhook.
+func (w *Webhook) Dispatch(ctx context.Context, result detectors.ResultWithMetadata) error {
+	body, err := output.MarshalJSON(&result)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting result to webhook: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the value of the SignatureHeader for the body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
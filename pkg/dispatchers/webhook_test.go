@@ -0,0 +1,78 @@
+package dispatchers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+func testResult() detectors.ResultWithMetadata {
+	return detectors.ResultWithMetadata{
+		Result: detectors.Result{DetectorType: detectorspb.DetectorType_Github, Raw: []byte("secret"), Verified: true},
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt to check that it is retried.
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, Sign([]byte("hunter2"), body), r.Header.Get(SignatureHeader))
+
+		var got struct {
+			DetectorName string
+			Raw          string
+			Verified     bool
+		}
+		require.NoError(t, json.Unmarshal(body, &got))
+		assert.Equal(t, "Github", got.DetectorName)
+		assert.Equal(t, "secret", got.Raw)
+		assert.True(t, got.Verified)
+	}))
+	defer server.Close()
+
+	client := common.RetryableHTTPClient(common.WithRetryWaitMin(time.Millisecond), common.WithRetryWaitMax(time.Millisecond))
+	webhook, err := NewWebhook(server.URL, WithWebhookSecret("hunter2"), WithWebhookClient(client))
+	require.NoError(t, err)
+
+	assert.NoError(t, webhook.Dispatch(context.Background(), testResult()))
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestWebhook_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	webhook, err := NewWebhook(server.URL)
+	require.NoError(t, err)
+	assert.ErrorContains(t, webhook.Dispatch(context.Background(), testResult()), "403")
+}
+
+func TestNewWebhook_InvalidURL(t *testing.T) {
+	for _, u := range []string{"", "example.com/hook", "ftp://example.com/hook", "https://"} {
+		_, err := NewWebhook(u)
+		assert.Error(t, err, u)
+	}
+}
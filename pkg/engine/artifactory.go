@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/artifactory"
+)
+
+// ArtifactoryConfig represents the configuration for JFrog Artifactory.
+type ArtifactoryConfig struct {
+	// Endpoint is the Artifactory base URL, e.g. https://example.jfrog.io/artifactory.
+	Endpoint string
+	// Token is an access token or API key.
+	Token string
+	// Username and Password are used for basic authentication.
+	Username string
+	Password string
+	// Repositories is the list of repository keys to scan. Leave empty to
+	// scan every local and federated repository.
+	Repositories []string
+	// IncludePaths and IgnorePaths are globs matched against artifact paths.
+	IncludePaths []string
+	IgnorePaths  []string
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanArtifactory scans JFrog Artifactory repositories with the provided configuration.
+func (e *Engine) ScanArtifactory(ctx context.Context, c ArtifactoryConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Artifactory{
+		Endpoint:     c.Endpoint,
+		Repositories: c.Repositories,
+		IncludePaths: c.IncludePaths,
+		IgnorePaths:  c.IgnorePaths,
+	}
+
+	switch {
+	case c.Token != "":
+		connection.Credential = &sourcespb.Artifactory_AccessToken{AccessToken: c.Token}
+	case c.Username != "" || c.Password != "":
+		connection.Credential = &sourcespb.Artifactory_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{
+				Username: c.Username,
+				Password: c.Password,
+			},
+		}
+	default:
+		connection.Credential = &sourcespb.Artifactory_Unauthenticated{
+			Unauthenticated: &credentialspb.Unauthenticated{},
+		}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal artifactory connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - artifactory"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, artifactory.SourceType)
+
+	artifactorySource := &artifactory.Source{}
+	if err := artifactorySource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, artifactorySource)
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"fmt"
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/azure_repos"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+// AzureReposConfig represents the configuration for Azure Repos.
+type AzureReposConfig struct {
+	// Endpoint is the Azure DevOps endpoint. Leave empty for Azure DevOps Services.
+	Endpoint string
+	// Token is a personal access token.
+	Token string
+	// Repos is the list of repository URLs to scan.
+	Repos []string
+	// Organizations is the list of organizations (or project collections) to enumerate.
+	Organizations []string
+	// Projects is the list of "org/project" projects to enumerate.
+	Projects []string
+	// IncludeRepos and IgnoreRepos are "org/project/repo" globs used to filter enumerated repositories.
+	IncludeRepos []string
+	IgnoreRepos  []string
+	// IncludeProjects and IgnoreProjects are "org/project" globs used to filter enumerated projects.
+	IncludeProjects []string
+	IgnoreProjects  []string
+	// IncludeForks indicates whether to include forked repositories in the scan.
+	IncludeForks bool
+	// Filter is the filter to use to scan the source.
+	Filter *common.Filter
+	// SkipBinaries allows skipping binary files from the scan.
+	SkipBinaries bool
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanAzureRepos scans Azure Repos with the provided configuration.
+func (e *Engine) ScanAzureRepos(ctx context.Context, c AzureReposConfig) (sources.JobProgressRef, error) {
+	if c.Token == "" {
+		return sources.JobProgressRef{}, fmt.Errorf("must provide an Azure DevOps token")
+	}
+	connection := &sourcespb.AzureRepos{
+		Endpoint:        c.Endpoint,
+		Credential:      &sourcespb.AzureRepos_Token{Token: c.Token},
+		Repositories:    c.Repos,
+		Organizations:   c.Organizations,
+		Projects:        c.Projects,
+		IncludeForks:    c.IncludeForks,
+		IgnoreRepos:     c.IgnoreRepos,
+		IncludeRepos:    c.IncludeRepos,
+		IncludeProjects: c.IncludeProjects,
+		IgnoreProjects:  c.IgnoreProjects,
+		SkipBinaries:    c.SkipBinaries,
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal azure repos connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - azure repos"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, azure_repos.SourceType)
+
+	azureSource := &azure_repos.Source{}
+	if err := azureSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	if c.Filter != nil {
+		azureSource.WithScanOptions(git.NewScanOptions(git.ScanOptionFilter(c.Filter)))
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, azureSource)
+}
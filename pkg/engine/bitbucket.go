@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"fmt"
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/bitbucket"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+// BitbucketConfig represents the configuration for Bitbucket.
+type BitbucketConfig struct {
+	// Endpoint is the Bitbucket API endpoint. Leave empty for Bitbucket Cloud.
+	Endpoint string
+	// InstallationType selects between Bitbucket Cloud and Data Center.
+	InstallationType sourcespb.BitbucketInstallationType
+	// Token is an access token (Cloud) or HTTP access token (Data Center).
+	Token string
+	// Username and Password are used for basic authentication.
+	Username string
+	Password string
+	// Repos is the list of repository URLs to scan.
+	Repos []string
+	// IgnoreRepos is a list of repository globs to skip during enumeration.
+	IgnoreRepos []string
+	// Filter is the filter to use to scan the source.
+	Filter *common.Filter
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanBitbucket scans Bitbucket Cloud or Data Center with the provided configuration.
+func (e *Engine) ScanBitbucket(ctx context.Context, c BitbucketConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Bitbucket{
+		Endpoint:         c.Endpoint,
+		InstallationType: c.InstallationType,
+		Repositories:     c.Repos,
+		IgnoreRepos:      c.IgnoreRepos,
+	}
+
+	switch {
+	case c.Token != "":
+		connection.Credential = &sourcespb.Bitbucket_Token{Token: c.Token}
+	case c.Username != "" && c.Password != "":
+		connection.Credential = &sourcespb.Bitbucket_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{
+				Username: c.Username,
+				Password: c.Password,
+			},
+		}
+	default:
+		return sources.JobProgressRef{}, fmt.Errorf("must provide a token or a username and password")
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal bitbucket connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - bitbucket"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, bitbucket.SourceType)
+
+	bitbucketSource := &bitbucket.Source{}
+	if err := bitbucketSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	if c.Filter != nil {
+		bitbucketSource.WithScanOptions(git.NewScanOptions(git.ScanOptionFilter(c.Filter)))
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, bitbucketSource)
+}
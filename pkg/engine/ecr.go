@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/ecr"
+)
+
+// ECRConfig represents the configuration for Amazon ECR.
+type ECRConfig struct {
+	// Key and Secret are the AWS access key used to authenticate. When unset,
+	// credentials are loaded from the environment like the AWS CLI does.
+	Key    string
+	Secret string
+	// Registries is the list of registry hostnames to scan, e.g.
+	// 123456789012.dkr.ecr.us-east-1.amazonaws.com.
+	Registries []string
+	// Endpoint overrides the ECR API endpoint.
+	Endpoint string
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanECR scans every tagged image in Amazon ECR registries with the provided configuration.
+func (e *Engine) ScanECR(ctx context.Context, c ECRConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.ECR{
+		Registries: c.Registries,
+		Endpoint:   c.Endpoint,
+	}
+	if c.Key != "" {
+		connection.Credential = &sourcespb.ECR_AccessKey{
+			AccessKey: &credentialspb.KeySecret{Key: c.Key, Secret: c.Secret},
+		}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal ECR connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - ecr"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, ecr.SourceType)
+
+	ecrSource := &ecr.Source{}
+	if err := ecrSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, ecrSource)
+}
@@ -15,6 +15,10 @@ import (
 	lru "github.com/hashicorp/golang-lru/v2"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers"
+	analyzerconfig "github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/config"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/baseline"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/config"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
@@ -33,6 +37,9 @@ import (
 
 var detectionTimeout = detectors.DefaultResponseTimeout
 
+// analysisTimeout bounds the analysis of a single verified credential.
+var analysisTimeout = time.Minute
+
 // Metrics for the scan engine for external consumption.
 type Metrics struct {
 	BytesScanned           uint64
@@ -138,6 +145,19 @@ type Config struct {
 
 	VerificationResultCache  verificationcache.ResultCache
 	VerificationCacheMetrics verificationcache.MetricsReporter
+
+	// Baseline holds known findings, which are not notified. If
+	// UpdateBaseline is set, findings are added to it instead.
+	Baseline       *baseline.Baseline
+	UpdateBaseline bool
+
+	// Allowlist suppresses the findings that match its rules. Only findings
+	// that could match a context regex are verified before being suppressed.
+	Allowlist *config.Allowlist
+
+	// AnalyzeVerified runs the matching analyzer on verified results that
+	// carry analysis info, and attaches its permissions to the result.
+	AnalyzeVerified bool
 }
 
 // Engine represents the core scanning engine responsible for detecting secrets in input data.
@@ -165,6 +185,15 @@ type Engine struct {
 	retainFalsePositives    bool
 	printAvgDetectorTime    bool
 	printOnce               bool
+	// baseline holds known findings, which are suppressed unless
+	// updateBaseline is set, in which case every finding is added to it.
+	baseline       *baseline.Baseline
+	updateBaseline bool
+	// allowlist suppresses the findings that match its rules.
+	allowlist *config.Allowlist
+	// analyzerFor returns the analyzer for a verified result's credential. It
+	// is nil unless verified results should be analyzed.
+	analyzerFor func(detectorspb.DetectorType, map[string]string) analyzers.Analyzer
 	// By default, the engine will only scan a subset of the chunk if a detector matches the chunk.
 	// If this flag is set to true, the engine will scan the entire chunk.
 	scanEntireChunk bool
@@ -176,9 +205,13 @@ type Engine struct {
 	sourceManager        *sources.SourceManager
 	results              chan detectors.ResultWithMetadata
 	detectableChunksChan chan detectableChunk
-	workersWg            sync.WaitGroup
-	wgDetectorWorkers    sync.WaitGroup
-	WgNotifier           sync.WaitGroup
+	// analysisChan holds the results waiting on their analysis before they
+	// are dispatched. It is nil unless verified results should be analyzed.
+	analysisChan      chan detectors.ResultWithMetadata
+	workersWg         sync.WaitGroup
+	wgDetectorWorkers sync.WaitGroup
+	WgNotifier        sync.WaitGroup
+	wgAnalyzerWorkers sync.WaitGroup
 
 	// Runtime information.
 	metrics runtimeMetrics
@@ -216,6 +249,9 @@ func NewEngine(ctx context.Context, cfg *Config) (*Engine, error) {
 		filterEntropy:                 cfg.FilterEntropy,
 		printAvgDetectorTime:          cfg.PrintAvgDetectorTime,
 		printOnce:                     cfg.PrintOnce,
+		baseline:                      cfg.Baseline,
+		updateBaseline:                cfg.UpdateBaseline,
+		allowlist:                     cfg.Allowlist,
 		retainFalsePositives:          cfg.LogFilteredUnverified,
 		sourceManager:                 cfg.SourceManager,
 		scanEntireChunk:               cfg.ShouldScanEntireChunk,
@@ -226,6 +262,12 @@ func NewEngine(ctx context.Context, cfg *Config) (*Engine, error) {
 	if engine.sourceManager == nil {
 		return nil, fmt.Errorf("source manager is required")
 	}
+	if cfg.AnalyzeVerified {
+		analyzerCfg := &analyzerconfig.Config{}
+		engine.analyzerFor = func(detectorType detectorspb.DetectorType, analysisInfo map[string]string) analyzers.Analyzer {
+			return analyzer.ForDetector(analyzerCfg, detectorType, analysisInfo)
+		}
+	}
 
 	engine.setDefaults(ctx)
 
@@ -484,6 +526,9 @@ func (e *Engine) initialize(ctx context.Context) error {
 	// considering the expected concurrency and workload in the system.
 	e.detectableChunksChan = make(chan detectableChunk, defaultChannelBuffer*detectableChunksChanMultiplier)
 	e.results = make(chan detectors.ResultWithMetadata, defaultChannelBuffer*resultsChanMultiplier)
+	if e.analyzerFor != nil {
+		e.analysisChan = make(chan detectors.ResultWithMetadata, defaultChannelBuffer*resultsChanMultiplier)
+	}
 	e.dedupeCache = cache
 	ctx.Logger().V(4).Info("engine initialized")
 
@@ -620,6 +665,10 @@ func (e *Engine) startWorkers(ctx context.Context) {
 	// ResultsDispatcher workers communicate detected issues to the user or any downstream systems.
 	// We want 1/4th of the notifier workers as the number of scanner workers.
 	e.startNotifierWorkers(ctx)
+
+	// Analyzer workers call out to the analyzers and dispatch the analyzed
+	// results, so slow analysis doesn't hold up the notifier workers.
+	e.startAnalyzerWorkers(ctx)
 }
 
 func (e *Engine) startScannerWorkers(ctx context.Context) {
@@ -665,6 +714,24 @@ func (e *Engine) startNotifierWorkers(ctx context.Context) {
 	}
 }
 
+func (e *Engine) startAnalyzerWorkers(ctx context.Context) {
+	if e.analysisChan == nil {
+		return
+	}
+	numWorkers := e.concurrency
+
+	ctx.Logger().V(2).Info("starting analyzer workers", "count", numWorkers)
+	for worker := 0; worker < numWorkers; worker++ {
+		e.wgAnalyzerWorkers.Add(1)
+		go func() {
+			ctx := context.WithValue(ctx, "analyzer_worker_id", common.RandomID(5))
+			defer common.Recover(ctx)
+			defer e.wgAnalyzerWorkers.Done()
+			e.analyzerWorker(ctx)
+		}()
+	}
+}
+
 // Finish waits for running sources to complete and workers to finish scanning
 // chunks before closing their respective channels. Once Finish is called, no
 // more sources may be scanned by the engine.
@@ -681,6 +748,11 @@ func (e *Engine) Finish(ctx context.Context) error {
 	close(e.results)    // Detector workers are done, close the results channel and call it a day.
 	e.WgNotifier.Wait() // Wait for the notifier workers to finish notifying results.
 
+	if e.analysisChan != nil {
+		close(e.analysisChan)      // Notifier workers are done, no more results need analysis.
+		e.wgAnalyzerWorkers.Wait() // Wait for the analyzer workers to dispatch the analyzed results.
+	}
+
 	e.metrics.ScanDuration = time.Since(e.metrics.scanStartTime)
 
 	return err
@@ -701,12 +773,65 @@ func (e *Engine) ScanChunk(chunk *sources.Chunk) {
 	e.sourceManager.ScanChunk(chunk)
 }
 
+// DetectChunk synchronously decodes the chunk and runs every matching detector
+// against it. The results are dispatched like any others, and the ones that
+// pass the results filter are also returned to the caller. Only the
+// dispatched results are analyzed; the returned ones never carry an
+// AnalyzerResult. It must only be called after Start and before Finish.
+func (e *Engine) DetectChunk(ctx context.Context, chunk *sources.Chunk) []detectors.ResultWithMetadata {
+	var (
+		mu      sync.Mutex
+		results []detectors.ResultWithMetadata
+		wg      sync.WaitGroup
+	)
+	collect := func(result detectors.ResultWithMetadata) {
+		mu.Lock()
+		results = append(results, result)
+		mu.Unlock()
+	}
+
+	for _, decoder := range e.decoders {
+		decoded := decoder.FromChunk(ctx, chunk)
+		if decoded == nil {
+			continue
+		}
+
+		for _, detector := range e.AhoCorasickCore.FindDetectorMatches(decoded.Chunk.Data) {
+			decoded.Chunk.Verify = e.shouldVerifyChunk(chunk.Verify, detector, e.detectorVerificationOverrides)
+			wg.Add(1)
+			data := detectableChunk{
+				chunk:    *decoded.Chunk,
+				detector: detector,
+				decoder:  decoded.DecoderType,
+				wgDoneFn: wg.Done,
+				notifyFn: collect,
+			}
+			go e.detectChunk(ctx, data)
+		}
+	}
+	wg.Wait()
+
+	atomic.AddUint64(&e.metrics.ChunksScanned, 1)
+	atomic.AddUint64(&e.metrics.BytesScanned, uint64(len(chunk.Data)))
+
+	notified := make([]detectors.ResultWithMetadata, 0, len(results))
+	for _, result := range results {
+		e.results <- result
+		if e.shouldNotify(result) && !e.inBaseline(result) {
+			notified = append(notified, result)
+		}
+	}
+	return notified
+}
+
 // detectableChunk is a decoded chunk that is ready to be scanned by its detector.
 type detectableChunk struct {
 	detector *ahocorasick.DetectorMatch
 	chunk    sources.Chunk
 	decoder  detectorspb.DecoderType
 	wgDoneFn func()
+	// notifyFn optionally receives the results instead of the notifier workers.
+	notifyFn func(detectors.ResultWithMetadata)
 }
 
 func (e *Engine) scannerWorker(ctx context.Context) {
@@ -821,6 +946,13 @@ func (e *Engine) detectChunk(ctx context.Context, data detectableChunk) {
 
 	isFalsePositive := detectors.GetFalsePositiveCheck(data.detector.Detector)
 
+	detectorID := config.GetDetectorID(data.detector.Detector)
+	if e.allowlist.MatchesChunk(detectorID, data.chunk.SourceType, data.chunk.SourceMetadata) {
+		ctx.Logger().V(4).Info("chunk skipped by allowlist")
+		data.wgDoneFn()
+		return
+	}
+
 	var matchCount int
 	// To reduce the overhead of regex calls in the detector,
 	// we limit the amount of data passed to each detector.
@@ -836,12 +968,7 @@ func (e *Engine) detectChunk(ctx context.Context, data detectableChunk) {
 		t := time.AfterFunc(detectionTimeout+1*time.Second, func() {
 			ctx.Logger().Error(nil, "a detector ignored the context timeout")
 		})
-		results, err := e.verificationCache.FromData(
-			ctx,
-			data.detector.Detector,
-			data.chunk.Verify,
-			data.chunk.SecretID != 0,
-			matchBytes)
+		results, err := e.fromData(ctx, data, detectorID, matchBytes)
 		t.Stop()
 		cancel()
 		if err != nil {
@@ -894,6 +1021,61 @@ func (e *Engine) detectChunk(ctx context.Context, data detectableChunk) {
 	data.wgDoneFn()
 }
 
+// fromData finds the results of the detector in matchBytes, without the ones
+// allowlisted regardless of their context. If the allowlist could match the
+// secrets themselves, they are detected without verification first, and only
+// verified if any of them isn't allowlisted. This keeps allowlisted secrets
+// from being sent to be verified.
+func (e *Engine) fromData(
+	ctx context.Context,
+	data detectableChunk,
+	detectorID config.DetectorID,
+	matchBytes []byte,
+) ([]detectors.Result, error) {
+	if data.chunk.Verify && e.allowlist.HasSecretRules(detectorID, data.chunk.SourceType, data.chunk.SourceMetadata) {
+		unverified, err := data.detector.Detector.FromData(ctx, false, matchBytes)
+		if err != nil {
+			return nil, err
+		}
+		if len(e.dropAllowlisted(ctx, data, detectorID, unverified)) == 0 {
+			return nil, nil
+		}
+	}
+
+	results, err := e.verificationCache.FromData(
+		ctx,
+		data.detector.Detector,
+		data.chunk.Verify,
+		data.chunk.SecretID != 0,
+		matchBytes)
+	if err != nil {
+		return nil, err
+	}
+	return e.dropAllowlisted(ctx, data, detectorID, results), nil
+}
+
+// dropAllowlisted removes the results that the allowlist matches regardless
+// of their context.
+func (e *Engine) dropAllowlisted(
+	ctx context.Context,
+	data detectableChunk,
+	detectorID config.DetectorID,
+	results []detectors.Result,
+) []detectors.Result {
+	if e.allowlist == nil {
+		return results
+	}
+	kept := results[:0]
+	for _, res := range results {
+		if e.allowlist.MatchesSecret(detectorID, detectors.CopyMetadata(&data.chunk, res)) {
+			ctx.Logger().V(4).Info("result suppressed by allowlist")
+			continue
+		}
+		kept = append(kept, res)
+	}
+	return kept
+}
+
 func (e *Engine) filterResults(
 	ctx context.Context,
 	detector *ahocorasick.DetectorMatch,
@@ -946,6 +1128,10 @@ func (e *Engine) processResult(
 	}
 
 	secret := detectors.CopyMetadata(&data.chunk, res)
+	if e.allowlist.MatchesContext(config.GetDetectorID(data.detector.Detector), secret, data.chunk.Data) {
+		ctx.Logger().V(4).Info("result suppressed by allowlist", "detector", data.detector.Type().String())
+		return
+	}
 	secret.DecoderType = data.decoder
 	secret.DetectorDescription = data.detector.Detector.Description()
 
@@ -954,6 +1140,10 @@ func (e *Engine) processResult(
 		secret.IsWordlistFalsePositive = isFp
 	}
 
+	if data.notifyFn != nil {
+		data.notifyFn(secret)
+		return
+	}
 	e.results <- secret
 }
 
@@ -961,21 +1151,12 @@ func (e *Engine) notifierWorker(ctx context.Context) {
 	for result := range e.ResultsChan() {
 		startTime := time.Now()
 		// Filter unwanted results, based on `--results`.
-		if !result.Verified {
-			if result.VerificationError() != nil {
-				if !e.notifyUnknownResults {
-					// Skip results with verification errors.
-					continue
-				}
-			} else if !e.notifyUnverifiedResults {
-				// Skip unverified results.
-				continue
-			}
-		} else if !e.notifyVerifiedResults {
-			// Skip verified results.
-			// TODO: Is this a legitimate use case?
+		if !e.shouldNotify(result) || e.inBaseline(result) {
 			continue
 		}
+		if e.updateBaseline && e.baseline != nil {
+			e.baseline.Add(result)
+		}
 		atomic.AddUint32(&e.numFoundResults, 1)
 
 		// Dedupe results by comparing the detector type, raw result, and source metadata.
@@ -1005,6 +1186,11 @@ func (e *Engine) notifierWorker(ctx context.Context) {
 			atomic.AddUint64(&e.metrics.UnverifiedSecretsFound, 1)
 		}
 
+		if e.analysisChan != nil && e.shouldAnalyze(result) {
+			e.analysisChan <- result
+			continue
+		}
+
 		if err := e.dispatcher.Dispatch(ctx, result); err != nil {
 			ctx.Logger().Error(err, "error notifying result")
 		}
@@ -1013,6 +1199,65 @@ func (e *Engine) notifierWorker(ctx context.Context) {
 	}
 }
 
+func (e *Engine) analyzerWorker(ctx context.Context) {
+	for result := range e.analysisChan {
+		e.analyze(ctx, &result)
+
+		if err := e.dispatcher.Dispatch(ctx, result); err != nil {
+			ctx.Logger().Error(err, "error notifying result")
+		}
+	}
+}
+
+// shouldNotify reports whether the result passes the `--results` filter.
+func (e *Engine) shouldNotify(result detectors.ResultWithMetadata) bool {
+	switch {
+	case result.Verified:
+		// TODO: Is skipping verified results a legitimate use case?
+		return e.notifyVerifiedResults
+	case result.VerificationError() != nil:
+		return e.notifyUnknownResults
+	default:
+		return e.notifyUnverifiedResults
+	}
+}
+
+// analyze attaches the permissions of a verified result's credential, as
+// reported by the analyzer for its detector. Results without analysis info or
+// a matching analyzer are left as they are, as are results whose analysis
+// fails.
+func (e *Engine) analyze(ctx context.Context, result *detectors.ResultWithMetadata) {
+	if !e.shouldAnalyze(*result) {
+		return
+	}
+	a := e.analyzerFor(result.DetectorType, result.AnalysisInfo)
+	if a == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, analysisTimeout)
+	defer cancel()
+	analyzerResult, err := a.Analyze(ctx, result.AnalysisInfo)
+	if err != nil {
+		ctx.Logger().Error(err, "error analyzing result",
+			"detector", result.DetectorType.String(), "analyzer", a.Type().String())
+		return
+	}
+	result.AnalyzerResult = analyzerResult
+}
+
+// shouldAnalyze reports whether the result is a verified credential that
+// carries the info its analyzer needs.
+func (e *Engine) shouldAnalyze(result detectors.ResultWithMetadata) bool {
+	return e.analyzerFor != nil && result.Verified && result.AnalysisInfo != nil
+}
+
+// inBaseline reports whether the result is a known finding that should be
+// suppressed. Nothing is suppressed while the baseline is being updated.
+func (e *Engine) inBaseline(result detectors.ResultWithMetadata) bool {
+	return e.baseline != nil && !e.updateBaseline && e.baseline.Contains(result)
+}
+
 // SupportsLineNumbers determines if a line number can be found for a source type.
 func SupportsLineNumbers(sourceType sourcespb.SourceType) bool {
 	switch sourceType {
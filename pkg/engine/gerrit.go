@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/gerrit"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+// GerritConfig represents the configuration for Gerrit.
+type GerritConfig struct {
+	// Endpoint is the base URL of the Gerrit server.
+	Endpoint string
+	// Username and Password are used for basic authentication. Password is
+	// the HTTP password generated in the Gerrit user settings. Leave both
+	// empty to scan anonymously.
+	Username string
+	Password string
+	// Projects is the list of projects to scan. Leave empty to scan every
+	// visible project.
+	Projects []string
+	// Filter is the filter to use to scan the source.
+	Filter *common.Filter
+	// SkipBinaries allows skipping binary files from the scan.
+	SkipBinaries bool
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanGerrit scans Gerrit projects, including every change and patchset, with
+// the provided configuration.
+func (e *Engine) ScanGerrit(ctx context.Context, c GerritConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Gerrit{
+		Endpoint:     c.Endpoint,
+		Projects:     c.Projects,
+		SkipBinaries: c.SkipBinaries,
+	}
+
+	if c.Username != "" || c.Password != "" {
+		connection.Credential = &sourcespb.Gerrit_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{
+				Username: c.Username,
+				Password: c.Password,
+			},
+		}
+	} else {
+		connection.Credential = &sourcespb.Gerrit_Unauthenticated{}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal gerrit connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - gerrit"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, gerrit.SourceType)
+
+	gerritSource := &gerrit.Source{}
+	if err := gerritSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	if c.Filter != nil {
+		gerritSource.WithScanOptions(git.NewScanOptions(git.ScanOptionFilter(c.Filter)))
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, gerritSource)
+}
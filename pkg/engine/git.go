@@ -24,6 +24,14 @@ func (e *Engine) ScanGit(ctx context.Context, c sources.GitConfig) (sources.JobP
 		ExcludePathsFile: c.ExcludePathsFile,
 		MaxDepth:         int64(c.MaxDepth),
 		SkipBinaries:     c.SkipBinaries,
+		Lfs:              c.LFS,
+		LfsEndpoint:      c.LFSEndpoint,
+		Stashes:          c.Stashes,
+		Reflog:           c.Reflog,
+		Dangling:         c.Dangling,
+		Submodules:       c.Submodules,
+		Snapshot:         c.Snapshot,
+		PushedRevisions:  c.PushedRevisions,
 	}
 	var conn anypb.Any
 	if err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{}); err != nil {
@@ -50,6 +50,7 @@ func (e *Engine) ScanGitHub(ctx context.Context, c sources.GithubConfig) (source
 	opts := []git.ScanOption{
 		git.ScanOptionFilter(c.Filter),
 		git.ScanOptionLogOptions(logOptions),
+		git.ScanOptionSubmodules(c.Submodules),
 	}
 	scanOptions := git.NewScanOptions(opts...)
 
@@ -21,6 +21,7 @@ func (e *Engine) ScanGitLab(ctx context.Context, c sources.GitlabConfig) (source
 	opts := []git.ScanOption{
 		git.ScanOptionFilter(c.Filter),
 		git.ScanOptionLogOptions(logOptions),
+		git.ScanOptionSubmodules(c.Submodules),
 	}
 	scanOptions := git.NewScanOptions(opts...)
 
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/jira"
+)
+
+// JiraConfig represents the configuration for Jira.
+type JiraConfig struct {
+	// Endpoint is the Jira base URL.
+	Endpoint string
+	// Username and Password are used for basic authentication. For Jira
+	// Cloud, the password is an API token.
+	Username string
+	Password string
+	// Token is a personal access token sent as a bearer token.
+	Token string
+	// Projects is the list of project keys to scan. All projects are scanned if empty.
+	Projects []string
+	// IgnoreProjects is a list of project keys to skip.
+	IgnoreProjects []string
+	// InsecureSkipVerifyTLS disables TLS certificate verification.
+	InsecureSkipVerifyTLS bool
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanJira scans Jira with the provided configuration.
+func (e *Engine) ScanJira(ctx context.Context, c JiraConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.JIRA{
+		Endpoint:              c.Endpoint,
+		Projects:              c.Projects,
+		IgnoreProjects:        c.IgnoreProjects,
+		InsecureSkipVerifyTls: c.InsecureSkipVerifyTLS,
+	}
+
+	switch {
+	case c.Token != "":
+		connection.Credential = &sourcespb.JIRA_Token{Token: c.Token}
+	case c.Username != "" && c.Password != "":
+		connection.Credential = &sourcespb.JIRA_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{
+				Username: c.Username,
+				Password: c.Password,
+			},
+		}
+	default:
+		connection.Credential = &sourcespb.JIRA_Unauthenticated{
+			Unauthenticated: &credentialspb.Unauthenticated{},
+		}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal jira connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - jira"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, jira.SourceType)
+
+	jiraSource := &jira.Source{}
+	if err := jiraSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, jiraSource)
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/npm"
+)
+
+// NPMConfig represents the configuration for npm packages.
+type NPMConfig struct {
+	// Registry is the npm registry URL. Defaults to the public registry.
+	Registry string
+	// Packages is the list of package names to scan.
+	Packages []string
+	// Maintainers is the list of npm users whose packages are scanned.
+	Maintainers []string
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanNPM scans every published version of npm packages with the provided configuration.
+func (e *Engine) ScanNPM(ctx context.Context, c NPMConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.NPMUnauthenticatedPackage{
+		Credential: &sourcespb.NPMUnauthenticatedPackage_Unauthenticated{
+			Unauthenticated: &credentialspb.Unauthenticated{},
+		},
+		Registry:    c.Registry,
+		Packages:    c.Packages,
+		Maintainers: c.Maintainers,
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal npm connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - npm"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, npm.SourceType)
+
+	npmSource := &npm.Source{}
+	if err := npmSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, npmSource)
+}
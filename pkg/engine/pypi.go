@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/pypi"
+)
+
+// PyPIConfig represents the configuration for PyPI packages.
+type PyPIConfig struct {
+	// Registry is the package index URL. Defaults to the public index.
+	Registry string
+	// Packages is the list of project names to scan.
+	Packages []string
+	// Users is the list of PyPI users whose projects are scanned.
+	Users []string
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanPyPI scans every file of every release of PyPI projects with the provided configuration.
+func (e *Engine) ScanPyPI(ctx context.Context, c PyPIConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.PyPIUnauthenticatedPackage{
+		Credential: &sourcespb.PyPIUnauthenticatedPackage_Unauthenticated{
+			Unauthenticated: &credentialspb.Unauthenticated{},
+		},
+		Registry: c.Registry,
+		Packages: c.Packages,
+		Users:    c.Users,
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal pypi connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - pypi"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, pypi.SourceType)
+
+	pypiSource := &pypi.Source{}
+	if err := pypiSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, pypiSource)
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"fmt"
+	"runtime"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/slack"
+)
+
+// SlackConfig represents the configuration for Slack.
+type SlackConfig struct {
+	// Token is a bot or user token with the channels:history,
+	// groups:history, channels:read, groups:read and files:read scopes.
+	Token string
+	// Endpoint overrides the Slack Web API base URL.
+	Endpoint string
+	// Channels is the list of channel IDs or names to scan. All channels are scanned if empty.
+	Channels []string
+	// IgnoreList is a list of channel IDs or names to skip.
+	IgnoreList []string
+	// Concurrency is the number of concurrent workers to use to scan the source.
+	Concurrency int
+}
+
+// ScanSlack scans a Slack workspace with the provided configuration.
+func (e *Engine) ScanSlack(ctx context.Context, c SlackConfig) (sources.JobProgressRef, error) {
+	if c.Token == "" {
+		return sources.JobProgressRef{}, fmt.Errorf("must provide a Slack token")
+	}
+	connection := &sourcespb.Slack{
+		Endpoint:   c.Endpoint,
+		Credential: &sourcespb.Slack_Token{Token: c.Token},
+		Channels:   c.Channels,
+		IgnoreList: c.IgnoreList,
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal slack connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	concurrency := c.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	sourceName := "trufflehog - slack"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, slack.SourceType)
+
+	slackSource := &slack.Source{}
+	if err := slackSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, concurrency); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, slackSource)
+}
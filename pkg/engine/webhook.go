@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/webhook"
+)
+
+// WebhookConfig represents the configuration for the webhook listener.
+type WebhookConfig struct {
+	// ListenAddress is the address to listen on for requests.
+	ListenAddress string
+	// HeaderName and HeaderValue, when set, are required on every request.
+	HeaderName  string
+	HeaderValue string
+}
+
+// ScanWebhook listens for HTTP requests and scans their bodies until the
+// context is cancelled. Requests can wait for their findings, which are
+// detected synchronously by the engine.
+func (e *Engine) ScanWebhook(ctx context.Context, c WebhookConfig) (sources.JobProgressRef, error) {
+	connection := &sourcespb.Webhook{ListenAddress: c.ListenAddress}
+	if c.HeaderName != "" || c.HeaderValue != "" {
+		connection.Credential = &sourcespb.Webhook_Header{
+			Header: &credentialspb.Header{Key: c.HeaderName, Value: c.HeaderValue},
+		}
+	}
+
+	var conn anypb.Any
+	err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{})
+	if err != nil {
+		ctx.Logger().Error(err, "failed to marshal webhook connection")
+		return sources.JobProgressRef{}, err
+	}
+
+	sourceName := "trufflehog - webhook"
+	sourceID, jobID, _ := e.sourceManager.GetIDs(ctx, sourceName, webhook.SourceType)
+
+	webhookSource := &webhook.Source{}
+	if err := webhookSource.Init(ctx, sourceName, jobID, sourceID, true, &conn, 1); err != nil {
+		return sources.JobProgressRef{}, err
+	}
+	webhookSource.WithDetector(e)
+	return e.sourceManager.EnumerateAndScan(ctx, sourceName, webhookSource)
+}
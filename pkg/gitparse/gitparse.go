@@ -253,28 +253,76 @@ func (c *Parser) RepoPath(
 	}
 
 	cmd := exec.Command("git", args...)
-	absPath, err := filepath.Abs(source)
-	if err == nil {
-		if !isBare {
-			cmd.Env = append(cmd.Env, "GIT_DIR="+filepath.Join(absPath, ".git"))
-		} else {
-			cmd.Env = append(cmd.Env,
-				"GIT_DIR="+absPath,
-			)
-			// We need those variables to handle incoming commits
-			// while using trufflehog in pre-receive hooks
-			if dir := os.Getenv("GIT_OBJECT_DIRECTORY"); dir != "" {
-				cmd.Env = append(cmd.Env, "GIT_OBJECT_DIRECTORY="+dir)
-			}
-			if dir := os.Getenv("GIT_ALTERNATE_OBJECT_DIRECTORIES"); dir != "" {
-				cmd.Env = append(cmd.Env, "GIT_ALTERNATE_OBJECT_DIRECTORIES="+dir)
-			}
-		}
+	setGitDir(cmd, source, isBare)
+
+	return c.executeCommand(ctx, cmd, false)
+}
+
+// RevisionsPath parses the output of the `git log` command for the commits
+// of the `source` path that aren't in the history of any ref, e.g. commits
+// that are only referenced by reflogs or are dangling. If noWalk is set, only
+// the commits themselves are logged, and merge commits such as stash entries
+// are diffed against their first parent.
+func (c *Parser) RevisionsPath(
+	ctx context.Context,
+	source string,
+	commits []string,
+	noWalk bool,
+	excludedGlobs []string,
+	isBare bool,
+) (chan *Diff, error) {
+	args := []string{
+		"-C", source,
+		"log",
+		"--patch",
+		"--full-history",
+		"--date=format:%a %b %d %H:%M:%S %Y %z",
+		"--pretty=fuller",
+		"--notes",
+		// The commits are read from stdin, as there may be too many to pass
+		// as arguments.
+		"--stdin",
+	}
+	if noWalk {
+		args = append(args, "--no-walk", "-m", "--first-parent")
+	} else {
+		args = append(args, "--not", "--all")
 	}
+	for _, glob := range excludedGlobs {
+		args = append(args, "--", ".", ":(exclude)"+glob)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(strings.Join(commits, "\n") + "\n")
+	setGitDir(cmd, source, isBare)
 
 	return c.executeCommand(ctx, cmd, false)
 }
 
+// setGitDir points the git command at the repository of the source path.
+func setGitDir(cmd *exec.Cmd, source string, isBare bool) {
+	absPath, err := filepath.Abs(source)
+	if err != nil {
+		return
+	}
+	if !isBare {
+		cmd.Env = append(cmd.Env, "GIT_DIR="+filepath.Join(absPath, ".git"))
+		return
+	}
+
+	cmd.Env = append(cmd.Env,
+		"GIT_DIR="+absPath,
+	)
+	// We need those variables to handle incoming commits
+	// while using trufflehog in pre-receive hooks
+	if dir := os.Getenv("GIT_OBJECT_DIRECTORY"); dir != "" {
+		cmd.Env = append(cmd.Env, "GIT_OBJECT_DIRECTORY="+dir)
+	}
+	if dir := os.Getenv("GIT_ALTERNATE_OBJECT_DIRECTORIES"); dir != "" {
+		cmd.Env = append(cmd.Env, "GIT_ALTERNATE_OBJECT_DIRECTORIES="+dir)
+	}
+}
+
 // Staged parses the output of the `git diff` command for the `source` path.
 func (c *Parser) Staged(ctx context.Context, source string) (chan *Diff, error) {
 	// Provide the --cached flag to diff to get the diff of the staged changes.
@@ -41,15 +41,21 @@ func determineProvider(repo string) provider {
 }
 
 func NormalizeBitbucketRepo(repoURL string) (string, error) {
-	if !strings.HasPrefix(repoURL, "https") {
-		return "", errors.New("Bitbucket requires https repo urls: e.g. https://bitbucket.org/org/repo.git")
+	if !strings.HasPrefix(repoURL, "http:") && !strings.HasPrefix(repoURL, "https:") {
+		return "", errors.New("Bitbucket requires http/https repo urls: e.g. https://bitbucket.org/org/repo.git")
 	}
 
 	return NormalizeOrgRepoURL(providerBitbucket, repoURL)
 }
 
+// NormalizeGerritProject returns the Gerrit project name, stripping any
+// surrounding slashes and ".git" suffix. Project names may contain slashes.
 func NormalizeGerritProject(project string) (string, error) {
-	return "", errors.Errorf("Not yet implemented")
+	project = strings.TrimSuffix(strings.Trim(strings.TrimSpace(project), "/"), ".git")
+	if project == "" {
+		return "", errors.New("Gerrit project name cannot be empty")
+	}
+	return project, nil
 }
 
 func NormalizeGithubRepo(repoURL string) (string, error) {
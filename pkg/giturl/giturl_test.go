@@ -54,8 +54,9 @@ func Test_NormalizeBitbucketRepo(t *testing.T) {
 		Out  string
 		Err  error
 	}{
-		"good":                  {Repo: "https://bitbucket.org/org/repo", Out: "https://bitbucket.org/org/repo.git", Err: nil},
-		"bitbucket needs https": {Repo: "git@bitbucket.org:org/repo.git", Out: "", Err: errors.New("Bitbucket requires https repo urls: e.g. https://bitbucket.org/org/repo.git")},
+		"good":                 {Repo: "https://bitbucket.org/org/repo", Out: "https://bitbucket.org/org/repo.git", Err: nil},
+		"data center http":     {Repo: "http://bitbucket.example.com/PROJ/repo", Out: "http://bitbucket.example.com/PROJ/repo.git", Err: nil},
+		"bitbucket needs http": {Repo: "git@bitbucket.org:org/repo.git", Out: "", Err: errors.New("Bitbucket requires http/https repo urls: e.g. https://bitbucket.org/org/repo.git")},
 	}
 
 	for name, tt := range tests {
@@ -74,6 +75,35 @@ func Test_NormalizeBitbucketRepo(t *testing.T) {
 	}
 }
 
+func Test_NormalizeGerritProject(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		Project string
+		Out     string
+		Err     error
+	}{
+		"good":          {Project: "platform/build", Out: "platform/build", Err: nil},
+		"git suffix":    {Project: "/platform/build.git", Out: "platform/build", Err: nil},
+		"empty project": {Project: " / ", Out: "", Err: errors.New("Gerrit project name cannot be empty")},
+	}
+
+	for name, tt := range tests {
+		out, err := NormalizeGerritProject(tt.Project)
+
+		switch {
+		case err != nil && tt.Err != nil && (err.Error() != tt.Err.Error()):
+			t.Errorf("Test %q, error does not match expected error, \n got: %v \nwant: %v", name, err.Error(), tt.Err.Error())
+		case (err != nil && tt.Err == nil) || (err == nil && tt.Err != nil):
+			t.Errorf("Test %q, error does not match expected error, \n got: %v \nwant: %v", name, err, tt.Err)
+		}
+
+		if out != tt.Out {
+			t.Errorf("Test %q, output does not match expected out, got: %q want: %q", name, out, tt.Out)
+		}
+	}
+}
+
 func Test_NormalizeGitlabRepo(t *testing.T) {
 	t.Parallel()
 
@@ -518,8 +518,10 @@ func getFileExtension(chunkSkel *sources.Chunk) string {
 		fileName = metadata.Teams.File
 	case *source_metadatapb.MetaData_TravisCI:
 		fileName = metadata.TravisCI.Link
+	case *source_metadatapb.MetaData_Webhook:
+		fileName = metadata.Webhook.File
 	// Add other sources if they have a file or equivalent field
-	// Skipping Syslog, Forager, Postman, Vector, Webhook and Elasticsearch
+	// Skipping Syslog, Forager, Postman, Vector and Elasticsearch
 	default:
 		return ""
 	}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers"
+)
+
+// Analysis is the JSON representation of an analyzer result. Parents are
+// referenced by name rather than nested, to keep the output flat.
+type Analysis struct {
+	AnalyzerType       string
+	Bindings           []AnalysisBinding
+	UnboundedResources []AnalysisResource `json:",omitempty"`
+	Metadata           map[string]any     `json:",omitempty"`
+}
+
+// AnalysisBinding is a permission granted on a resource.
+type AnalysisBinding struct {
+	Resource         AnalysisResource
+	Permission       string
+	ParentPermission string `json:",omitempty"`
+}
+
+// AnalysisResource is a resource the credential has access to.
+type AnalysisResource struct {
+	Name               string
+	FullyQualifiedName string
+	Type               string
+	Metadata           map[string]any `json:",omitempty"`
+	// Parent is the fully qualified name of the parent resource.
+	Parent string `json:",omitempty"`
+}
+
+// NewAnalysis converts an analyzer result for JSON output. It returns nil if
+// the result is nil, so that unanalyzed results omit it.
+func NewAnalysis(r *analyzers.AnalyzerResult) *Analysis {
+	if r == nil {
+		return nil
+	}
+
+	a := &Analysis{
+		AnalyzerType: r.AnalyzerType.String(),
+		Bindings:     make([]AnalysisBinding, 0, len(r.Bindings)),
+		Metadata:     r.Metadata,
+	}
+	for _, binding := range r.Bindings {
+		b := AnalysisBinding{
+			Resource:   newAnalysisResource(binding.Resource),
+			Permission: binding.Permission.Value,
+		}
+		if binding.Permission.Parent != nil {
+			b.ParentPermission = binding.Permission.Parent.Value
+		}
+		a.Bindings = append(a.Bindings, b)
+	}
+	for _, resource := range r.UnboundedResources {
+		a.UnboundedResources = append(a.UnboundedResources, newAnalysisResource(resource))
+	}
+	return a
+}
+
+func newAnalysisResource(r analyzers.Resource) AnalysisResource {
+	resource := AnalysisResource{
+		Name:               r.Name,
+		FullyQualifiedName: r.FullyQualifiedName,
+		Type:               r.Type,
+		Metadata:           r.Metadata,
+	}
+	if r.Parent != nil {
+		resource.Parent = r.Parent.FullyQualifiedName
+	}
+	return resource
+}
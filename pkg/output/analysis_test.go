@@ -0,0 +1,49 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/analyzer/analyzers"
+)
+
+func TestNewAnalysis(t *testing.T) {
+	assert.Nil(t, NewAnalysis(nil))
+
+	org := &analyzers.Resource{Name: "acme", FullyQualifiedName: "github.com/acme", Type: "organization"}
+	a := NewAnalysis(&analyzers.AnalyzerResult{
+		AnalyzerType: analyzers.AnalyzerTypeGitHub,
+		Bindings: []analyzers.Binding{{
+			Resource: analyzers.Resource{
+				Name:               "widgets",
+				FullyQualifiedName: "github.com/acme/widgets",
+				Type:               "repository",
+				Parent:             org,
+			},
+			Permission: analyzers.Permission{Value: "contents:write", Parent: &analyzers.Permission{Value: "repo"}},
+		}},
+		UnboundedResources: []analyzers.Resource{*org},
+		Metadata:           map[string]any{"expires": "never"},
+	})
+
+	out, err := json.Marshal(a)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"AnalyzerType": "GitHub",
+		"Bindings": [{
+			"Resource": {
+				"Name": "widgets",
+				"FullyQualifiedName": "github.com/acme/widgets",
+				"Type": "repository",
+				"Parent": "github.com/acme"
+			},
+			"Permission": "contents:write",
+			"ParentPermission": "repo"
+		}],
+		"UnboundedResources": [{"Name": "acme", "FullyQualifiedName": "github.com/acme", "Type": "organization"}],
+		"Metadata": {"expires": "never"}
+	}`, string(out))
+}
@@ -17,6 +17,20 @@ import (
 type JSONPrinter struct{ mu sync.Mutex }
 
 func (p *JSONPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	out, err := MarshalJSON(r)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	fmt.Println(string(out))
+	p.mu.Unlock()
+	return nil
+}
+
+// MarshalJSON encodes the result as a single line of JSON, in the format
+// printed by JSONPrinter.
+func MarshalJSON(r *detectors.ResultWithMetadata) ([]byte, error) {
 	verificationErr := func(err error) string {
 		if err != nil {
 			return err.Error()
@@ -54,6 +68,8 @@ func (p *JSONPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata)
 		Redacted       string
 		ExtraData      map[string]string
 		StructuredData *detectorspb.StructuredData
+		// Analysis contains the permissions of a verified credential, if it was analyzed.
+		Analysis *Analysis `json:",omitempty"`
 	}{
 		SourceMetadata:        r.SourceMetadata,
 		SourceID:              r.SourceID,
@@ -71,14 +87,11 @@ func (p *JSONPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata)
 		Redacted:              r.Redacted,
 		ExtraData:             r.ExtraData,
 		StructuredData:        r.StructuredData,
+		Analysis:              NewAnalysis(r.AnalyzerResult),
 	}
 	out, err := json.Marshal(v)
 	if err != nil {
-		return fmt.Errorf("could not marshal result: %w", err)
+		return nil, fmt.Errorf("could not marshal result: %w", err)
 	}
-
-	p.mu.Lock()
-	fmt.Println(string(out))
-	p.mu.Unlock()
-	return nil
+	return out, nil
 }
@@ -105,7 +105,9 @@ func (p *PlainPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata)
 	}
 
 	// if analysis info is not nil, means the detector added key for analyzer and result is verified
-	if r.Result.AnalysisInfo != nil && r.Result.Verified {
+	if r.AnalyzerResult != nil {
+		printer.Printf("Analysis: %d permission bindings, see the JSON output for details\n", len(r.AnalyzerResult.Bindings))
+	} else if r.Result.AnalysisInfo != nil && r.Result.Verified {
 		printer.Printf("Analyze: Run `trufflehog analyze` to analyze this key's permissions\n")
 	}
 
@@ -0,0 +1,259 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/version"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIFPrinter is a printer that collects results and prints them as a SARIF
+// 2.1.0 log once the scan is done. Flush must be called to print the log.
+type SARIFPrinter struct {
+	mu      sync.Mutex
+	rules   map[string]sarifRule
+	results []sarifResult
+}
+
+func (p *SARIFPrinter) Print(_ context.Context, r *detectors.ResultWithMetadata) error {
+	ruleID := r.DetectorType.String()
+	// Custom detectors share a detector type, so tell them apart by name.
+	if name, ok := r.ExtraData["name"]; ok && r.DetectorType == detectorspb.DetectorType_CustomRegex {
+		ruleID = fmt.Sprintf("%s/%s", ruleID, name)
+	}
+
+	verifiedStatus := "unverified"
+	level := "warning"
+	if r.Verified {
+		verifiedStatus = "verified"
+		level = "error"
+	}
+	message := fmt.Sprintf("Found %s %s result", verifiedStatus, ruleID)
+	if r.DecoderType != detectorspb.DecoderType_PLAIN {
+		message = fmt.Sprintf("Found %s %s result with %s encoding", verifiedStatus, ruleID, r.DecoderType)
+	}
+
+	properties := map[string]any{
+		"verified":   r.Verified,
+		"decoder":    r.DecoderType.String(),
+		"sourceType": r.SourceType.String(),
+		"sourceName": r.SourceName,
+	}
+	if err := r.VerificationError(); err != nil {
+		properties["verificationError"] = err.Error()
+	}
+	if r.Redacted != "" {
+		properties["redacted"] = r.Redacted
+	}
+
+	location, err := sarifLocationFromResult(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+	result := sarifResult{
+		RuleID:     ruleID,
+		Level:      level,
+		Message:    sarifMessage{Text: message},
+		Properties: properties,
+	}
+	if location != nil {
+		result.Locations = []sarifLocation{*location}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rules == nil {
+		p.rules = make(map[string]sarifRule)
+	}
+	if _, ok := p.rules[ruleID]; !ok {
+		shortDescription := fmt.Sprintf("%s secret", ruleID)
+		description := r.DetectorDescription
+		if description == "" {
+			description = shortDescription
+		}
+		p.rules[ruleID] = sarifRule{
+			ID:               ruleID,
+			Name:             ruleID,
+			ShortDescription: sarifMessage{Text: shortDescription},
+			FullDescription:  sarifMessage{Text: description},
+		}
+	}
+	p.results = append(p.results, result)
+	return nil
+}
+
+// Flush prints the SARIF log of every result printed so far to stdout.
+func (p *SARIFPrinter) Flush() error {
+	return p.writeLog(os.Stdout)
+}
+
+func (p *SARIFPrinter) writeLog(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ruleIDs := make([]string, 0, len(p.rules))
+	for id := range p.rules {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+	rules := make([]sarifRule, 0, len(ruleIDs))
+	ruleIndex := make(map[string]int, len(ruleIDs))
+	for i, id := range ruleIDs {
+		rules = append(rules, p.rules[id])
+		ruleIndex[id] = i
+	}
+
+	results := make([]sarifResult, 0, len(p.results))
+	for _, result := range p.results {
+		result.RuleIndex = ruleIndex[result.RuleID]
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "TruffleHog",
+				Version:        version.BuildVersion,
+				InformationURI: "https://github.com/trufflesecurity/trufflehog",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(log); err != nil {
+		return fmt.Errorf("could not write SARIF log: %w", err)
+	}
+	return nil
+}
+
+// sarifLocationFromResult derives the location of the result from the file
+// and line of its source metadata, falling back to its link. It returns nil
+// if the source metadata has neither.
+func sarifLocationFromResult(r *detectors.ResultWithMetadata) (*sarifLocation, error) {
+	if r.SourceMetadata == nil {
+		return nil, nil
+	}
+	meta, err := structToMap(r.SourceMetadata.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		uri  string
+		line int64
+		link string
+	)
+	for _, data := range meta {
+		for k, v := range data {
+			switch k {
+			case "file":
+				if file, ok := v.(string); ok {
+					uri = filepath.ToSlash(file)
+				}
+			case "line":
+				if l, ok := v.(float64); ok {
+					line = int64(l)
+				}
+			case "link":
+				if l, ok := v.(string); ok {
+					link = l
+				}
+			}
+		}
+	}
+	if uri == "" {
+		uri = link
+	}
+	if uri == "" {
+		return nil, nil
+	}
+
+	location := &sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: uri},
+	}}
+	// SARIF lines are 1-based, so a zero line means it is unknown.
+	if line > 0 {
+		location.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+	}
+	return location, nil
+}
+
+// The types below are the subset of the SARIF 2.1.0 object model used by the
+// printer.
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int64 `json:"startLine"`
+}
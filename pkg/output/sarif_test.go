@@ -0,0 +1,94 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+func TestSARIFPrinter(t *testing.T) {
+	ctx := context.Background()
+	p := new(SARIFPrinter)
+
+	verified := &detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Git{
+			Git: &source_metadatapb.Git{File: "config/app.env", Line: 3, Commit: "abc"},
+		}},
+		SourceType:          sourcespb.SourceType_SOURCE_TYPE_GIT,
+		SourceName:          "trufflehog - git",
+		Result:              detectors.Result{DetectorType: detectorspb.DetectorType_AWS, Verified: true, Redacted: "AKIAEXAMPLE"},
+		DetectorDescription: "AWS access keys",
+		DecoderType:         detectorspb.DecoderType_PLAIN,
+	}
+	unknown := &detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Filesystem{
+			Filesystem: &source_metadatapb.Filesystem{File: "notes.txt"},
+		}},
+		Result: detectors.Result{
+			DetectorType: detectorspb.DetectorType_CustomRegex,
+			ExtraData:    map[string]string{"name": "Internal"},
+		},
+		DecoderType: detectorspb.DecoderType_BASE64,
+	}
+	unknown.SetVerificationError(errors.New("unexpected HTTP response status 503"))
+	noLocation := &detectors.ResultWithMetadata{
+		SourceMetadata: &source_metadatapb.MetaData{Data: &source_metadatapb.MetaData_Stdin{Stdin: &source_metadatapb.Stdin{}}},
+		Result:         detectors.Result{DetectorType: detectorspb.DetectorType_AWS},
+	}
+	for _, r := range []*detectors.ResultWithMetadata{verified, unknown, noLocation} {
+		require.NoError(t, p.Print(ctx, r))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, p.writeLog(&buf))
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "TruffleHog", run.Tool.Driver.Name)
+	assert.Equal(t, []sarifRule{
+		{ID: "AWS", Name: "AWS", ShortDescription: sarifMessage{Text: "AWS secret"}, FullDescription: sarifMessage{Text: "AWS access keys"}},
+		{ID: "CustomRegex/Internal", Name: "CustomRegex/Internal", ShortDescription: sarifMessage{Text: "CustomRegex/Internal secret"}, FullDescription: sarifMessage{Text: "CustomRegex/Internal secret"}},
+	}, run.Tool.Driver.Rules)
+
+	require.Len(t, run.Results, 3)
+	assert.Equal(t, "AWS", run.Results[0].RuleID)
+	assert.Equal(t, 0, run.Results[0].RuleIndex)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "Found verified AWS result", run.Results[0].Message.Text)
+	assert.Equal(t, []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: "config/app.env"},
+		Region:           &sarifRegion{StartLine: 3},
+	}}}, run.Results[0].Locations)
+	assert.Equal(t, true, run.Results[0].Properties["verified"])
+	assert.Equal(t, "AKIAEXAMPLE", run.Results[0].Properties["redacted"])
+
+	assert.Equal(t, "CustomRegex/Internal", run.Results[1].RuleID)
+	assert.Equal(t, 1, run.Results[1].RuleIndex)
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Equal(t, "Found unverified CustomRegex/Internal result with BASE64 encoding", run.Results[1].Message.Text)
+	assert.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, false, run.Results[1].Properties["verified"])
+	assert.Equal(t, "unexpected HTTP response status 503", run.Results[1].Properties["verificationError"])
+
+	assert.Empty(t, run.Results[2].Locations)
+}
+
+func TestSARIFPrinter_NoResults(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, new(SARIFPrinter).writeLog(&buf))
+	assert.Contains(t, buf.String(), `"results": []`)
+	assert.Contains(t, buf.String(), `"rules": []`)
+}
@@ -124,6 +124,40 @@ func (m *Config) validate(all bool) error {
 
 	}
 
+	for idx, item := range m.GetAllowlist() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ConfigValidationError{
+						field:  fmt.Sprintf("Allowlist[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ConfigValidationError{
+						field:  fmt.Sprintf("Allowlist[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ConfigValidationError{
+					field:  fmt.Sprintf("Allowlist[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
 	if len(errors) > 0 {
 		return ConfigMultiError(errors)
 	}
@@ -200,3 +234,105 @@ var _ interface {
 	Cause() error
 	ErrorName() string
 } = ConfigValidationError{}
+
+// Validate checks the field values on AllowlistRule with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *AllowlistRule) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on AllowlistRule with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in AllowlistRuleMultiError, or
+// nil if none found.
+func (m *AllowlistRule) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *AllowlistRule) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Description
+
+	if len(errors) > 0 {
+		return AllowlistRuleMultiError(errors)
+	}
+
+	return nil
+}
+
+// AllowlistRuleMultiError is an error wrapping multiple validation errors
+// returned by AllowlistRule.ValidateAll() if the designated constraints
+// aren't met.
+type AllowlistRuleMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m AllowlistRuleMultiError) Error() string {
+	var msgs []string
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m AllowlistRuleMultiError) AllErrors() []error { return m }
+
+// AllowlistRuleValidationError is the validation error returned by
+// AllowlistRule.Validate if the designated constraints aren't met.
+type AllowlistRuleValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e AllowlistRuleValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e AllowlistRuleValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e AllowlistRuleValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e AllowlistRuleValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e AllowlistRuleValidationError) ErrorName() string { return "AllowlistRuleValidationError" }
+
+// Error satisfies the builtin error interface
+func (e AllowlistRuleValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sAllowlistRule.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = AllowlistRuleValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = AllowlistRuleValidationError{}
@@ -853,6 +853,12 @@ func (m *ECR) validate(all bool) error {
 
 	// no validation rules for Email
 
+	// no validation rules for Repository
+
+	// no validation rules for Tag
+
+	// no validation rules for Digest
+
 	if len(errors) > 0 {
 		return ECRMultiError(errors)
 	}
@@ -1070,6 +1076,16 @@ func (m *Git) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for Origin
+
+	// no validation rules for Blob
+
+	// no validation rules for SuperprojectRepository
+
+	// no validation rules for SuperprojectCommit
+
+	// no validation rules for SubmodulePath
+
 	if len(errors) > 0 {
 		return GitMultiError(errors)
 	}
@@ -2195,6 +2211,10 @@ func (m *Gerrit) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for ChangeNumber
+
+	// no validation rules for Patchset
+
 	if len(errors) > 0 {
 		return GerritMultiError(errors)
 	}
@@ -3666,6 +3686,14 @@ func (m *Webhook) validate(all bool) error {
 
 	var errors []error
 
+	// no validation rules for RequestId
+
+	// no validation rules for File
+
+	// no validation rules for ContentType
+
+	// no validation rules for RemoteAddress
+
 	switch v := m.Data.(type) {
 	case *Webhook_Vector:
 		if v == nil {
@@ -1540,6 +1540,8 @@ func (m *ECR) validate(all bool) error {
 
 	var errors []error
 
+	// no validation rules for Endpoint
+
 	switch v := m.Credential.(type) {
 	case *ECR_AccessKey:
 		if v == nil {
@@ -2073,6 +2075,22 @@ func (m *Git) validate(all bool) error {
 
 	// no validation rules for SkipArchives
 
+	// no validation rules for Lfs
+
+	// no validation rules for LfsEndpoint
+
+	// no validation rules for Stashes
+
+	// no validation rules for Reflog
+
+	// no validation rules for Dangling
+
+	// no validation rules for Submodules
+
+	// no validation rules for Snapshot
+
+	// no validation rules for PushedRevisions
+
 	switch v := m.Credential.(type) {
 	case *Git_BasicAuth:
 		if v == nil {
@@ -3723,6 +3741,8 @@ func (m *NPMUnauthenticatedPackage) validate(all bool) error {
 
 	var errors []error
 
+	// no validation rules for Registry
+
 	switch v := m.Credential.(type) {
 	case *NPMUnauthenticatedPackage_Unauthenticated:
 		if v == nil {
@@ -3871,6 +3891,8 @@ func (m *PyPIUnauthenticatedPackage) validate(all bool) error {
 
 	var errors []error
 
+	// no validation rules for Registry
+
 	switch v := m.Credential.(type) {
 	case *PyPIUnauthenticatedPackage_Unauthenticated:
 		if v == nil {
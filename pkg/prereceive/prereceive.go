@@ -0,0 +1,133 @@
+// Package prereceive works out which commits a push adds to a repository,
+// so that a pre-receive hook scans only those, and builds the message that
+// rejects the push when secrets are found in them.
+package prereceive
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// objectNameRe matches full SHA-1 and SHA-256 object names.
+var objectNameRe = regexp.MustCompile(`^(?:[0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// RefUpdate is a ref that a push creates, updates or deletes, as read by a
+// pre-receive hook from its standard input.
+type RefUpdate struct {
+	OldRev string
+	NewRev string
+	Ref    string
+}
+
+// Created reports whether the push creates the ref.
+func (u RefUpdate) Created() bool { return isZero(u.OldRev) }
+
+// Deleted reports whether the push deletes the ref.
+func (u RefUpdate) Deleted() bool { return isZero(u.NewRev) }
+
+// isZero reports whether the object name is all zeros, which git uses for
+// refs that don't exist before or after the push.
+func isZero(rev string) bool { return strings.Trim(rev, "0") == "" }
+
+// ParseUpdates reads the `<old-value> <new-value> <ref-name>` lines that git
+// passes to pre-receive hooks.
+func ParseUpdates(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !objectNameRe.MatchString(fields[0]) || !objectNameRe.MatchString(fields[1]) {
+			return nil, fmt.Errorf("invalid ref update: %q", line)
+		}
+		updates = append(updates, RefUpdate{OldRev: fields[0], NewRev: fields[1], Ref: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading ref updates: %w", err)
+	}
+	return updates, nil
+}
+
+// Push is the set of ref updates of a push, and the commits it adds to the
+// repository for each of them.
+type Push struct {
+	Updates []RefUpdate
+	// commits holds the new commits of each update, in the same order.
+	commits [][]string
+}
+
+// NewPush lists the commits that the updates add to the repository in the
+// git directory. It must run before the refs are updated, i.e. in a
+// pre-receive hook, where the new commits are the ones that aren't reachable
+// from any existing ref. This covers new refs, whose history is mostly
+// already in the repository, and force pushes, whose old commits aren't new.
+// Deleted refs add no commits.
+func NewPush(ctx context.Context, gitDir string, updates []RefUpdate) (*Push, error) {
+	push := &Push{Updates: updates, commits: make([][]string, len(updates))}
+	for i, update := range updates {
+		if update.Deleted() {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, "git", "-C", gitDir, "rev-list", update.NewRev, "--not", "--all")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("error listing the new commits of %s: %w\n%s", update.Ref, err, stderr.Bytes())
+		}
+		push.commits[i] = strings.Fields(string(out))
+	}
+	return push, nil
+}
+
+// Revisions returns the new tips of the refs that the push adds commits to.
+func (p *Push) Revisions() []string {
+	var revisions []string
+	seen := make(map[string]struct{})
+	for i, update := range p.Updates {
+		if len(p.commits[i]) == 0 {
+			continue
+		}
+		if _, ok := seen[update.NewRev]; ok {
+			continue
+		}
+		seen[update.NewRev] = struct{}{}
+		revisions = append(revisions, update.NewRev)
+	}
+	return revisions
+}
+
+// Commits returns the number of distinct commits that the push adds.
+func (p *Push) Commits() int {
+	seen := make(map[string]struct{})
+	for _, commits := range p.commits {
+		for _, commit := range commits {
+			seen[commit] = struct{}{}
+		}
+	}
+	return len(seen)
+}
+
+// refs returns the refs whose new commits include the commit.
+func (p *Push) refs(commit string) []string {
+	var refs []string
+	for i, commits := range p.commits {
+		for _, c := range commits {
+			if c == commit {
+				refs = append(refs, p.Updates[i].Ref)
+				break
+			}
+		}
+	}
+	return refs
+}
@@ -0,0 +1,144 @@
+package prereceive
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+const zero = "0000000000000000000000000000000000000000"
+
+// pushRepo is a repository with commits that aren't reachable from any ref
+// yet, as in a pre-receive hook.
+type pushRepo struct {
+	dir string
+	// main and topic are the tips of the existing branches, A and X.
+	main, topic string
+	// b and c are new commits on top of main, and y replaces topic.
+	b, c, y string
+}
+
+func newPushRepo(t *testing.T) pushRepo {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "-b", "main")
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+	git("commit", "-q", "--allow-empty", "-m", "A")
+	git("checkout", "-q", "-b", "topic")
+	git("commit", "-q", "--allow-empty", "-m", "X")
+	git("checkout", "-q", "main")
+	git("branch", "gone", "topic")
+
+	r := pushRepo{dir: dir, main: git("rev-parse", "main"), topic: git("rev-parse", "topic")}
+	// commit-tree creates commits without updating any ref.
+	tree := r.main + "^{tree}"
+	r.b = git("commit-tree", tree, "-p", r.main, "-m", "B")
+	r.c = git("commit-tree", tree, "-p", r.b, "-m", "C")
+	r.y = git("commit-tree", tree, "-p", r.main, "-m", "Y")
+	return r
+}
+
+func TestParseUpdates(t *testing.T) {
+	sha256 := strings.Repeat("a", 64)
+	updates, err := ParseUpdates(strings.NewReader(
+		zero + " " + strings.Repeat("b", 40) + " refs/heads/new\n\n" +
+			sha256 + " " + strings.Repeat("0", 64) + " refs/heads/gone\n",
+	))
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+	assert.True(t, updates[0].Created())
+	assert.False(t, updates[0].Deleted())
+	assert.Equal(t, "refs/heads/new", updates[0].Ref)
+	assert.True(t, updates[1].Deleted())
+	assert.False(t, updates[1].Created())
+
+	for _, line := range []string{
+		zero + " refs/heads/main",
+		"HEAD~1 " + zero + " refs/heads/main",
+		"--all " + zero + " refs/heads/main",
+	} {
+		_, err := ParseUpdates(strings.NewReader(line))
+		assert.Error(t, err, line)
+	}
+}
+
+func TestNewPush(t *testing.T) {
+	ctx := context.Background()
+	r := newPushRepo(t)
+
+	push, err := NewPush(ctx, r.dir, []RefUpdate{
+		{OldRev: r.main, NewRev: r.c, Ref: "refs/heads/main"},
+		// A new branch at the same commit.
+		{OldRev: zero, NewRev: r.c, Ref: "refs/heads/feature"},
+		// A force push.
+		{OldRev: r.topic, NewRev: r.y, Ref: "refs/heads/topic"},
+		{OldRev: r.topic, NewRev: zero, Ref: "refs/heads/gone"},
+		// A new tag of an existing commit.
+		{OldRev: zero, NewRev: r.main, Ref: "refs/tags/v1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{r.c, r.b}, {r.c, r.b}, {r.y}, nil, {}}, push.commits)
+	assert.Equal(t, []string{r.c, r.y}, push.Revisions())
+	assert.Equal(t, 3, push.Commits())
+
+	_, err = NewPush(ctx, r.dir, []RefUpdate{{OldRev: zero, NewRev: strings.Repeat("1", 40), Ref: "refs/heads/missing"}})
+	assert.Error(t, err)
+}
+
+func TestRejection(t *testing.T) {
+	ctx := context.Background()
+	r := newPushRepo(t)
+	push, err := NewPush(ctx, r.dir, []RefUpdate{
+		{OldRev: r.main, NewRev: r.c, Ref: "refs/heads/main"},
+		{OldRev: r.topic, NewRev: r.y, Ref: "refs/heads/topic"},
+	})
+	require.NoError(t, err)
+
+	rejection := NewRejection(push)
+	assert.False(t, rejection.Rejected())
+
+	result := func(git *source_metadatapb.Git, verified bool) detectors.ResultWithMetadata {
+		return detectors.ResultWithMetadata{
+			SourceMetadata: &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Git{Git: git},
+			},
+			Result: detectors.Result{DetectorType: detectorspb.DetectorType_AWS, Verified: verified},
+		}
+	}
+	inB := result(&source_metadatapb.Git{Commit: r.b, File: "config.yaml", Line: 3}, true)
+	require.NoError(t, rejection.Dispatch(ctx, inB))
+	require.NoError(t, rejection.Dispatch(ctx, inB))
+	require.NoError(t, rejection.Dispatch(ctx, result(&source_metadatapb.Git{
+		Commit:             strings.Repeat("2", 40),
+		File:               "lib.txt",
+		Line:               1,
+		SuperprojectCommit: r.y,
+		SubmodulePath:      "vendor/lib",
+	}, false)))
+	assert.True(t, rejection.Rejected())
+
+	var out bytes.Buffer
+	_, err = rejection.WriteTo(&out)
+	require.NoError(t, err)
+	msg := out.String()
+	assert.Contains(t, msg, "Push rejected: 2 secrets found")
+	assert.Contains(t, msg, "*** refs/heads/main\n***   "+r.b[:12]+" config.yaml:3 AWS (verified)\n")
+	assert.Contains(t, msg, "*** refs/heads/topic\n***   "+r.y[:12]+" vendor/lib/lib.txt:1 AWS\n")
+	assert.NotContains(t, msg, "other commits")
+}
@@ -0,0 +1,126 @@
+package prereceive
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// finding is a secret found in one of the pushed commits.
+type finding struct {
+	commit   string
+	file     string
+	line     int64
+	detector string
+	verified bool
+}
+
+// Rejection collects the results of scanning a push, and explains to the
+// pusher why it was rejected. It's a results dispatcher for the engine.
+type Rejection struct {
+	push *Push
+
+	mu       sync.Mutex
+	findings []finding
+	seen     map[finding]struct{}
+}
+
+// NewRejection creates a Rejection for the results of scanning the push.
+func NewRejection(push *Push) *Rejection {
+	return &Rejection{push: push, seen: make(map[finding]struct{})}
+}
+
+// Dispatch records the commit and location of the result. A secret found in
+// several chunks of the same file is recorded once.
+func (r *Rejection) Dispatch(_ context.Context, result detectors.ResultWithMetadata) error {
+	git := result.SourceMetadata.GetGit()
+	f := finding{
+		commit:   git.GetCommit(),
+		file:     git.GetFile(),
+		line:     git.GetLine(),
+		detector: result.DetectorType.String(),
+		verified: result.Verified,
+	}
+	// Findings in submodules are attributed to the pushed commit that pins
+	// them.
+	if superproject := git.GetSuperprojectCommit(); superproject != "" {
+		f.commit = superproject
+		f.file = git.GetSubmodulePath() + "/" + f.file
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.seen[f]; ok {
+		return nil
+	}
+	r.seen[f] = struct{}{}
+	r.findings = append(r.findings, f)
+	return nil
+}
+
+// Rejected reports whether any secrets were found in the push.
+func (r *Rejection) Rejected() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.findings) > 0
+}
+
+// WriteTo writes the rejection message, grouping the findings by the refs
+// whose new commits contain them. Git relays what a pre-receive hook writes
+// to the pusher, prefixed with "remote:".
+func (r *Rejection) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byRef := make(map[string][]finding)
+	var other []finding
+	for _, f := range r.findings {
+		refs := r.push.refs(f.commit)
+		if len(refs) == 0 {
+			other = append(other, f)
+		}
+		for _, ref := range refs {
+			byRef[ref] = append(byRef[ref], f)
+		}
+	}
+
+	var b strings.Builder
+	secrets := "secrets"
+	if len(r.findings) == 1 {
+		secrets = "secret"
+	}
+	fmt.Fprintf(&b, "*** Push rejected: %d %s found in the pushed commits.\n", len(r.findings), secrets)
+	writeGroup := func(name string, findings []finding) {
+		if len(findings) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "***\n*** %s\n", name)
+		for _, f := range findings {
+			location := f.file
+			if f.line > 0 {
+				location = fmt.Sprintf("%s:%d", f.file, f.line)
+			}
+			verified := ""
+			if f.verified {
+				verified = " (verified)"
+			}
+			fmt.Fprintf(&b, "***   %.12s %s %s%s\n", f.commit, location, f.detector, verified)
+		}
+	}
+	for _, update := range r.push.Updates {
+		writeGroup(update.Ref, byRef[update.Ref])
+		// A ref may be listed more than once.
+		delete(byRef, update.Ref)
+	}
+	writeGroup("other commits", other)
+	b.WriteString("***\n")
+	b.WriteString("*** Remove the secrets from the history of these commits, e.g. with\n")
+	b.WriteString("*** `git rebase -i`, revoke any that are live, and push again.\n")
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,297 @@
+// Package reverify re-verifies the findings of a previous scan, to tell which
+// secrets are still live without scanning the sources again.
+package reverify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// Status is the state of a finding's secret when it was re-verified.
+type Status string
+
+const (
+	// StatusLive means the secret was verified and is still active.
+	StatusLive Status = "live"
+	// StatusRotated means the secret no longer verifies, e.g. because it was
+	// rotated or revoked.
+	StatusRotated Status = "rotated"
+	// StatusUnknown means the secret could not be re-verified, e.g. because
+	// verification failed or the detector does not exist.
+	StatusUnknown Status = "unknown"
+)
+
+// Finding is a result read from the JSON output of a scan.
+type Finding struct {
+	DetectorType   detectorspb.DetectorType
+	DetectorName   string
+	Verified       bool
+	Raw            string
+	RawV2          string
+	Redacted       string
+	ExtraData      map[string]string
+	SourceMetadata json.RawMessage
+}
+
+// Report is the outcome of re-verifying a finding. It identifies the finding
+// by its detector, redacted secret and source metadata, never the secret
+// itself.
+type Report struct {
+	DetectorName       string
+	Redacted           string          `json:",omitempty"`
+	SourceMetadata     json.RawMessage `json:",omitempty"`
+	PreviouslyVerified bool
+	Status             Status
+	VerificationError  string `json:",omitempty"`
+}
+
+// Summary counts the reports by status.
+type Summary struct {
+	Live    int
+	Rotated int
+	Unknown int
+}
+
+// Reverifier re-verifies findings with the detectors they were found by.
+type Reverifier struct {
+	concurrency int
+	// detectors are the detectors by type.
+	detectors map[detectorspb.DetectorType][]detectors.Detector
+
+	mu sync.Mutex
+	// cache holds the outcome of each secret, which is only re-verified once
+	// however often it was found.
+	cache    map[string]outcome
+	inflight singleflight.Group
+}
+
+type outcome struct {
+	status Status
+	err    string
+}
+
+// New creates a Reverifier that uses the provided detectors, e.g. the default
+// detectors, and re-verifies up to concurrency findings at a time.
+func New(dets []detectors.Detector, concurrency int) *Reverifier {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	r := &Reverifier{
+		concurrency: concurrency,
+		detectors:   make(map[detectorspb.DetectorType][]detectors.Detector),
+		cache:       make(map[string]outcome),
+	}
+	for _, d := range dets {
+		r.detectors[d.Type()] = append(r.detectors[d.Type()], d)
+	}
+	return r
+}
+
+// maxLineSize bounds a line of JSON output, which contains the chunk metadata
+// and may contain large secrets such as private keys.
+const maxLineSize = 16 * 1024 * 1024
+
+// Run reads the JSON lines output of a scan from in, re-verifies every finding
+// and writes a JSON line report for each to out. Reports are written as
+// findings are re-verified, so they may be out of order.
+func (r *Reverifier) Run(ctx context.Context, in io.Reader, out io.Writer) (Summary, error) {
+	var (
+		summary Summary
+		mu      sync.Mutex
+		enc     = json.NewEncoder(out)
+	)
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(r.concurrency)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var finding Finding
+		if err := json.Unmarshal(scanner.Bytes(), &finding); err != nil {
+			_ = g.Wait()
+			return summary, fmt.Errorf("error decoding finding on line %d: %w", line, err)
+		}
+
+		g.Go(func() error {
+			report := r.Reverify(context.AddLogger(gCtx), finding)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch report.Status {
+			case StatusLive:
+				summary.Live++
+			case StatusRotated:
+				summary.Rotated++
+			default:
+				summary.Unknown++
+			}
+			return enc.Encode(report)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return summary, fmt.Errorf("error writing report: %w", err)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return summary, fmt.Errorf("error reading findings: line exceeds %d bytes", maxLineSize)
+		}
+		return summary, fmt.Errorf("error reading findings: %w", err)
+	}
+	return summary, nil
+}
+
+// Reverify re-verifies a single finding.
+func (r *Reverifier) Reverify(ctx context.Context, finding Finding) Report {
+	report := Report{
+		DetectorName:       finding.DetectorName,
+		Redacted:           finding.Redacted,
+		SourceMetadata:     finding.SourceMetadata,
+		PreviouslyVerified: finding.Verified,
+	}
+
+	key := fmt.Sprintf("%d\x00%s\x00%d:%s\x00%s", finding.DetectorType, finding.ExtraData["version"], len(finding.Raw), finding.Raw, finding.RawV2)
+	v, _, _ := r.inflight.Do(key, func() (any, error) {
+		r.mu.Lock()
+		cached, ok := r.cache[key]
+		r.mu.Unlock()
+		if ok {
+			return cached, nil
+		}
+
+		cached = r.verify(ctx, finding)
+		r.mu.Lock()
+		r.cache[key] = cached
+		r.mu.Unlock()
+		return cached, nil
+	})
+	cached := v.(outcome)
+
+	report.Status = cached.status
+	report.VerificationError = cached.err
+	return report
+}
+
+// verify runs the finding's detector on data reconstructed from the secret.
+// The detector must find the same secret again for its verification to count.
+func (r *Reverifier) verify(ctx context.Context, finding Finding) outcome {
+	candidates := r.detectorsFor(finding)
+	if len(candidates) == 0 {
+		return outcome{status: StatusUnknown, err: fmt.Sprintf("no detector for %s findings", finding.DetectorName)}
+	}
+
+	var verificationErr error
+	for _, d := range candidates {
+		detectCtx, cancel := context.WithTimeout(ctx, detectors.DefaultResponseTimeout)
+		results, err := d.FromData(detectCtx, true, reconstruct(d, finding))
+		cancel()
+		if err != nil {
+			verificationErr = err
+			continue
+		}
+		for _, result := range results {
+			if !sameSecret(result, finding) {
+				continue
+			}
+			if result.Verified {
+				return outcome{status: StatusLive}
+			}
+			if err := result.VerificationError(); err != nil {
+				verificationErr = err
+				continue
+			}
+			return outcome{status: StatusRotated}
+		}
+	}
+
+	if verificationErr != nil {
+		return outcome{status: StatusUnknown, err: verificationErr.Error()}
+	}
+	return outcome{status: StatusUnknown, err: "the detector did not find the secret again"}
+}
+
+// Rediscover runs the finding's detector without verification on data
+// reconstructed from the secret, and returns its result for the same secret.
+// The result has what the scan output leaves out, e.g. the credential parts
+// an analyzer needs.
+func (r *Reverifier) Rediscover(ctx context.Context, finding Finding) (detectors.Result, bool) {
+	for _, d := range r.detectorsFor(finding) {
+		detectCtx, cancel := context.WithTimeout(ctx, detectors.DefaultResponseTimeout)
+		results, err := d.FromData(detectCtx, false, reconstruct(d, finding))
+		cancel()
+		if err != nil {
+			continue
+		}
+		for _, result := range results {
+			if sameSecret(result, finding) {
+				return result, true
+			}
+		}
+	}
+	return detectors.Result{}, false
+}
+
+// detectorsFor returns the detectors that may have found the finding. If its
+// extra data records the detector version, only that version is returned.
+func (r *Reverifier) detectorsFor(finding Finding) []detectors.Detector {
+	candidates := r.detectors[finding.DetectorType]
+	version, err := strconv.Atoi(finding.ExtraData["version"])
+	if err != nil {
+		return candidates
+	}
+	for _, d := range candidates {
+		if v, ok := d.(detectors.Versioner); ok && v.Version() == version {
+			return []detectors.Detector{d}
+		}
+	}
+	return candidates
+}
+
+// reconstruct builds data for the detector to find the secret in. The chunk
+// the secret was found in is not part of the scan output, so the secret is
+// placed next to the detector's keywords, which many detectors expect nearby.
+// Multi-part secrets are split on colons, the separator most detectors use
+// for RawV2, so that each part can be matched on its own.
+func reconstruct(d detectors.Detector, finding Finding) []byte {
+	values := []string{finding.Raw}
+	if finding.RawV2 != "" && finding.RawV2 != finding.Raw {
+		values = append(values, finding.RawV2)
+		if strings.Contains(finding.RawV2, ":") {
+			values = append(values, strings.Split(finding.RawV2, ":")...)
+		}
+	}
+
+	var b strings.Builder
+	for _, keyword := range d.Keywords() {
+		for _, value := range values {
+			fmt.Fprintf(&b, "%s = %s\n", keyword, value)
+		}
+	}
+	return []byte(b.String())
+}
+
+// sameSecret reports whether the detector result is the finding's secret.
+func sameSecret(result detectors.Result, finding Finding) bool {
+	if result.DetectorType != finding.DetectorType || string(result.Raw) != finding.Raw {
+		return false
+	}
+	return finding.RawV2 == "" || string(result.RawV2) == finding.RawV2
+}
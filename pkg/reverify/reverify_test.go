@@ -0,0 +1,128 @@
+package reverify
+
+import (
+	"bytes"
+	aCtx "context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+var fakePat = regexp.MustCompile(`fake(?:.|\n){0,10}?\b(tok_[a-z0-9]+)\b`)
+
+// fakeDetector finds tok_ secrets near its keyword. Secrets containing "live"
+// verify, and secrets containing "flaky" fail to verify.
+type fakeDetector struct {
+	version int
+	calls   *atomic.Int32
+}
+
+func (d fakeDetector) FromData(_ aCtx.Context, verify bool, data []byte) ([]detectors.Result, error) {
+	d.calls.Add(1)
+	var results []detectors.Result
+	for _, match := range fakePat.FindAllSubmatch(data, -1) {
+		result := detectors.Result{
+			DetectorType: detectorspb.DetectorType_Github,
+			Raw:          match[1],
+			AnalysisInfo: map[string]string{"key": string(match[1])},
+		}
+		if verify {
+			switch {
+			case bytes.Contains(match[1], []byte("live")):
+				result.Verified = true
+			case bytes.Contains(match[1], []byte("flaky")):
+				result.SetVerificationError(errors.New("connection reset"))
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (fakeDetector) Keywords() []string             { return []string{"fake"} }
+func (fakeDetector) Type() detectorspb.DetectorType { return detectorspb.DetectorType_Github }
+func (fakeDetector) Description() string            { return "" }
+func (d fakeDetector) Version() int                 { return d.version }
+
+func TestReverifier_Run(t *testing.T) {
+	var v1Calls, v2Calls atomic.Int32
+	r := New([]detectors.Detector{fakeDetector{1, &v1Calls}, fakeDetector{2, &v2Calls}}, 2)
+
+	input := strings.Join([]string{
+		`{"DetectorType": 8, "DetectorName": "Github", "Verified": true, "Raw": "tok_live1", "Redacted": "tok_", "SourceMetadata": {"Data": {"Filesystem": {"file": "a.txt"}}}, "ExtraData": {"version": "2"}}`,
+		`{"DetectorType": 8, "DetectorName": "Github", "Verified": true, "Raw": "tok_live1", "ExtraData": {"version": "2"}}`,
+		``,
+		`{"DetectorType": 8, "DetectorName": "Github", "Verified": true, "Raw": "tok_old1"}`,
+		`{"DetectorType": 8, "DetectorName": "Github", "Raw": "tok_flaky1", "ExtraData": {"version": "2"}}`,
+		`{"DetectorType": 8, "DetectorName": "Github", "Raw": "not-a-token", "ExtraData": {"version": "2"}}`,
+		`{"DetectorType": 2, "DetectorName": "AWS", "Raw": "AKIA"}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	summary, err := r.Run(context.Background(), strings.NewReader(input), &out)
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Live: 2, Rotated: 1, Unknown: 3}, summary)
+
+	reports := make(map[string][]Report)
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var report Report
+		require.NoError(t, dec.Decode(&report))
+		assert.NotContains(t, report.Redacted, "live1", "reports must not contain the secret")
+		key := report.DetectorName + "/" + string(report.Status)
+		reports[key] = append(reports[key], report)
+	}
+
+	assert.Len(t, reports["Github/live"], 2)
+	if assert.Len(t, reports["Github/rotated"], 1) {
+		assert.True(t, reports["Github/rotated"][0].PreviouslyVerified)
+	}
+	if assert.Len(t, reports["Github/unknown"], 2) {
+		errs := []string{reports["Github/unknown"][0].VerificationError, reports["Github/unknown"][1].VerificationError}
+		assert.ElementsMatch(t, []string{"connection reset", "the detector did not find the secret again"}, errs)
+	}
+	if assert.Len(t, reports["AWS/unknown"], 1) {
+		assert.Equal(t, "no detector for AWS findings", reports["AWS/unknown"][0].VerificationError)
+	}
+
+	// The live secret was only verified once, with the detector version that
+	// found it. The finding without a version was found by the first version.
+	assert.EqualValues(t, 3, v2Calls.Load())
+	assert.EqualValues(t, 1, v1Calls.Load())
+}
+
+func TestReverifier_Run_InvalidInput(t *testing.T) {
+	r := New(nil, 1)
+	_, err := r.Run(context.Background(), strings.NewReader("{}\nnot json"), &bytes.Buffer{})
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestReverifier_Rediscover(t *testing.T) {
+	var calls atomic.Int32
+	r := New([]detectors.Detector{fakeDetector{1, &calls}}, 1)
+
+	result, ok := r.Rediscover(context.Background(), Finding{DetectorType: detectorspb.DetectorType_Github, Raw: "tok_live1"})
+	require.True(t, ok)
+	assert.False(t, result.Verified, "rediscovering must not verify the secret")
+	assert.Equal(t, map[string]string{"key": "tok_live1"}, result.AnalysisInfo)
+
+	_, ok = r.Rediscover(context.Background(), Finding{DetectorType: detectorspb.DetectorType_Github, Raw: "not-a-token"})
+	assert.False(t, ok)
+	_, ok = r.Rediscover(context.Background(), Finding{DetectorType: detectorspb.DetectorType_AWS, Raw: "AKIA"})
+	assert.False(t, ok)
+}
+
+func TestReconstruct(t *testing.T) {
+	data := reconstruct(fakeDetector{}, Finding{Raw: "id", RawV2: "id:secret"})
+	assert.Equal(t, "fake = id\nfake = id:secret\nfake = id\nfake = secret\n", string(data))
+}
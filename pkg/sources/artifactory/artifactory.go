@@ -0,0 +1,317 @@
+package artifactory
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gobwas/glob"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_JFROG_ARTIFACTORY
+
+const unitKindRepository = "repository"
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	repos       []string
+	includePath func(string) bool
+	ignorePath  func(string) bool
+
+	client *client
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Artifactory source.
+func (s *Source) Init(ctx context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.Artifactory
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	endpoint := strings.TrimRight(conn.GetEndpoint(), "/")
+	if endpoint == "" {
+		return fmt.Errorf("an endpoint is required for Artifactory")
+	}
+	if _, err := url.ParseRequestURI(endpoint); err != nil {
+		return fmt.Errorf("invalid Artifactory endpoint %q: %w", endpoint, err)
+	}
+	s.repos = conn.GetRepositories()
+
+	var authorize func(req *http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Artifactory_BasicAuth:
+		user, password := cred.BasicAuth.GetUsername(), cred.BasicAuth.GetPassword()
+		if password == "" {
+			return fmt.Errorf("invalid configuration given for source %q (%s): empty password", name, s.Type().String())
+		}
+		log.RedactGlobally(password)
+		authorize = func(req *http.Request) { req.SetBasicAuth(user, password) }
+	case *sourcespb.Artifactory_AccessToken:
+		token := cred.AccessToken
+		if token == "" {
+			return fmt.Errorf("invalid configuration given for source %q (%s): empty access token", name, s.Type().String())
+		}
+		log.RedactGlobally(token)
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	case *sourcespb.Artifactory_Unauthenticated:
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	onCompileErr := func(err error, pattern string) {
+		ctx.Logger().Error(err, "could not compile path glob", "glob", pattern)
+	}
+	s.includePath = buildMatcher(conn.GetIncludePaths(), onCompileErr)
+	s.ignorePath = buildMatcher(conn.GetIgnorePaths(), onCompileErr)
+	if len(conn.GetIncludePaths()) == 0 {
+		s.includePath = func(string) bool { return true }
+	}
+
+	s.client = &client{
+		httpClient:     common.RetryableHTTPClientTimeout(60),
+		downloadClient: common.RetryableHTTPClient(),
+		baseURL:        endpoint,
+		authorize:      authorize,
+	}
+
+	return nil
+}
+
+// buildMatcher returns a function reporting whether a path matches any of
+// the globs.
+func buildMatcher(patterns []string, onCompileErr func(err error, pattern string)) func(path string) bool {
+	globs := make([]glob.Glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			onCompileErr(err, pattern)
+			continue
+		}
+		globs = append(globs, g)
+	}
+
+	return func(path string) bool {
+		for _, g := range globs {
+			if g.Match(path) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Validate checks that the configured credentials are accepted and that any
+// explicitly configured repositories are visible.
+func (s *Source) Validate(ctx context.Context) []error {
+	if len(s.repos) == 0 {
+		if _, err := s.client.listRepositories(ctx); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+
+	var errs []error
+	for _, repo := range s.repos {
+		if err := s.client.getRepository(ctx, repo); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var units []sources.SourceUnit
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	var scanned int64
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, unit := range units {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			repo, _ := unit.SourceUnitID()
+			s.SetProgressComplete(i, len(units), fmt.Sprintf("Repository: %s", repo), "")
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+				return nil
+			}
+			ctx.Logger().V(2).Info("completed scan", "repository", repo, "num", atomic.AddInt64(&scanned, 1), "total", len(units))
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(units), len(units), "Completed Artifactory scan", "")
+
+	return nil
+}
+
+// Enumerate reports all Artifactory repositories to be scanned to the
+// reporter. If none are configured, it lists every local and federated
+// repository. Remote repositories only cache third-party artifacts and
+// virtual repositories aggregate other repositories, so both are skipped.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	repos := s.repos
+	if len(repos) == 0 {
+		all, err := s.client.listRepositories(ctx)
+		if err != nil {
+			return err
+		}
+		for _, repo := range all {
+			switch strings.ToUpper(repo.Type) {
+			case "LOCAL", "FEDERATED":
+				repos = append(repos, repo.Key)
+			default:
+				ctx.Logger().V(3).Info("skipping repository", "repository", repo.Key, "type", repo.Type)
+			}
+		}
+		ctx.Logger().V(2).Info("enumerated repositories", "count", len(repos))
+	}
+
+	artifactoryReposEnumerated.WithLabelValues(s.name).Set(0)
+	for _, repo := range repos {
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{Kind: unitKindRepository, ID: repo}); err != nil {
+			return err
+		}
+		artifactoryReposEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// ChunkUnit downloads every artifact in the repository and passes it through
+// the file handlers, which unpack archives, packages and images.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	repo, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "repository", repo)
+
+	files, err := s.client.listFiles(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if common.IsDone(ctx) {
+			return ctx.Err()
+		}
+		path := strings.TrimPrefix(file.URI, "/")
+		if !s.includePath(path) || s.ignorePath(path) {
+			ctx.Logger().V(5).Info("skipping artifact", "path", path, "reason", "filtered by path")
+			continue
+		}
+		if err := s.scanArtifact(ctx, repo, file, reporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanArtifact scans a single artifact. Errors scanning the artifact are
+// reported to the reporter; the returned error is only non-nil if the
+// reporter failed.
+func (s *Source) scanArtifact(ctx context.Context, repo string, file fileListEntry, reporter sources.ChunkReporter) error {
+	ctx = context.WithValue(ctx, "artifact", file.URI)
+
+	// The file info is only used for metadata, so fall back to the listing.
+	info, err := s.client.getFileInfo(ctx, repo, file.URI)
+	if err != nil {
+		ctx.Logger().V(2).Info("could not get artifact info", "error", err)
+		info = fileInfo{LastModified: file.LastModified}
+	}
+	user := info.ModifiedBy
+	if user == "" {
+		user = info.CreatedBy
+	}
+
+	rc, err := s.client.download(ctx, repo, file.URI)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("error downloading artifact %s%s: %w", repo, file.URI, err))
+	}
+	defer rc.Close()
+
+	chunkSkel := &sources.Chunk{
+		SourceType: s.Type(),
+		SourceName: s.name,
+		SourceID:   s.sourceID,
+		JobID:      s.jobID,
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Artifactory{
+				Artifactory: &source_metadatapb.Artifactory{
+					Repo:      sanitizer.UTF8(repo),
+					Path:      sanitizer.UTF8(strings.TrimPrefix(file.URI, "/")),
+					Link:      s.client.artifactURL(repo, file.URI),
+					Timestamp: sanitizer.UTF8(info.LastModified),
+					Username:  sanitizer.UTF8(user),
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+	if err := handlers.HandleFile(ctx, rc, chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("error handling artifact %s%s: %w", repo, file.URI, err))
+	}
+	artifactoryArtifactsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
@@ -0,0 +1,225 @@
+package artifactory
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func newJar(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("application.properties")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("db.password=hunter2\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	jar := newJar(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/artifactory/api/repositories":
+			sourcestest.WriteJSON(t, w, []any{
+				map[string]any{"key": "libs-release", "type": "LOCAL", "packageType": "Maven"},
+				map[string]any{"key": "docker-prod", "type": "FEDERATED", "packageType": "Docker"},
+				map[string]any{"key": "npm-remote", "type": "REMOTE", "packageType": "Npm"},
+				map[string]any{"key": "all", "type": "VIRTUAL", "packageType": "Generic"},
+			})
+		case "/artifactory/api/repositories/libs-release":
+			sourcestest.WriteJSON(t, w, map[string]any{"key": "libs-release"})
+		case "/artifactory/api/storage/libs-release":
+			sourcestest.WriteJSON(t, w, map[string]any{"files": []any{
+				map[string]any{"uri": "/com/acme/app/1.0/app-1.0.jar", "size": len(jar)},
+				map[string]any{"uri": "/com/acme/app/1.0", "folder": true},
+				map[string]any{"uri": "/notes/readme.txt", "size": 5, "lastModified": "2024-01-02T00:00:00.000Z"},
+			}})
+		case "/artifactory/api/storage/libs-release/com/acme/app/1.0/app-1.0.jar":
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"repo":         "libs-release",
+				"path":         "/com/acme/app/1.0/app-1.0.jar",
+				"lastModified": "2024-01-01T00:00:00.000Z",
+				"createdBy":    "ci-bot",
+			})
+		case "/artifactory/libs-release/com/acme/app/1.0/app-1.0.jar":
+			_, _ = w.Write(jar)
+		case "/artifactory/libs-release/notes/readme.txt":
+			_, _ = w.Write([]byte("hello"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func initSource(t *testing.T, srv *httptest.Server, conn *sourcespb.Artifactory) *Source {
+	t.Helper()
+	conn.Endpoint = srv.URL + "/artifactory/"
+	if conn.Credential == nil {
+		conn.Credential = &sourcespb.Artifactory_AccessToken{AccessToken: "token"}
+	}
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	return s
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	srv := newServer(t)
+
+	tests := map[string]struct {
+		conn *sourcespb.Artifactory
+		want []string
+	}{
+		"local and federated repositories": {
+			conn: &sourcespb.Artifactory{},
+			want: []string{"libs-release", "docker-prod"},
+		},
+		"configured repositories": {
+			conn: &sourcespb.Artifactory{Repositories: []string{"libs-release"}},
+			want: []string{"libs-release"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := initSource(t, srv, tt.conn)
+			assert.Empty(t, s.Validate(context.Background()))
+
+			reporter := sourcestest.TestReporter{}
+			require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+			var got []string
+			for _, unit := range reporter.Units {
+				id, _ := unit.SourceUnitID()
+				got = append(got, id)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSource_ChunkUnit(t *testing.T) {
+	srv := newServer(t)
+	unit := sources.CommonSourceUnit{Kind: unitKindRepository, ID: "libs-release"}
+
+	t.Run("all artifacts", func(t *testing.T) {
+		s := initSource(t, srv, &sourcespb.Artifactory{})
+
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+		require.Empty(t, reporter.ChunkErrs)
+		require.Len(t, reporter.Chunks, 2)
+
+		jar := reporter.Chunks[0]
+		assert.Contains(t, string(jar.Data), "db.password=hunter2")
+		meta := jar.SourceMetadata.GetArtifactory()
+		require.NotNil(t, meta)
+		assert.Equal(t, "libs-release", meta.Repo)
+		assert.Equal(t, "com/acme/app/1.0/app-1.0.jar", meta.Path)
+		assert.Equal(t, srv.URL+"/artifactory/libs-release/com/acme/app/1.0/app-1.0.jar", meta.Link)
+		assert.Equal(t, "ci-bot", meta.Username)
+		assert.Equal(t, "2024-01-01T00:00:00.000Z", meta.Timestamp)
+
+		// Missing file info falls back to the listing.
+		readme := reporter.Chunks[1].SourceMetadata.GetArtifactory()
+		assert.Equal(t, "notes/readme.txt", readme.Path)
+		assert.Equal(t, "2024-01-02T00:00:00.000Z", readme.Timestamp)
+	})
+
+	t.Run("path filters", func(t *testing.T) {
+		s := initSource(t, srv, &sourcespb.Artifactory{
+			IncludePaths: []string{"com/**", "notes/*"},
+			IgnorePaths:  []string{"*.txt"},
+		})
+
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+		require.Len(t, reporter.Chunks, 1)
+		assert.Equal(t, "com/acme/app/1.0/app-1.0.jar", reporter.Chunks[0].SourceMetadata.GetArtifactory().Path)
+	})
+}
+
+func TestSource_Validate_BadCredentials(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, srv, &sourcespb.Artifactory{
+		Credential: &sourcespb.Artifactory_AccessToken{AccessToken: "wrong"},
+	})
+	assert.NotEmpty(t, s.Validate(context.Background()))
+}
+
+func TestSource_ChunkUnit_EscapedPathsAndForbiddenArtifacts(t *testing.T) {
+	var downloads []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "reader" || pass != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/artifactory/api/storage/generic-local":
+			sourcestest.WriteJSON(t, w, map[string]any{"files": []any{
+				map[string]any{"uri": "/build 1/notes #1.txt"},
+				map[string]any{"uri": "/restricted/secret.txt"},
+			}})
+		case "/artifactory/generic-local/build%201/notes%20%231.txt":
+			downloads = append(downloads, r.URL.Path)
+			_, _ = w.Write([]byte("notes"))
+		case "/artifactory/generic-local/restricted/secret.txt":
+			downloads = append(downloads, r.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			// File info is optional; the listing is used instead.
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	s := initSource(t, srv, &sourcespb.Artifactory{
+		Credential: &sourcespb.Artifactory_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{Username: "reader", Password: "password"},
+		},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindRepository, ID: "generic-local"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+
+	assert.Equal(t, []string{"/artifactory/generic-local/build 1/notes #1.txt", "/artifactory/generic-local/restricted/secret.txt"}, downloads)
+	require.Len(t, reporter.Chunks, 1)
+	assert.Equal(t, "notes", string(reporter.Chunks[0].Data))
+	assert.Equal(t, srv.URL+"/artifactory/generic-local/build%201/notes%20%231.txt", reporter.Chunks[0].SourceMetadata.GetArtifactory().Link)
+	require.Len(t, reporter.ChunkErrs, 1)
+	assert.ErrorContains(t, reporter.ChunkErrs[0], "insufficient permissions")
+}
+
+func TestSource_ChunkUnit_ListingForbidden(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	s := initSource(t, srv, &sourcespb.Artifactory{})
+	unit := sources.CommonSourceUnit{Kind: unitKindRepository, ID: "libs-release"}
+	err := s.ChunkUnit(context.Background(), unit, &sourcestest.TestReporter{})
+	assert.ErrorContains(t, err, `error listing files in "libs-release"`)
+	assert.ErrorContains(t, err, "status 403")
+}
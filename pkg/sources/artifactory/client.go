@@ -0,0 +1,168 @@
+package artifactory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// client is a minimal client for the Artifactory REST API.
+// https://jfrog.com/help/r/jfrog-rest-apis/artifactory-rest-apis
+type client struct {
+	// httpClient is used for API requests and downloadClient for artifacts,
+	// which can be arbitrarily large and so are not subject to a timeout.
+	httpClient     *http.Client
+	downloadClient *http.Client
+	// baseURL is the Artifactory base URL, e.g. https://example.jfrog.io/artifactory.
+	baseURL   string
+	authorize func(req *http.Request)
+}
+
+type repository struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	PackageType string `json:"packageType"`
+}
+
+type fileListEntry struct {
+	URI          string `json:"uri"`
+	Size         int64  `json:"size"`
+	LastModified string `json:"lastModified"`
+	Folder       bool   `json:"folder"`
+}
+
+type fileInfo struct {
+	Repo         string `json:"repo"`
+	Path         string `json:"path"`
+	Created      string `json:"created"`
+	CreatedBy    string `json:"createdBy"`
+	LastModified string `json:"lastModified"`
+	ModifiedBy   string `json:"modifiedBy"`
+	DownloadURI  string `json:"downloadUri"`
+	Size         string `json:"size"`
+}
+
+// get performs a GET request and returns the response if it was successful.
+// The caller is responsible for closing the body.
+func (c *client) get(ctx context.Context, httpClient *http.Client, reqURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Artifactory request: %w", err)
+	}
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Artifactory: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("invalid Artifactory credentials (status %d)", resp.StatusCode)
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("insufficient permissions for %q (status %d)", req.URL.Path, resp.StatusCode)
+	default:
+		return nil, fmt.Errorf("unexpected status code %d for %q", resp.StatusCode, req.URL.Path)
+	}
+}
+
+// getJSON performs a GET request against the API and decodes the JSON
+// response into target.
+func (c *client) getJSON(ctx context.Context, path string, target any) error {
+	resp, err := c.get(ctx, c.httpClient, c.baseURL+path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Artifactory API response: %w", err)
+	}
+	return nil
+}
+
+// listRepositories returns every repository visible to the client.
+func (c *client) listRepositories(ctx context.Context) ([]repository, error) {
+	var repos []repository
+	if err := c.getJSON(ctx, "/api/repositories", &repos); err != nil {
+		return nil, fmt.Errorf("error listing repositories: %w", err)
+	}
+	return repos, nil
+}
+
+// getRepository checks that the repository exists and is visible.
+func (c *client) getRepository(ctx context.Context, key string) error {
+	var repo json.RawMessage
+	if err := c.getJSON(ctx, "/api/repositories/"+url.PathEscape(key), &repo); err != nil {
+		return fmt.Errorf("error getting repository %q: %w", key, err)
+	}
+	return nil
+}
+
+// listFiles returns every file in the repository using the File List API.
+// The returned URIs are relative to the repository root and start with "/".
+func (c *client) listFiles(ctx context.Context, repo string) ([]fileListEntry, error) {
+	var list struct {
+		Files []fileListEntry `json:"files"`
+	}
+	path := "/api/storage/" + url.PathEscape(repo) + "?list&deep=1&listFolders=0"
+	if err := c.getJSON(ctx, path, &list); err != nil {
+		return nil, fmt.Errorf("error listing files in %q: %w", repo, err)
+	}
+
+	files := make([]fileListEntry, 0, len(list.Files))
+	for _, f := range list.Files {
+		if !f.Folder {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// getFileInfo returns the storage information of an artifact.
+func (c *client) getFileInfo(ctx context.Context, repo, path string) (fileInfo, error) {
+	var info fileInfo
+	if err := c.getJSON(ctx, "/api/storage/"+url.PathEscape(repo)+escapePath(path), &info); err != nil {
+		return info, fmt.Errorf("error getting file info for %s%s: %w", repo, path, err)
+	}
+	return info, nil
+}
+
+// download returns the contents of an artifact. The caller is responsible for
+// closing the returned reader.
+func (c *client) download(ctx context.Context, repo, path string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, c.downloadClient, c.artifactURL(repo, path))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// artifactURL returns the download URL of an artifact.
+func (c *client) artifactURL(repo, path string) string {
+	return c.baseURL + "/" + url.PathEscape(repo) + escapePath(path)
+}
+
+// escapePath escapes each segment of a slash separated path.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,535 @@
+package azure_repos
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/gobwas/glob"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/cache"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/cache/simple"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_AZURE_REPOS
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	endpoint     string
+	cloud        bool
+	authMethod   string
+	token        string
+	bearer       bool
+	repos        []string
+	orgs         []string
+	projects     []string
+	includeForks bool
+
+	filteredRepoCache *filteredRepoCache
+	projectFilter     *globFilter
+	repoInfoCache     repoInfoCache
+
+	client      *client
+	git         *git.Git
+	scanOptions *git.ScanOptions
+
+	jobPool *errgroup.Group
+	sources.Progress
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// globFilter matches names against include and exclude globs.
+type globFilter struct {
+	include, exclude []glob.Glob
+}
+
+func newGlobFilter(ctx context.Context, include, exclude []string) *globFilter {
+	compile := func(patterns []string, kind string) []glob.Glob {
+		globs := make([]glob.Glob, 0, len(patterns))
+		for _, pattern := range patterns {
+			g, err := glob.Compile(pattern)
+			if err != nil {
+				ctx.Logger().V(1).Info("invalid "+kind+" glob", kind+"_value", pattern, "err", err)
+				continue
+			}
+			globs = append(globs, g)
+		}
+		return globs
+	}
+	return &globFilter{include: compile(include, "include"), exclude: compile(exclude, "exclude")}
+}
+
+// Pass reports whether the name is included and not excluded.
+func (f *globFilter) Pass(name string) bool {
+	for _, g := range f.exclude {
+		if g.Match(name) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, g := range f.include {
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredRepoCache is a wrapper around cache.Cache that filters out repos
+// based on include and exclude globs matched against "org/project/repo".
+type filteredRepoCache struct {
+	cache.Cache[string]
+	filter *globFilter
+}
+
+// Set overrides the cache.Cache Set method to filter out repos based on
+// include and exclude globs.
+func (c *filteredRepoCache) Set(key, val string) {
+	if !c.filter.Pass(key) {
+		return
+	}
+	c.Cache.Set(key, val)
+}
+
+type repoInfo struct {
+	org        string
+	project    string
+	name       string
+	visibility source_metadatapb.Visibility
+}
+
+type repoInfoCache struct {
+	mu    sync.RWMutex
+	cache map[string]repoInfo
+}
+
+func (r *repoInfoCache) put(repoURL string, info repoInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache[repoURL] = info
+}
+
+func (r *repoInfoCache) get(repoURL string) (repoInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.cache[repoURL]
+	return info, ok
+}
+
+// Init returns an initialized Azure Repos source.
+func (s *Source) Init(ctx context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	if err := git.CmdCheck(); err != nil {
+		return err
+	}
+
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.AzureRepos
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.endpoint = strings.TrimRight(conn.GetEndpoint(), "/")
+	if s.endpoint == "" {
+		s.endpoint = cloudEndpoint
+	}
+	if _, err := url.ParseRequestURI(s.endpoint); err != nil {
+		return fmt.Errorf("invalid Azure DevOps endpoint %q: %w", conn.GetEndpoint(), err)
+	}
+	s.cloud = s.endpoint == cloudEndpoint
+	s.repos = conn.GetRepositories()
+	s.orgs = conn.GetOrganizations()
+	s.projects = conn.GetProjects()
+	s.includeForks = conn.GetIncludeForks()
+
+	s.filteredRepoCache = &filteredRepoCache{
+		Cache:  simple.NewCache[string](),
+		filter: newGlobFilter(ctx, conn.GetIncludeRepos(), conn.GetIgnoreRepos()),
+	}
+	s.projectFilter = newGlobFilter(ctx, conn.GetIncludeProjects(), conn.GetIgnoreProjects())
+	s.repoInfoCache = repoInfoCache{cache: make(map[string]repoInfo)}
+
+	var authorize func(req *http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.AzureRepos_Token:
+		// Personal access tokens are sent as the password with an empty username.
+		s.authMethod = "TOKEN"
+		s.token = cred.Token
+		authorize = func(req *http.Request) { req.SetBasicAuth("", s.token) }
+	case *sourcespb.AzureRepos_Oauth:
+		s.authMethod = "OAUTH"
+		s.token = cred.Oauth.GetAccessToken()
+		s.bearer = true
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+s.token) }
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	if s.token == "" {
+		return fmt.Errorf("invalid configuration given for source %q (%s): empty credential", name, s.Type().String())
+	}
+	log.RedactGlobally(s.token)
+
+	s.client = &client{
+		httpClient: common.RetryableHTTPClientTimeout(60),
+		baseURL:    s.endpoint,
+		profileURL: profileEndpoint,
+		authorize:  authorize,
+	}
+
+	cfg := &git.Config{
+		SourceName:   s.name,
+		JobID:        s.jobID,
+		SourceID:     s.sourceID,
+		SourceType:   s.Type(),
+		Verify:       s.verify,
+		SkipBinaries: conn.GetSkipBinaries(),
+		SkipArchives: conn.GetSkipArchives(),
+		Concurrency:  concurrency,
+		SourceMetadataFunc: func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
+			info := s.getRepoInfo(repository)
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_AzureRepos{
+					AzureRepos: &source_metadatapb.AzureRepos{
+						Link:         giturl.GenerateLink(repository, commit, file, line),
+						Repository:   sanitizer.UTF8(info.name),
+						Commit:       sanitizer.UTF8(commit),
+						Email:        sanitizer.UTF8(email),
+						File:         sanitizer.UTF8(file),
+						Timestamp:    sanitizer.UTF8(timestamp),
+						Line:         line,
+						Visibility:   info.visibility,
+						Project:      sanitizer.UTF8(info.project),
+						Organization: sanitizer.UTF8(info.org),
+					},
+				},
+			}
+		},
+	}
+	s.git = git.NewGit(cfg)
+
+	return nil
+}
+
+// getRepoInfo returns the cached information for a repository, falling back
+// to parsing its URL for repositories that weren't enumerated.
+func (s *Source) getRepoInfo(repoURL string) repoInfo {
+	if info, ok := s.repoInfoCache.get(repoURL); ok {
+		return info
+	}
+	org, proj, name, _ := repoCoordinates(repoURL)
+	return repoInfo{org: org, project: proj, name: name, visibility: source_metadatapb.Visibility_unknown}
+}
+
+// WithScanOptions sets the git scan options used for every repository.
+func (s *Source) WithScanOptions(scanOptions *git.ScanOptions) {
+	s.scanOptions = scanOptions
+}
+
+// Validate checks that the configured credentials can list projects in each
+// configured organization and that configured repositories are reachable.
+func (s *Source) Validate(ctx context.Context) []error {
+	var errs []error
+	for _, org := range s.orgs {
+		if _, err := s.client.listProjects(ctx, org); err != nil {
+			errs = append(errs, fmt.Errorf("azure repos authentication failed using method %v: %w", s.authMethod, err))
+		}
+	}
+	repos, repoErrs := normalizeRepos(s.repos)
+	errs = append(errs, repoErrs...)
+	if s.bearer {
+		return errs
+	}
+	for _, r := range repos {
+		if err := git.PingRepoUsingToken(ctx, s.token, r, ""); err != nil {
+			errs = append(errs, fmt.Errorf("could not reach git repository %q: %w", r, err))
+		}
+	}
+	return errs
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var units []sources.SourceUnit
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	var scanned int64
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, unit := range units {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			repoURL, _ := unit.SourceUnitID()
+			s.SetProgressComplete(i, len(units), fmt.Sprintf("Repo: %s", repoURL), "")
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+				return nil
+			}
+			ctx.Logger().V(2).Info("completed scan", "repo", repoURL, "num", atomic.AddInt64(&scanned, 1), "total", len(units))
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(units), len(units), "Completed Azure Repos scan", "")
+
+	return nil
+}
+
+// Enumerate reports every repository to be scanned. Explicitly configured
+// repositories are reported as-is. Otherwise, repositories are listed from
+// the configured projects and organizations (or, for Azure DevOps Services,
+// every organization the credential belongs to) and filtered by the include
+// and ignore globs.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	repos, errs := normalizeRepos(s.repos)
+	for _, repoErr := range errs {
+		if err := reporter.UnitErr(ctx, repoErr); err != nil {
+			return err
+		}
+	}
+	if len(errs) > 0 && len(repos) == 0 {
+		return fmt.Errorf("all configured repos had validation issues")
+	}
+
+	azureReposEnumerated.WithLabelValues(s.name).Set(0)
+	if len(repos) > 0 {
+		for _, repo := range repos {
+			if err := reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: repo}); err != nil {
+				return err
+			}
+			azureReposEnumerated.WithLabelValues(s.name).Inc()
+		}
+		return nil
+	}
+
+	if err := s.enumerateRepos(ctx, reporter); err != nil {
+		return err
+	}
+
+	keys := s.filteredRepoCache.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		repoURL, _ := s.filteredRepoCache.Get(key)
+		if err := reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: repoURL}); err != nil {
+			return err
+		}
+		azureReposEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// enumerateRepos populates the filteredRepoCache and repoInfoCache.
+func (s *Source) enumerateRepos(ctx context.Context, reporter sources.UnitReporter) error {
+	type orgProject struct{ org, project string }
+	var targets []orgProject
+
+	for _, p := range s.projects {
+		org, proj, ok := strings.Cut(strings.Trim(p, "/"), "/")
+		if !ok {
+			if err := reporter.UnitErr(ctx, fmt.Errorf("project %q must be in the form org/project", p)); err != nil {
+				return err
+			}
+			continue
+		}
+		targets = append(targets, orgProject{org, proj})
+	}
+
+	orgs := s.orgs
+	if len(orgs) == 0 && len(s.projects) == 0 {
+		if !s.cloud {
+			return fmt.Errorf("organizations (project collections) must be configured for Azure DevOps Server")
+		}
+		var err error
+		if orgs, err = s.client.listOrganizations(ctx); err != nil {
+			return err
+		}
+		ctx.Logger().V(2).Info("enumerated organizations", "count", len(orgs))
+	}
+
+	for _, org := range orgs {
+		projects, err := s.client.listProjects(ctx, org)
+		if err != nil {
+			if err := reporter.UnitErr(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, p := range projects {
+			targets = append(targets, orgProject{org, p.Name})
+		}
+	}
+
+	for _, t := range targets {
+		logger := ctx.Logger().WithValues("org", t.org, "project", t.project)
+		if !s.projectFilter.Pass(t.org + "/" + t.project) {
+			logger.V(3).Info("skipping project", "reason", "ignored in config")
+			continue
+		}
+		repos, err := s.client.listRepositories(ctx, t.org, t.project)
+		if err != nil {
+			if err := reporter.UnitErr(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, r := range repos {
+			if r.IsDisabled {
+				logger.V(3).Info("skipping repository", "repo", r.Name, "reason", "disabled")
+				continue
+			}
+			if r.IsFork && !s.includeForks {
+				logger.V(3).Info("skipping repository", "repo", r.Name, "reason", "fork")
+				continue
+			}
+			repoURL, err := normalizeRepo(r.RemoteURL)
+			if err != nil {
+				if err := reporter.UnitErr(ctx, err); err != nil {
+					return err
+				}
+				continue
+			}
+			s.filteredRepoCache.Set(t.org+"/"+t.project+"/"+r.Name, repoURL)
+			s.repoInfoCache.put(repoURL, repoInfo{
+				org:        t.org,
+				project:    t.project,
+				name:       r.Name,
+				visibility: visibility(r.Project.Visibility),
+			})
+		}
+	}
+	return nil
+}
+
+func visibility(v string) source_metadatapb.Visibility {
+	switch strings.ToLower(v) {
+	case "public":
+		return source_metadatapb.Visibility_public
+	case "private":
+		return source_metadatapb.Visibility_private
+	default:
+		return source_metadatapb.Visibility_unknown
+	}
+}
+
+// ChunkUnit clones and scans the given Azure Repos repository unit.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	repoURL, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "repo", repoURL)
+
+	var (
+		path string
+		repo *gogit.Repository
+		err  error
+	)
+	if s.bearer {
+		// OAuth access tokens must be sent as a bearer token rather than
+		// basic auth.
+		path, repo, err = git.CloneRepoUsingBearerToken(ctx, s.token, repoURL, "")
+	} else {
+		path, repo, err = git.CloneRepoUsingToken(ctx, s.token, repoURL, "", "", false)
+	}
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(path)
+
+	if err := s.git.ScanRepo(ctx, repo, path, s.scanOptions, reporter); err != nil {
+		return err
+	}
+	azureReposScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// UnmarshalSourceUnit implements the SourceUnitUnmarshaller interface.
+func (s *Source) UnmarshalSourceUnit(data []byte) (sources.SourceUnit, error) {
+	return git.UnmarshalUnit(data)
+}
+
+func normalizeRepos(repos []string) ([]string, []error) {
+	validRepos := make([]string, 0, len(repos))
+	var errs []error
+	for _, r := range repos {
+		repo, err := normalizeRepo(r)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		validRepos = append(validRepos, repo)
+	}
+	return validRepos, errs
+}
+
+// normalizeRepo validates an Azure Repos URL and strips any user info, which
+// Azure DevOps includes in remote URLs (https://org@dev.azure.com/...).
+func normalizeRepo(repo string) (string, error) {
+	u, err := url.Parse(repo)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("unable to normalize azure repos url %q: must be an http or https URL", repo)
+	}
+	if _, _, _, err := repoCoordinates(repo); err != nil {
+		return "", fmt.Errorf("unable to normalize azure repos url %q: %w", repo, err)
+	}
+	u.User = nil
+	return u.String(), nil
+}
@@ -0,0 +1,233 @@
+package azure_repos
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	repo := func(org, project, name string, fork bool) map[string]any {
+		return map[string]any{
+			"name":      name,
+			"remoteUrl": fmt.Sprintf("https://%s@dev.azure.com/%s/%s/_git/%s", org, org, project, name),
+			"isFork":    fork,
+			"project":   map[string]any{"name": project, "visibility": "private"},
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pass, ok := r.BasicAuth(); !ok || pass != "pat" {
+			// Azure DevOps redirects to a sign-in page.
+			w.Header().Set("Content-Type", "text/html")
+			return
+		}
+		switch r.URL.Path {
+		case "/_apis/profile/profiles/me":
+			sourcestest.WriteJSON(t, w, map[string]any{"id": "me"})
+		case "/_apis/accounts":
+			sourcestest.WriteJSON(t, w, map[string]any{"value": []any{map[string]any{"accountName": "contoso"}}})
+		case "/contoso/_apis/projects":
+			if r.URL.Query().Get("continuationToken") == "" {
+				w.Header().Set("x-ms-continuationtoken", "next")
+				sourcestest.WriteJSON(t, w, map[string]any{"value": []any{map[string]any{"name": "web"}}})
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{"value": []any{map[string]any{"name": "legacy"}}})
+		case "/contoso/web/_apis/git/repositories":
+			sourcestest.WriteJSON(t, w, map[string]any{"value": []any{
+				repo("contoso", "web", "frontend", false),
+				repo("contoso", "web", "frontend-fork", true),
+				repo("contoso", "web", "infra", false),
+			}})
+		case "/contoso/legacy/_apis/git/repositories":
+			sourcestest.WriteJSON(t, w, map[string]any{"value": []any{repo("contoso", "legacy", "monolith", false)}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func initSource(t *testing.T, srv *httptest.Server, conn *sourcespb.AzureRepos) *Source {
+	t.Helper()
+	if conn.Credential == nil {
+		conn.Credential = &sourcespb.AzureRepos_Token{Token: "pat"}
+	}
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	if srv != nil {
+		s.client.baseURL = srv.URL
+		s.client.profileURL = srv.URL
+	}
+	return s
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	srv := newServer(t)
+
+	tests := map[string]struct {
+		conn *sourcespb.AzureRepos
+		want []string
+	}{
+		"discover organizations": {
+			conn: &sourcespb.AzureRepos{},
+			want: []string{
+				"https://dev.azure.com/contoso/legacy/_git/monolith",
+				"https://dev.azure.com/contoso/web/_git/frontend",
+				"https://dev.azure.com/contoso/web/_git/infra",
+			},
+		},
+		"ignore projects and repos": {
+			conn: &sourcespb.AzureRepos{
+				Organizations:  []string{"contoso"},
+				IgnoreProjects: []string{"contoso/legacy"},
+				IgnoreRepos:    []string{"*/infra"},
+			},
+			want: []string{"https://dev.azure.com/contoso/web/_git/frontend"},
+		},
+		"explicit project with forks": {
+			conn: &sourcespb.AzureRepos{
+				Projects:     []string{"contoso/web"},
+				IncludeForks: true,
+				IncludeRepos: []string{"contoso/web/frontend*"},
+			},
+			want: []string{
+				"https://dev.azure.com/contoso/web/_git/frontend",
+				"https://dev.azure.com/contoso/web/_git/frontend-fork",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := initSource(t, srv, tt.conn)
+
+			reporter := sourcestest.TestReporter{}
+			require.NoError(t, s.Enumerate(context.Background(), &reporter))
+			assert.Empty(t, reporter.UnitErrs)
+
+			var got []string
+			for _, unit := range reporter.Units {
+				id, kind := unit.SourceUnitID()
+				assert.Equal(t, git.UnitRepo, kind)
+				got = append(got, id)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSource_Enumerate_ConfiguredRepos(t *testing.T) {
+	s := initSource(t, nil, &sourcespb.AzureRepos{
+		Repositories: []string{
+			"https://org@dev.azure.com/org/project/_git/repo",
+			"http://tfs.example.com/tfs/DefaultCollection/project/_git/repo",
+			"https://github.com/org/repo",
+			"ssh://ssh.dev.azure.com/v3/org/project/repo",
+		},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Len(t, reporter.UnitErrs, 2)
+	assert.Equal(t, []sources.SourceUnit{
+		git.SourceUnit{Kind: git.UnitRepo, ID: "https://dev.azure.com/org/project/_git/repo"},
+		git.SourceUnit{Kind: git.UnitRepo, ID: "http://tfs.example.com/tfs/DefaultCollection/project/_git/repo"},
+	}, reporter.Units)
+}
+
+func TestSource_Enumerate_BadCredentials(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, srv, &sourcespb.AzureRepos{
+		Organizations: []string{"contoso"},
+		Credential:    &sourcespb.AzureRepos_Token{Token: "wrong"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Len(t, reporter.UnitErrs, 1)
+	assert.Empty(t, reporter.Units)
+	assert.NotEmpty(t, s.Validate(context.Background()))
+}
+
+func TestSource_Enumerate_Server(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer oauth-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/tfs/DefaultCollection/_apis/projects":
+			sourcestest.WriteJSON(t, w, map[string]any{"value": []any{
+				map[string]any{"name": "web"},
+				map[string]any{"name": "restricted"},
+			}})
+		case "/tfs/DefaultCollection/web/_apis/git/repositories":
+			sourcestest.WriteJSON(t, w, map[string]any{"value": []any{
+				map[string]any{"name": "app", "remoteUrl": "http://" + r.Host + "/tfs/DefaultCollection/web/_git/app"},
+				map[string]any{"name": "archived", "remoteUrl": "http://" + r.Host + "/tfs/DefaultCollection/web/_git/archived", "isDisabled": true},
+			}})
+		case "/tfs/DefaultCollection/restricted/_apis/git/repositories":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	conn := &sourcespb.AzureRepos{
+		Endpoint:   srv.URL + "/tfs/",
+		Credential: &sourcespb.AzureRepos_Oauth{Oauth: &credentialspb.Oauth2{AccessToken: "oauth-token"}},
+	}
+	s := initSource(t, nil, conn)
+
+	// Organizations can't be discovered on Azure DevOps Server.
+	assert.ErrorContains(t, s.Enumerate(context.Background(), &sourcestest.TestReporter{}), "must be configured")
+
+	conn.Organizations = []string{"DefaultCollection"}
+	s = initSource(t, nil, conn)
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	require.Len(t, reporter.UnitErrs, 1)
+	assert.ErrorContains(t, reporter.UnitErrs[0], "restricted")
+	assert.ErrorContains(t, reporter.UnitErrs[0], "status 403")
+	assert.Equal(t, []sources.SourceUnit{
+		git.SourceUnit{Kind: git.UnitRepo, ID: srv.URL + "/tfs/DefaultCollection/web/_git/app"},
+	}, reporter.Units)
+}
+
+func TestSource_GetRepoInfo(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, srv, &sourcespb.AzureRepos{Organizations: []string{"contoso"}})
+	require.NoError(t, s.Enumerate(context.Background(), &sourcestest.TestReporter{}))
+
+	assert.Equal(t, repoInfo{
+		org:        "contoso",
+		project:    "web",
+		name:       "infra",
+		visibility: source_metadatapb.Visibility_private,
+	}, s.getRepoInfo("https://dev.azure.com/contoso/web/_git/infra"))
+
+	// Repositories that weren't enumerated fall back to the URL.
+	assert.Equal(t, repoInfo{
+		org:        "Collection",
+		project:    "My Project",
+		name:       "repo",
+		visibility: source_metadatapb.Visibility_unknown,
+	}, s.getRepoInfo("https://tfs.example.com/tfs/Collection/My%20Project/_git/repo"))
+}
@@ -0,0 +1,172 @@
+package azure_repos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	// cloudEndpoint is the Azure DevOps Services endpoint.
+	cloudEndpoint = "https://dev.azure.com"
+	// profileEndpoint hosts the profile and accounts APIs, which are used to
+	// discover organizations for Azure DevOps Services.
+	profileEndpoint = "https://app.vssps.visualstudio.com"
+	apiVersion      = "7.0"
+)
+
+// client is a minimal client for the Azure DevOps REST API.
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+	profileURL string
+	authorize  func(req *http.Request)
+}
+
+type project struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+}
+
+type repository struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	RemoteURL  string  `json:"remoteUrl"`
+	WebURL     string  `json:"webUrl"`
+	IsFork     bool    `json:"isFork"`
+	IsDisabled bool    `json:"isDisabled"`
+	Size       int64   `json:"size"`
+	Project    project `json:"project"`
+}
+
+type listResponse[T any] struct {
+	Value []T `json:"value"`
+}
+
+// getJSON performs a GET request and decodes the JSON response into target.
+// It returns the continuation token, if any.
+func (c *client) getJSON(ctx context.Context, reqURL string, query url.Values, target any) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Azure DevOps API request: %w", err)
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("api-version", apiVersion)
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request to Azure DevOps API: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	// Azure DevOps redirects unauthenticated API requests to a sign-in page
+	// instead of returning 401.
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return "", fmt.Errorf("unexpected response (status %d) for %q", resp.StatusCode, req.URL.Path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return "", fmt.Errorf("failed to decode Azure DevOps API response: %w", err)
+	}
+	return resp.Header.Get("x-ms-continuationtoken"), nil
+}
+
+// listOrganizations returns the organizations the authenticated user is a
+// member of. This is only supported by Azure DevOps Services.
+func (c *client) listOrganizations(ctx context.Context) ([]string, error) {
+	var profile struct {
+		ID string `json:"id"`
+	}
+	if _, err := c.getJSON(ctx, c.profileURL+"/_apis/profile/profiles/me", nil, &profile); err != nil {
+		return nil, fmt.Errorf("error getting user profile: %w", err)
+	}
+
+	var accounts listResponse[struct {
+		AccountName string `json:"accountName"`
+	}]
+	query := url.Values{"memberId": []string{profile.ID}}
+	if _, err := c.getJSON(ctx, c.profileURL+"/_apis/accounts", query, &accounts); err != nil {
+		return nil, fmt.Errorf("error listing organizations: %w", err)
+	}
+
+	orgs := make([]string, 0, len(accounts.Value))
+	for _, a := range accounts.Value {
+		orgs = append(orgs, a.AccountName)
+	}
+	return orgs, nil
+}
+
+// listProjects returns every project in the organization.
+func (c *client) listProjects(ctx context.Context, org string) ([]project, error) {
+	var (
+		projects []project
+		query    = url.Values{"$top": []string{"100"}}
+	)
+	for {
+		var page listResponse[project]
+		token, err := c.getJSON(ctx, c.baseURL+"/"+url.PathEscape(org)+"/_apis/projects", query, &page)
+		if err != nil {
+			return nil, fmt.Errorf("error listing projects in %q: %w", org, err)
+		}
+		projects = append(projects, page.Value...)
+		if token == "" {
+			return projects, nil
+		}
+		query.Set("continuationToken", token)
+	}
+}
+
+// listRepositories returns every repository in the project.
+func (c *client) listRepositories(ctx context.Context, org, projectName string) ([]repository, error) {
+	var page listResponse[repository]
+	reqURL := c.baseURL + "/" + url.PathEscape(org) + "/" + url.PathEscape(projectName) + "/_apis/git/repositories"
+	if _, err := c.getJSON(ctx, reqURL, nil, &page); err != nil {
+		return nil, fmt.Errorf("error listing repositories in %s/%s: %w", org, projectName, err)
+	}
+	return page.Value, nil
+}
+
+// repoCoordinates returns the organization, project and repository name from
+// an Azure Repos URL, e.g. https://dev.azure.com/org/project/_git/repo. For
+// Azure DevOps Server the organization is the project collection.
+func repoCoordinates(repoURL string) (org, projectName, repo string, err error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", "", err
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, part := range parts {
+		if part != "_git" || i < 1 || i+1 >= len(parts) {
+			continue
+		}
+		projectName, repo = parts[i-1], parts[i+1]
+		if i >= 2 {
+			org = parts[i-2]
+		} else if host, _, ok := strings.Cut(u.Hostname(), "."); ok {
+			// Legacy https://org.visualstudio.com/project/_git/repo URLs.
+			org = host
+		}
+		return unescape(org), unescape(projectName), unescape(repo), nil
+	}
+	return "", "", "", fmt.Errorf("%q is not an Azure Repos URL", repoURL)
+}
+
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
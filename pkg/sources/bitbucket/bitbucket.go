@@ -0,0 +1,412 @@
+package bitbucket
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gobwas/glob"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_BITBUCKET
+
+const (
+	// cloudAPIURL is the API endpoint for Bitbucket Cloud.
+	cloudAPIURL = "https://api.bitbucket.org"
+	// tokenUser is the username Bitbucket expects when cloning with an access
+	// token instead of a user's password.
+	tokenUser = "x-token-auth"
+)
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	cloud       bool
+	endpoint    string
+	authMethod  string
+	user        string
+	token       string
+	repos       []string
+	ignoreRepos []string
+
+	client      *client
+	git         *git.Git
+	scanOptions *git.ScanOptions
+
+	jobPool *errgroup.Group
+	sources.Progress
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Bitbucket source.
+func (s *Source) Init(ctx context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	if err := git.CmdCheck(); err != nil {
+		return err
+	}
+
+	var conn sourcespb.Bitbucket
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.repos = conn.GetRepositories()
+	s.ignoreRepos = conn.GetIgnoreRepos()
+
+	var err error
+	s.cloud, s.endpoint, err = resolveInstallation(conn.GetInstallationType(), conn.GetEndpoint())
+	if err != nil {
+		return err
+	}
+
+	var authorize func(req *http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Bitbucket_Token:
+		s.authMethod = "TOKEN"
+		s.user = tokenUser
+		s.token = cred.Token
+		authorize = bearerAuth(s.token)
+	case *sourcespb.Bitbucket_Oauth:
+		s.authMethod = "OAUTH"
+		s.user = tokenUser
+		s.token = cred.Oauth.GetAccessToken()
+		if s.token == "" {
+			return fmt.Errorf("bitbucket OAuth credential requires an access token")
+		}
+		authorize = bearerAuth(s.token)
+	case *sourcespb.Bitbucket_BasicAuth:
+		s.authMethod = "BASIC_AUTH"
+		s.user = cred.BasicAuth.GetUsername()
+		s.token = cred.BasicAuth.GetPassword()
+		authorize = func(req *http.Request) { req.SetBasicAuth(s.user, s.token) }
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	if s.token == "" {
+		return fmt.Errorf("invalid configuration given for source %q (%s): empty credential", name, s.Type().String())
+	}
+	log.RedactGlobally(s.token)
+
+	s.client = &client{
+		httpClient: common.RetryableHTTPClientTimeout(60),
+		baseURL:    s.endpoint,
+		cloud:      s.cloud,
+		authorize:  authorize,
+	}
+
+	cfg := &git.Config{
+		SourceName:   s.name,
+		JobID:        s.jobID,
+		SourceID:     s.sourceID,
+		SourceType:   s.Type(),
+		Verify:       s.verify,
+		SkipBinaries: conn.GetSkipBinaries(),
+		SkipArchives: conn.GetSkipArchives(),
+		Concurrency:  concurrency,
+		SourceMetadataFunc: func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
+			workspace, _ := repoFullName(repository)
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Bitbucket{
+					Bitbucket: &source_metadatapb.Bitbucket{
+						Commit:     sanitizer.UTF8(commit),
+						File:       sanitizer.UTF8(file),
+						Email:      sanitizer.UTF8(email),
+						Repository: sanitizer.UTF8(repository),
+						Workspace:  sanitizer.UTF8(workspace),
+						Link:       s.commitLink(repository, commit, file, line),
+						Timestamp:  sanitizer.UTF8(timestamp),
+						Line:       line,
+					},
+				},
+			}
+		},
+	}
+	s.git = git.NewGit(cfg)
+
+	return nil
+}
+
+// resolveInstallation determines whether the configured endpoint is Bitbucket
+// Cloud or Data Center and returns the API base URL to use.
+func resolveInstallation(installType sourcespb.BitbucketInstallationType, endpoint string) (bool, string, error) {
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	if installType == sourcespb.BitbucketInstallationType_AUTODETECT {
+		installType = sourcespb.BitbucketInstallationType_DATA_CENTER
+		if endpoint == "" || isCloudHost(endpoint) {
+			installType = sourcespb.BitbucketInstallationType_CLOUD
+		}
+	}
+
+	switch installType {
+	case sourcespb.BitbucketInstallationType_CLOUD:
+		if endpoint == "" || isCloudHost(endpoint) {
+			return true, cloudAPIURL, nil
+		}
+		return true, endpoint, nil
+	case sourcespb.BitbucketInstallationType_DATA_CENTER:
+		if endpoint == "" {
+			return false, "", fmt.Errorf("an endpoint is required for Bitbucket Data Center")
+		}
+		if _, err := url.ParseRequestURI(endpoint); err != nil {
+			return false, "", fmt.Errorf("invalid Bitbucket endpoint %q: %w", endpoint, err)
+		}
+		return false, endpoint, nil
+	default:
+		return false, "", fmt.Errorf("unknown Bitbucket installation type %q", installType)
+	}
+}
+
+func isCloudHost(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	return u.Hostname() == "bitbucket.org" || u.Hostname() == "api.bitbucket.org"
+}
+
+func bearerAuth(token string) func(req *http.Request) {
+	return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+}
+
+func (s *Source) commitLink(repository, commit, file string, line int64) string {
+	if s.cloud {
+		return giturl.GenerateLink(repository, commit, file, line)
+	}
+	return dataCenterCommitLink(repository, commit, file, line)
+}
+
+// WithScanOptions sets the git scan options used for every repository.
+func (s *Source) WithScanOptions(scanOptions *git.ScanOptions) {
+	s.scanOptions = scanOptions
+}
+
+// Validate checks that the configured credentials are accepted and that any
+// explicitly configured repositories are reachable.
+func (s *Source) Validate(ctx context.Context) []error {
+	if err := s.client.currentUser(ctx); err != nil {
+		return []error{fmt.Errorf("bitbucket authentication failed using method %v: %w", s.authMethod, err)}
+	}
+
+	repos, errs := normalizeRepos(s.repos)
+	for _, r := range repos {
+		if err := git.PingRepoUsingToken(ctx, s.token, r, s.user); err != nil {
+			errs = append(errs, fmt.Errorf("could not reach git repository %q: %w", r, err))
+		}
+	}
+	if len(repos) > 0 && len(s.ignoreRepos) > 0 {
+		errs = append(errs, fmt.Errorf("both repositories and ignore patterns were explicitly configured; ignore patterns will not be used"))
+	}
+	return errs
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var units []sources.SourceUnit
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	var scanned int64
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, unit := range units {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			repoURL, _ := unit.SourceUnitID()
+			s.SetProgressComplete(i, len(units), fmt.Sprintf("Repo: %s", repoURL), "")
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+				return nil
+			}
+			ctx.Logger().V(2).Info("completed scan", "repo", repoURL, "num", atomic.AddInt64(&scanned, 1), "total", len(units))
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(units), len(units), "Completed Bitbucket scan", "")
+
+	return nil
+}
+
+// Enumerate reports all Bitbucket repositories to be scanned to the reporter.
+// If none are configured, it lists every repository in every workspace (Cloud)
+// or project (Data Center) the credentials can access, respecting the
+// configured ignore patterns.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	repos, errs := normalizeRepos(s.repos)
+	for _, repoErr := range errs {
+		if err := reporter.UnitErr(ctx, repoErr); err != nil {
+			return err
+		}
+	}
+	if len(errs) > 0 && len(repos) == 0 {
+		return fmt.Errorf("all configured repos had validation issues")
+	}
+
+	bitbucketReposEnumerated.WithLabelValues(s.name).Set(0)
+	if len(repos) > 0 {
+		for _, repo := range repos {
+			if err := reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: repo}); err != nil {
+				return err
+			}
+			bitbucketReposEnumerated.WithLabelValues(s.name).Inc()
+		}
+		return nil
+	}
+
+	ignoreRepo := buildIgnorer(s.ignoreRepos, func(err error, pattern string) {
+		ctx.Logger().Error(err, "could not compile ignore repo glob", "glob", pattern)
+	})
+
+	owners, err := s.client.listOwners(ctx)
+	if err != nil {
+		return err
+	}
+	ctx.Logger().V(2).Info("enumerated owners", "count", len(owners), "cloud", s.cloud)
+
+	for _, owner := range owners {
+		ownerRepos, err := s.client.listRepositories(ctx, owner)
+		if err != nil {
+			if err := reporter.UnitErr(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, repo := range ownerRepos {
+			logger := ctx.Logger().WithValues("repo", repo.FullName)
+			if ignoreRepo(repo.FullName) {
+				logger.V(3).Info("skipping repository", "reason", "ignored in config")
+				continue
+			}
+			if repo.CloneURL == "" {
+				err := fmt.Errorf("repository %q has no HTTP clone link", repo.FullName)
+				if err := reporter.UnitErr(ctx, err); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := reporter.UnitOk(ctx, git.SourceUnit{Kind: git.UnitRepo, ID: repo.CloneURL}); err != nil {
+				return err
+			}
+			bitbucketReposEnumerated.WithLabelValues(s.name).Inc()
+		}
+	}
+	return nil
+}
+
+// ChunkUnit clones and scans the given Bitbucket repository unit.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	repoURL, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "repo", repoURL)
+
+	path, repo, err := git.CloneRepoUsingToken(ctx, s.token, repoURL, "", s.user, false)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(path)
+
+	if err := s.git.ScanRepo(ctx, repo, path, s.scanOptions, reporter); err != nil {
+		return err
+	}
+	bitbucketReposScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// UnmarshalSourceUnit implements the SourceUnitUnmarshaller interface.
+func (s *Source) UnmarshalSourceUnit(data []byte) (sources.SourceUnit, error) {
+	return git.UnmarshalUnit(data)
+}
+
+func normalizeRepos(repos []string) ([]string, []error) {
+	validRepos := make([]string, 0, len(repos))
+	var errs []error
+	for _, r := range repos {
+		repo, err := giturl.NormalizeBitbucketRepo(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to normalize bitbucket repo url %q: %w", r, err))
+			continue
+		}
+		validRepos = append(validRepos, repo)
+	}
+	return validRepos, errs
+}
+
+// buildIgnorer returns a function reporting whether a repository full name
+// matches any of the ignore globs.
+func buildIgnorer(ignore []string, onCompileErr func(err error, pattern string)) func(repo string) bool {
+	globs := make([]glob.Glob, 0, len(ignore))
+	for _, pattern := range ignore {
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			onCompileErr(err, pattern)
+			continue
+		}
+		globs = append(globs, g)
+	}
+
+	return func(repo string) bool {
+		for _, g := range globs {
+			if g.Match(repo) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,314 @@
+package bitbucket
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func newCloudServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer cloud-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		repo := func(ws, slug string) map[string]any {
+			return map[string]any{
+				"full_name": ws + "/" + slug,
+				"workspace": map[string]any{"slug": ws},
+				"links": map[string]any{"clone": []map[string]string{
+					{"name": "https", "href": fmt.Sprintf("https://someone@bitbucket.org/%s/%s.git", ws, slug)},
+					{"name": "ssh", "href": fmt.Sprintf("git@bitbucket.org:%s/%s.git", ws, slug)},
+				}},
+			}
+		}
+		switch r.URL.Path {
+		case "/2.0/user":
+			sourcestest.WriteJSON(t, w, map[string]any{"username": "someone"})
+		case "/2.0/workspaces":
+			if r.URL.Query().Get("page") == "2" {
+				sourcestest.WriteJSON(t, w, map[string]any{"values": []map[string]string{{"slug": "beta"}}})
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"values": []map[string]string{{"slug": "alpha"}},
+				"next":   srv.URL + "/2.0/workspaces?page=2",
+			})
+		case "/2.0/repositories/alpha":
+			sourcestest.WriteJSON(t, w, map[string]any{"values": []any{repo("alpha", "one"), repo("alpha", "skip-me")}})
+		case "/2.0/repositories/beta":
+			sourcestest.WriteJSON(t, w, map[string]any{"values": []any{repo("beta", "two")}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newDataCenterServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "hunter2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		repo := func(project, slug string) map[string]any {
+			return map[string]any{
+				"slug":    slug,
+				"project": map[string]any{"key": project},
+				"links": map[string]any{"clone": []map[string]string{
+					{"name": "http", "href": fmt.Sprintf("%s/scm/%s/%s.git", srv.URL, project, slug)},
+				}},
+			}
+		}
+		switch r.URL.Path {
+		case "/rest/api/1.0/projects":
+			if r.URL.Query().Get("start") == "1" {
+				sourcestest.WriteJSON(t, w, map[string]any{"values": []map[string]string{{"key": "OPS"}}, "isLastPage": true})
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"values":        []map[string]string{{"key": "DEV"}},
+				"isLastPage":    false,
+				"nextPageStart": 1,
+			})
+		case "/rest/api/1.0/projects/DEV/repos":
+			sourcestest.WriteJSON(t, w, map[string]any{"values": []any{repo("DEV", "api")}, "isLastPage": true})
+		case "/rest/api/1.0/projects/OPS/repos":
+			sourcestest.WriteJSON(t, w, map[string]any{"values": []any{repo("OPS", "infra"), repo("OPS", "archive")}, "isLastPage": true})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func initSource(t *testing.T, conn *sourcespb.Bitbucket) *Source {
+	t.Helper()
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	return s
+}
+
+func TestSource_Enumerate_Cloud(t *testing.T) {
+	srv := newCloudServer(t)
+	s := initSource(t, &sourcespb.Bitbucket{
+		Endpoint:         srv.URL,
+		InstallationType: sourcespb.BitbucketInstallationType_CLOUD,
+		Credential:       &sourcespb.Bitbucket_Token{Token: "cloud-token"},
+		IgnoreRepos:      []string{"*/skip-*"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []string{
+		"https://bitbucket.org/alpha/one.git",
+		"https://bitbucket.org/beta/two.git",
+	}, unitIDs(reporter))
+	assert.Empty(t, s.Validate(context.Background()))
+}
+
+func TestSource_Enumerate_DataCenter(t *testing.T) {
+	srv := newDataCenterServer(t)
+	s := initSource(t, &sourcespb.Bitbucket{
+		Endpoint: srv.URL,
+		Credential: &sourcespb.Bitbucket_BasicAuth{BasicAuth: &credentialspb.BasicAuth{
+			Username: "admin",
+			Password: "hunter2",
+		}},
+		IgnoreRepos: []string{"OPS/archive"},
+	})
+	assert.False(t, s.cloud)
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []string{
+		srv.URL + "/scm/DEV/api.git",
+		srv.URL + "/scm/OPS/infra.git",
+	}, unitIDs(reporter))
+}
+
+func TestSource_Enumerate_BadCredentials(t *testing.T) {
+	srv := newCloudServer(t)
+	s := initSource(t, &sourcespb.Bitbucket{
+		Endpoint:         srv.URL,
+		InstallationType: sourcespb.BitbucketInstallationType_CLOUD,
+		Credential:       &sourcespb.Bitbucket_Token{Token: "wrong"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	assert.Error(t, s.Enumerate(context.Background(), &reporter))
+	assert.NotEmpty(t, s.Validate(context.Background()))
+}
+
+func TestSource_Enumerate_DataCenterPageStartNotAdvancing(t *testing.T) {
+	// A page that claims not to be the last but has no nextPageStart must
+	// not restart the listing from the first page.
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/1.0/projects":
+			if requests.Add(1) > 2 {
+				t.Error("projects listed again")
+				w.WriteHeader(http.StatusTeapot)
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{"values": []map[string]string{{"key": "DEV"}}, "isLastPage": false})
+		case "/rest/api/1.0/projects/DEV/repos":
+			sourcestest.WriteJSON(t, w, map[string]any{"values": []any{}, "isLastPage": true})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	s := initSource(t, &sourcespb.Bitbucket{
+		Endpoint:   srv.URL,
+		Credential: &sourcespb.Bitbucket_Token{Token: "token"},
+	})
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.EqualValues(t, 1, requests.Load())
+	assert.Empty(t, reporter.Units)
+}
+
+func TestClient_GetJSON(t *testing.T) {
+	var rateLimited atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/rate-limited":
+			// Bitbucket Cloud answers 429 with Retry-After when an hourly
+			// limit is reached; the request is retried once it has passed.
+			if rateLimited.CompareAndSwap(false, true) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]string{"ok": "yes"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	s := initSource(t, &sourcespb.Bitbucket{
+		Endpoint:   srv.URL,
+		Credential: &sourcespb.Bitbucket_Token{Token: "token"},
+	})
+	ctx := context.Background()
+	var got map[string]string
+
+	assert.ErrorContains(t, s.client.getJSON(ctx, srv.URL+"/unauthorized", &got), "invalid Bitbucket credentials")
+	assert.ErrorContains(t, s.client.getJSON(ctx, srv.URL+"/forbidden", &got), `insufficient permissions for "/forbidden"`)
+	assert.ErrorContains(t, s.client.getJSON(ctx, srv.URL+"/missing", &got), "unexpected status code 404")
+
+	require.NoError(t, s.client.getJSON(ctx, srv.URL+"/rate-limited", &got))
+	assert.Equal(t, map[string]string{"ok": "yes"}, got)
+}
+
+func TestSource_Enumerate_ConfiguredRepos(t *testing.T) {
+	s := initSource(t, &sourcespb.Bitbucket{
+		Credential:   &sourcespb.Bitbucket_Token{Token: "token"},
+		Repositories: []string{"https://bitbucket.org/org/repo", "http://bitbucket.example.com/PROJ/repo", "git@bitbucket.org:org/ssh.git"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+	assert.Len(t, reporter.UnitErrs, 1)
+	assert.Equal(t, []sources.SourceUnit{
+		git.SourceUnit{Kind: git.UnitRepo, ID: "https://bitbucket.org/org/repo.git"},
+		git.SourceUnit{Kind: git.UnitRepo, ID: "http://bitbucket.example.com/PROJ/repo.git"},
+	}, reporter.Units)
+}
+
+func TestResolveInstallation(t *testing.T) {
+	tests := map[string]struct {
+		installType  sourcespb.BitbucketInstallationType
+		endpoint     string
+		wantCloud    bool
+		wantEndpoint string
+		wantErr      bool
+	}{
+		"autodetect empty endpoint": {
+			wantCloud:    true,
+			wantEndpoint: cloudAPIURL,
+		},
+		"autodetect bitbucket.org": {
+			endpoint:     "https://bitbucket.org/",
+			wantCloud:    true,
+			wantEndpoint: cloudAPIURL,
+		},
+		"autodetect self-hosted": {
+			endpoint:     "https://git.example.com/bitbucket/",
+			wantEndpoint: "https://git.example.com/bitbucket",
+		},
+		"explicit data center without endpoint": {
+			installType: sourcespb.BitbucketInstallationType_DATA_CENTER,
+			wantErr:     true,
+		},
+		"explicit cloud with custom endpoint": {
+			installType:  sourcespb.BitbucketInstallationType_CLOUD,
+			endpoint:     "http://127.0.0.1:8080",
+			wantCloud:    true,
+			wantEndpoint: "http://127.0.0.1:8080",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cloud, endpoint, err := resolveInstallation(tt.installType, tt.endpoint)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCloud, cloud)
+			assert.Equal(t, tt.wantEndpoint, endpoint)
+		})
+	}
+}
+
+func TestDataCenterCommitLink(t *testing.T) {
+	repo := "https://git.example.com/bitbucket/scm/DEV/api.git"
+	assert.Equal(t,
+		"https://git.example.com/bitbucket/projects/DEV/repos/api/commits/abc123",
+		dataCenterCommitLink(repo, "abc123", "", 0))
+	assert.Equal(t,
+		"https://git.example.com/bitbucket/projects/DEV/repos/api/browse/config/app.yaml?at=abc123#12",
+		dataCenterCommitLink(repo, "abc123", "config/app.yaml", 12))
+}
+
+func unitIDs(reporter sourcestest.TestReporter) []string {
+	ids := make([]string, 0, len(reporter.Units))
+	for _, u := range reporter.Units {
+		id, _ := u.SourceUnitID()
+		ids = append(ids, id)
+	}
+	return ids
+}
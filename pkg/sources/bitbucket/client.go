@@ -0,0 +1,274 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	// Bitbucket Cloud allows at most 100 results per page.
+	cloudPageLen = 100
+	// Bitbucket Data Center defaults to 25 results per page but supports more.
+	dataCenterPageLimit = 100
+)
+
+// repository is the provider-agnostic view of a Bitbucket repository used by
+// the source.
+type repository struct {
+	// FullName is "workspace/slug" for Cloud and "PROJECT/slug" for Data Center.
+	FullName string
+	// Owner is the workspace (Cloud) or project key (Data Center).
+	Owner    string
+	CloneURL string
+}
+
+// client is a minimal Bitbucket REST client that understands both the Cloud
+// (api.bitbucket.org/2.0) and the Data Center (/rest/api/1.0) APIs.
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+	cloud      bool
+	authorize  func(req *http.Request)
+}
+
+// getJSON performs a GET request against the provided URL and decodes the
+// JSON response into target.
+func (c *client) getJSON(ctx context.Context, reqURL string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Bitbucket API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to Bitbucket API: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("invalid Bitbucket credentials (status %d)", resp.StatusCode)
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("insufficient permissions for %q (status %d)", req.URL.Path, resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("unexpected status code %d for %q", resp.StatusCode, req.URL.Path)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Bitbucket API response: %w", err)
+	}
+	return nil
+}
+
+// Bitbucket Cloud API types.
+
+type cloudPage[T any] struct {
+	Values []T    `json:"values"`
+	Next   string `json:"next"`
+}
+
+type cloudWorkspace struct {
+	Slug string `json:"slug"`
+}
+
+type cloudLink struct {
+	Name string `json:"name"`
+	Href string `json:"href"`
+}
+
+type cloudRepository struct {
+	FullName  string         `json:"full_name"`
+	Workspace cloudWorkspace `json:"workspace"`
+	Links     struct {
+		Clone []cloudLink `json:"clone"`
+	} `json:"links"`
+}
+
+// Bitbucket Data Center API types.
+
+type dataCenterPage[T any] struct {
+	Values        []T  `json:"values"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+type dataCenterProject struct {
+	Key string `json:"key"`
+}
+
+type dataCenterRepository struct {
+	Slug    string            `json:"slug"`
+	Project dataCenterProject `json:"project"`
+	Links   struct {
+		Clone []cloudLink `json:"clone"`
+	} `json:"links"`
+}
+
+// listOwners returns the workspaces (Cloud) or project keys (Data Center)
+// visible to the authenticated user.
+func (c *client) listOwners(ctx context.Context) ([]string, error) {
+	var owners []string
+	if c.cloud {
+		next := fmt.Sprintf("%s/2.0/workspaces?pagelen=%d", c.baseURL, cloudPageLen)
+		for next != "" {
+			var page cloudPage[cloudWorkspace]
+			if err := c.getJSON(ctx, next, &page); err != nil {
+				return nil, fmt.Errorf("error listing workspaces: %w", err)
+			}
+			for _, ws := range page.Values {
+				owners = append(owners, ws.Slug)
+			}
+			next = page.Next
+		}
+		return owners, nil
+	}
+
+	for start := 0; ; {
+		reqURL := fmt.Sprintf("%s/rest/api/1.0/projects?limit=%d&start=%d", c.baseURL, dataCenterPageLimit, start)
+		var page dataCenterPage[dataCenterProject]
+		if err := c.getJSON(ctx, reqURL, &page); err != nil {
+			return nil, fmt.Errorf("error listing projects: %w", err)
+		}
+		for _, proj := range page.Values {
+			owners = append(owners, proj.Key)
+		}
+		if page.IsLastPage || page.NextPageStart <= start {
+			break
+		}
+		start = page.NextPageStart
+	}
+	return owners, nil
+}
+
+// listRepositories returns every repository belonging to the given workspace
+// (Cloud) or project (Data Center).
+func (c *client) listRepositories(ctx context.Context, owner string) ([]repository, error) {
+	var repos []repository
+	if c.cloud {
+		next := fmt.Sprintf("%s/2.0/repositories/%s?pagelen=%d", c.baseURL, url.PathEscape(owner), cloudPageLen)
+		for next != "" {
+			var page cloudPage[cloudRepository]
+			if err := c.getJSON(ctx, next, &page); err != nil {
+				return nil, fmt.Errorf("error listing repositories for workspace %q: %w", owner, err)
+			}
+			for _, r := range page.Values {
+				repos = append(repos, repository{
+					FullName: r.FullName,
+					Owner:    r.Workspace.Slug,
+					CloneURL: httpCloneURL(r.Links.Clone, "https"),
+				})
+			}
+			next = page.Next
+		}
+		return repos, nil
+	}
+
+	for start := 0; ; {
+		reqURL := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos?limit=%d&start=%d",
+			c.baseURL, url.PathEscape(owner), dataCenterPageLimit, start)
+		var page dataCenterPage[dataCenterRepository]
+		if err := c.getJSON(ctx, reqURL, &page); err != nil {
+			return nil, fmt.Errorf("error listing repositories for project %q: %w", owner, err)
+		}
+		for _, r := range page.Values {
+			repos = append(repos, repository{
+				FullName: r.Project.Key + "/" + r.Slug,
+				Owner:    r.Project.Key,
+				CloneURL: httpCloneURL(r.Links.Clone, "http"),
+			})
+		}
+		if page.IsLastPage || page.NextPageStart <= start {
+			break
+		}
+		start = page.NextPageStart
+	}
+	return repos, nil
+}
+
+// currentUser checks that the configured credentials are accepted by the API.
+func (c *client) currentUser(ctx context.Context) error {
+	var discard map[string]any
+	if c.cloud {
+		return c.getJSON(ctx, c.baseURL+"/2.0/user", &discard)
+	}
+	// Data Center has no "current user" endpoint; listing a single project is
+	// the cheapest authenticated call.
+	return c.getJSON(ctx, c.baseURL+"/rest/api/1.0/projects?limit=1", &discard)
+}
+
+// httpCloneURL picks the clone link with the given name and strips any user
+// information Bitbucket embeds in it, since credentials are supplied
+// separately when cloning.
+func httpCloneURL(links []cloudLink, name string) string {
+	for _, link := range links {
+		if link.Name != name {
+			continue
+		}
+		u, err := url.Parse(link.Href)
+		if err != nil {
+			return link.Href
+		}
+		u.User = nil
+		return u.String()
+	}
+	return ""
+}
+
+// repoFullName derives "owner/slug" from a Bitbucket clone URL. Cloud URLs
+// look like https://bitbucket.org/owner/slug.git, while Data Center URLs look
+// like https://host/scm/owner/slug.git (optionally under a context path).
+func repoFullName(cloneURL string) (owner, slug string) {
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return "", ""
+	}
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if _, after, found := strings.Cut(path, "scm/"); found {
+		path = after
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+// dataCenterCommitLink builds a web link to a commit (and optionally a file
+// and line) on a Bitbucket Data Center instance.
+func dataCenterCommitLink(cloneURL, commit, file string, line int64) string {
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		return ""
+	}
+	owner, slug := repoFullName(cloneURL)
+	if owner == "" {
+		return ""
+	}
+	contextPath, _, _ := strings.Cut(u.Path, "/scm/")
+	u.User = nil
+	if file == "" {
+		u.Path = fmt.Sprintf("%s/projects/%s/repos/%s/commits/%s", contextPath, owner, slug, commit)
+		return u.String()
+	}
+	u.Path = fmt.Sprintf("%s/projects/%s/repos/%s/browse/%s", contextPath, owner, slug, file)
+	q := url.Values{"at": []string{commit}}
+	u.RawQuery = q.Encode()
+	if line > 0 {
+		u.Fragment = strconv.FormatInt(line, 10)
+	}
+	return u.String()
+}
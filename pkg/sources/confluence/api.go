@@ -0,0 +1,201 @@
+package confluence
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// pageLimit is the number of results requested per page. Confluence caps
+// most endpoints at 100 (and content with an expanded body at 50).
+const pageLimit = 50
+
+// apiClient is a minimal client for the Confluence REST API (v1), which is
+// shared by Confluence Cloud and Data Center.
+type apiClient struct {
+	httpClient *http.Client
+	// baseURL is the Confluence base URL, e.g. https://example.atlassian.net/wiki.
+	baseURL   string
+	authorize func(req *http.Request)
+}
+
+type links struct {
+	WebUI    string `json:"webui"`
+	Download string `json:"download"`
+}
+
+type space struct {
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Links links  `json:"_links"`
+}
+
+type user struct {
+	Email       string `json:"email"`
+	DisplayName string `json:"displayName"`
+	Username    string `json:"username"`
+}
+
+type version struct {
+	Number int    `json:"number"`
+	When   string `json:"when"`
+	By     user   `json:"by"`
+}
+
+type content struct {
+	ID      string  `json:"id"`
+	Type    string  `json:"type"`
+	Title   string  `json:"title"`
+	Version version `json:"version"`
+	Body    struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links links `json:"_links"`
+}
+
+type results[T any] struct {
+	Results []T `json:"results"`
+	Start   int `json:"start"`
+	Limit   int `json:"limit"`
+	Size    int `json:"size"`
+}
+
+// getJSON performs a GET request against the given API path and decodes the
+// JSON response into target.
+func (c *apiClient) getJSON(ctx context.Context, path string, query url.Values, target any) error {
+	resp, err := c.get(ctx, c.baseURL+path, query)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Confluence API response: %w", err)
+	}
+	return nil
+}
+
+// get performs an authenticated GET request and returns the response if it
+// was successful. The caller is responsible for closing the body.
+func (c *apiClient) get(ctx context.Context, reqURL string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Confluence API request: %w", err)
+	}
+	if query != nil {
+		req.URL.RawQuery = query.Encode()
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Confluence API: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %q", resp.StatusCode, req.URL.Path)
+	}
+	return resp, nil
+}
+
+// paginate requests successive pages of results from path until a short page
+// is returned. Iteration stops early if visit returns an error.
+func paginate[T any](ctx context.Context, c *apiClient, path string, query url.Values, visit func(T) error) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	for start := 0; ; {
+		query.Set("start", strconv.Itoa(start))
+		query.Set("limit", strconv.Itoa(pageLimit))
+
+		var page results[T]
+		if err := c.getJSON(ctx, path, query, &page); err != nil {
+			return err
+		}
+		for _, item := range page.Results {
+			if err := visit(item); err != nil {
+				return err
+			}
+		}
+		if len(page.Results) == 0 || page.Size < page.Limit {
+			return nil
+		}
+		start += len(page.Results)
+	}
+}
+
+// listSpaces visits every space of the given type ("global", "personal" or
+// empty for all types).
+func (c *apiClient) listSpaces(ctx context.Context, spaceType string, visit func(space) error) error {
+	query := url.Values{}
+	if spaceType != "" {
+		query.Set("type", spaceType)
+	}
+	return paginate(ctx, c, "/rest/api/space", query, visit)
+}
+
+// getSpace returns a single space by key.
+func (c *apiClient) getSpace(ctx context.Context, key string) (space, error) {
+	var s space
+	err := c.getJSON(ctx, "/rest/api/space/"+url.PathEscape(key), nil, &s)
+	return s, err
+}
+
+// listContent visits the latest version of every page and blog post in the
+// space, including the storage-format body.
+func (c *apiClient) listContent(ctx context.Context, spaceKey string, visit func(content) error) error {
+	for _, contentType := range []string{"page", "blogpost"} {
+		query := url.Values{
+			"spaceKey": []string{spaceKey},
+			"type":     []string{contentType},
+			"expand":   []string{"body.storage,version"},
+		}
+		if err := paginate(ctx, c, "/rest/api/content", query, visit); err != nil {
+			return fmt.Errorf("error listing %s content in space %q: %w", contentType, spaceKey, err)
+		}
+	}
+	return nil
+}
+
+// getHistoricalContent returns the content as it was at the given version.
+func (c *apiClient) getHistoricalContent(ctx context.Context, contentID string, versionNumber int) (content, error) {
+	var cnt content
+	query := url.Values{
+		"status":  []string{"historical"},
+		"version": []string{strconv.Itoa(versionNumber)},
+		"expand":  []string{"body.storage,version"},
+	}
+	err := c.getJSON(ctx, "/rest/api/content/"+url.PathEscape(contentID), query, &cnt)
+	return cnt, err
+}
+
+// listAttachments visits every attachment of the given content.
+func (c *apiClient) listAttachments(ctx context.Context, contentID string, visit func(content) error) error {
+	query := url.Values{"expand": []string{"version"}}
+	return paginate(ctx, c, "/rest/api/content/"+url.PathEscape(contentID)+"/child/attachment", query, visit)
+}
+
+// download fetches an attachment by its download link, which is relative to
+// the base URL.
+func (c *apiClient) download(ctx context.Context, downloadPath string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, c.baseURL+downloadPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,343 @@
+package confluence
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/roundtripper"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_CONFLUENCE
+
+const unitKindSpace = "space"
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	baseURL            string
+	spaces             []string
+	ignoreSpaces       map[string]struct{}
+	spacesScope        sourcespb.Confluence_GetAllSpacesScope
+	includeAttachments bool
+	skipHistory        bool
+
+	client *apiClient
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Confluence source.
+func (s *Source) Init(ctx context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.Confluence
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.baseURL = strings.TrimRight(conn.GetEndpoint(), "/")
+	if _, err := url.ParseRequestURI(s.baseURL); err != nil {
+		return fmt.Errorf("invalid Confluence endpoint %q: %w", conn.GetEndpoint(), err)
+	}
+	s.spaces = conn.GetSpaces()
+	s.ignoreSpaces = make(map[string]struct{}, len(conn.GetIgnoreSpaces()))
+	for _, key := range conn.GetIgnoreSpaces() {
+		s.ignoreSpaces[strings.ToUpper(key)] = struct{}{}
+	}
+	s.spacesScope = conn.GetSpacesScope()
+	s.includeAttachments = conn.GetIncludeAttachments()
+	s.skipHistory = conn.GetSkipHistory()
+
+	var authorize func(req *http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Confluence_Unauthenticated:
+	case *sourcespb.Confluence_BasicAuth:
+		user, password := cred.BasicAuth.GetUsername(), cred.BasicAuth.GetPassword()
+		if password == "" {
+			return fmt.Errorf("invalid configuration given for source %q (%s): empty password", name, s.Type().String())
+		}
+		log.RedactGlobally(password)
+		authorize = func(req *http.Request) { req.SetBasicAuth(user, password) }
+	case *sourcespb.Confluence_Token:
+		token := cred.Token
+		if token == "" {
+			return fmt.Errorf("invalid configuration given for source %q (%s): empty token", name, s.Type().String())
+		}
+		log.RedactGlobally(token)
+		authorize = func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	var opts []func(*roundtripper.RoundTripper)
+	// If the user has specified to skip TLS verification, we add the WithInsecureTLS option.
+	if conn.GetInsecureSkipVerifyTls() {
+		opts = append(opts, roundtripper.WithInsecureTLS())
+	}
+	const retryDelay = time.Second * 30
+	opts = append(opts,
+		roundtripper.WithLogger(ctx.Logger()),
+		roundtripper.WithRetryable(
+			roundtripper.WithShouldRetry5XXDuration(retryDelay),
+		),
+	)
+
+	s.client = &apiClient{
+		httpClient: &http.Client{Transport: roundtripper.NewRoundTripper(nil, opts...)},
+		baseURL:    s.baseURL,
+		authorize:  authorize,
+	}
+
+	return nil
+}
+
+// Validate checks that the configured credentials are accepted and that any
+// explicitly configured spaces exist.
+func (s *Source) Validate(ctx context.Context) []error {
+	var errs []error
+	if len(s.spaces) == 0 {
+		query := url.Values{"limit": []string{"1"}}
+		if err := s.client.getJSON(ctx, "/rest/api/space", query, &results[space]{}); err != nil {
+			errs = append(errs, fmt.Errorf("could not list Confluence spaces: %w", err))
+		}
+		return errs
+	}
+	for _, key := range s.spaces {
+		if _, err := s.client.getSpace(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("could not get Confluence space %q: %w", key, err))
+		}
+	}
+	return errs
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var units []sources.SourceUnit
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, unit := range units {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			key, _ := unit.SourceUnitID()
+			s.SetProgressComplete(i, len(units), fmt.Sprintf("Space: %s", key), "")
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+			}
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(units), len(units), "Completed Confluence scan", "")
+
+	return nil
+}
+
+// Enumerate reports every Confluence space to be scanned. If no spaces are
+// configured, all spaces within the configured scope are listed.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	report := func(key string) error {
+		if s.ignored(key) {
+			ctx.Logger().V(3).Info("skipping space", "space", key, "reason", "ignored in config")
+			return nil
+		}
+		return reporter.UnitOk(ctx, sources.CommonSourceUnit{Kind: unitKindSpace, ID: key})
+	}
+
+	if len(s.spaces) > 0 {
+		for _, key := range s.spaces {
+			if err := report(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return s.client.listSpaces(ctx, spaceType(s.spacesScope), func(sp space) error {
+		return report(sp.Key)
+	})
+}
+
+func (s *Source) ignored(key string) bool {
+	_, ok := s.ignoreSpaces[strings.ToUpper(key)]
+	return ok
+}
+
+// spaceType maps the configured scope to the Confluence space type filter.
+func spaceType(scope sourcespb.Confluence_GetAllSpacesScope) string {
+	switch scope {
+	case sourcespb.Confluence_GLOBAL:
+		return "global"
+	case sourcespb.Confluence_PERSONAL:
+		return "personal"
+	default:
+		return ""
+	}
+}
+
+// ChunkUnit scans every page and blog post in a space, including historical
+// versions and attachments when configured.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	key, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "space", key)
+
+	return s.client.listContent(ctx, key, func(cnt content) error {
+		if common.IsDone(ctx) {
+			return ctx.Err()
+		}
+		logger := ctx.Logger().WithValues("content_id", cnt.ID, "title", cnt.Title)
+
+		if err := reporter.ChunkOk(ctx, s.contentChunk(key, cnt, s.baseURL+cnt.Links.WebUI)); err != nil {
+			return err
+		}
+
+		if !s.skipHistory {
+			for v := cnt.Version.Number - 1; v > 0; v-- {
+				old, err := s.client.getHistoricalContent(ctx, cnt.ID, v)
+				if err != nil {
+					if err := reporter.ChunkErr(ctx, fmt.Errorf("error fetching version %d of %q: %w", v, cnt.Title, err)); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := reporter.ChunkOk(ctx, s.contentChunk(key, old, s.versionLink(cnt.ID, v))); err != nil {
+					return err
+				}
+			}
+		}
+
+		if s.includeAttachments {
+			if err := s.scanAttachments(ctx, key, cnt, reporter); err != nil {
+				logger.V(2).Info("error scanning attachments", "error", err)
+				return reporter.ChunkErr(ctx, err)
+			}
+		}
+		return nil
+	})
+}
+
+// versionLink returns a link to a specific historical version of a page.
+func (s *Source) versionLink(contentID string, version int) string {
+	return fmt.Sprintf("%s/pages/viewpage.action?pageId=%s&pageVersion=%d", s.baseURL, url.QueryEscape(contentID), version)
+}
+
+func (s *Source) contentChunk(spaceKey string, cnt content, link string) sources.Chunk {
+	data := cnt.Title + "\n" + cnt.Body.Storage.Value
+	return sources.Chunk{
+		SourceType:     s.Type(),
+		SourceName:     s.name,
+		SourceID:       s.sourceID,
+		JobID:          s.jobID,
+		SourceMetadata: s.metadata(spaceKey, cnt.Title, cnt.Type, "", link, cnt.Version),
+		Data:           []byte(data),
+		Verify:         s.verify,
+	}
+}
+
+func (s *Source) metadata(spaceKey, page, location, file, link string, v version) *source_metadatapb.MetaData {
+	return &source_metadatapb.MetaData{
+		Data: &source_metadatapb.MetaData_Confluence{
+			Confluence: &source_metadatapb.Confluence{
+				Page:      sanitizer.UTF8(page),
+				Space:     sanitizer.UTF8(spaceKey),
+				Version:   strconv.Itoa(v.Number),
+				Link:      link,
+				Email:     sanitizer.UTF8(v.By.Email),
+				Timestamp: sanitizer.UTF8(v.When),
+				Location:  location,
+				File:      sanitizer.UTF8(file),
+			},
+		},
+	}
+}
+
+// scanAttachments downloads every attachment of the content and passes it
+// through the file handlers.
+func (s *Source) scanAttachments(ctx context.Context, spaceKey string, parent content, reporter sources.ChunkReporter) error {
+	return s.client.listAttachments(ctx, parent.ID, func(attachment content) error {
+		if attachment.Links.Download == "" {
+			return nil
+		}
+		fileCtx := context.WithValues(ctx, "attachment", attachment.Title)
+
+		rc, err := s.client.download(fileCtx, attachment.Links.Download)
+		if err != nil {
+			return reporter.ChunkErr(fileCtx, fmt.Errorf("error downloading attachment %q: %w", attachment.Title, err))
+		}
+		defer rc.Close()
+
+		chunkSkel := &sources.Chunk{
+			SourceType:     s.Type(),
+			SourceName:     s.name,
+			SourceID:       s.sourceID,
+			JobID:          s.jobID,
+			SourceMetadata: s.metadata(spaceKey, parent.Title, "attachment", attachment.Title, s.baseURL+attachment.Links.Download, attachment.Version),
+			Verify:         s.verify,
+		}
+		if err := handlers.HandleFile(fileCtx, rc, chunkSkel, reporter); err != nil {
+			return reporter.ChunkErr(fileCtx, fmt.Errorf("error handling attachment %q: %w", attachment.Title, err))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,295 @@
+package confluence
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func page(results ...any) map[string]any {
+	return map[string]any{"results": results, "start": 0, "limit": pageLimit, "size": len(results)}
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me@example.com" || pass != "api-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/wiki/rest/api/space":
+			if q.Get("type") == "personal" {
+				sourcestest.WriteJSON(t, w, page(map[string]any{"key": "~me"}))
+				return
+			}
+			sourcestest.WriteJSON(t, w, page(map[string]any{"key": "OPS"}, map[string]any{"key": "ARCHIVE"}))
+		case "/wiki/rest/api/content":
+			if q.Get("spaceKey") != "OPS" || q.Get("type") != "page" {
+				sourcestest.WriteJSON(t, w, page())
+				return
+			}
+			sourcestest.WriteJSON(t, w, page(map[string]any{
+				"id":      "42",
+				"type":    "page",
+				"title":   "Runbook",
+				"version": map[string]any{"number": 2, "when": "2024-01-02T00:00:00Z", "by": map[string]any{"email": "ops@example.com"}},
+				"body":    map[string]any{"storage": map[string]any{"value": "<p>nothing to see</p>"}},
+				"_links":  map[string]any{"webui": "/spaces/OPS/pages/42/Runbook"},
+			}))
+		case "/wiki/rest/api/content/42":
+			if q.Get("status") != "historical" || q.Get("version") != "1" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"id":      "42",
+				"type":    "page",
+				"title":   "Runbook",
+				"version": map[string]any{"number": 1, "when": "2024-01-01T00:00:00Z"},
+				"body":    map[string]any{"storage": map[string]any{"value": "<p>password=hunter2</p>"}},
+			})
+		case "/wiki/rest/api/content/42/child/attachment":
+			sourcestest.WriteJSON(t, w, page(map[string]any{
+				"id":      "att1",
+				"type":    "attachment",
+				"title":   "creds.txt",
+				"version": map[string]any{"number": 1},
+				"_links":  map[string]any{"download": "/download/attachments/42/creds.txt"},
+			}))
+		case "/wiki/download/attachments/42/creds.txt":
+			_, _ = w.Write([]byte("AKIA in an attachment"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func initSource(t *testing.T, conn *sourcespb.Confluence) *Source {
+	t.Helper()
+	if conn.Credential == nil {
+		conn.Credential = &sourcespb.Confluence_BasicAuth{BasicAuth: &credentialspb.BasicAuth{
+			Username: "me@example.com",
+			Password: "api-token",
+		}}
+	}
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	return s
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+
+	tests := map[string]struct {
+		conn *sourcespb.Confluence
+		want []string
+	}{
+		"all spaces with ignore": {
+			conn: &sourcespb.Confluence{IgnoreSpaces: []string{"archive"}},
+			want: []string{"OPS"},
+		},
+		"personal scope": {
+			conn: &sourcespb.Confluence{SpacesScope: sourcespb.Confluence_PERSONAL},
+			want: []string{"~me"},
+		},
+		"configured spaces": {
+			conn: &sourcespb.Confluence{Spaces: []string{"DEV", "OPS"}, IgnoreSpaces: []string{"OPS"}},
+			want: []string{"DEV"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.conn.Endpoint = srv.URL + "/wiki/"
+			s := initSource(t, tt.conn)
+
+			reporter := sourcestest.TestReporter{}
+			require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+			var got []string
+			for _, unit := range reporter.Units {
+				id, kind := unit.SourceUnitID()
+				assert.Equal(t, sources.SourceUnitKind(unitKindSpace), kind)
+				got = append(got, id)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSource_ChunkUnit(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+
+	s := initSource(t, &sourcespb.Confluence{
+		Endpoint:           srv.URL + "/wiki",
+		IncludeAttachments: true,
+	})
+	assert.Empty(t, s.Validate(context.Background()))
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindSpace, ID: "OPS"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 3)
+
+	var links []string
+	for _, chunk := range reporter.Chunks {
+		meta := chunk.SourceMetadata.GetConfluence()
+		require.NotNil(t, meta)
+		assert.Equal(t, "OPS", meta.Space)
+		assert.Equal(t, "Runbook", meta.Page)
+		links = append(links, meta.Link)
+	}
+	sort.Strings(links)
+	assert.Equal(t, []string{
+		srv.URL + "/wiki/download/attachments/42/creds.txt",
+		srv.URL + "/wiki/pages/viewpage.action?pageId=42&pageVersion=1",
+		srv.URL + "/wiki/spaces/OPS/pages/42/Runbook",
+	}, links)
+
+	historical := reporter.Chunks[1]
+	assert.Equal(t, "1", historical.SourceMetadata.GetConfluence().Version)
+	assert.Contains(t, string(historical.Data), "password=hunter2")
+
+	attachment := reporter.Chunks[2]
+	assert.Equal(t, "creds.txt", attachment.SourceMetadata.GetConfluence().File)
+	assert.Equal(t, "AKIA in an attachment", string(attachment.Data))
+}
+
+func TestSource_ChunkUnit_SkipHistory(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+
+	s := initSource(t, &sourcespb.Confluence{
+		Endpoint:    srv.URL + "/wiki",
+		SkipHistory: true,
+	})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindSpace, ID: "OPS"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	require.Len(t, reporter.Chunks, 1)
+	assert.Equal(t, "2", reporter.Chunks[0].SourceMetadata.GetConfluence().Version)
+}
+
+func TestSource_Validate_BadCredentials(t *testing.T) {
+	srv := newServer(t)
+	defer srv.Close()
+
+	s := initSource(t, &sourcespb.Confluence{
+		Endpoint:   srv.URL + "/wiki",
+		Credential: &sourcespb.Confluence_Token{Token: "wrong"},
+	})
+	assert.NotEmpty(t, s.Validate(context.Background()))
+}
+
+func TestSource_Enumerate_ServerCappedPageSize(t *testing.T) {
+	// Confluence may return fewer results per page than requested. Paging
+	// must follow the limit the server reports and advance by the number of
+	// results actually returned.
+	const serverLimit = 2
+	keys := []string{"A", "B", "C", "D", "E"}
+
+	var starts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		starts = append(starts, q.Get("start"))
+		var start int
+		_, _ = fmt.Sscan(q.Get("start"), &start)
+		var results []any
+		for i := start; i < len(keys) && i < start+serverLimit; i++ {
+			results = append(results, map[string]any{"key": keys[i]})
+		}
+		sourcestest.WriteJSON(t, w, map[string]any{"results": results, "start": start, "limit": serverLimit, "size": len(results)})
+	}))
+	defer srv.Close()
+
+	s := initSource(t, &sourcespb.Confluence{
+		Endpoint:   srv.URL + "/wiki",
+		Credential: &sourcespb.Confluence_Unauthenticated{},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+	var got []string
+	for _, unit := range reporter.Units {
+		id, _ := unit.SourceUnitID()
+		got = append(got, id)
+	}
+	assert.Equal(t, keys, got)
+	assert.Equal(t, []string{"0", "2", "4"}, starts)
+}
+
+func TestSource_ChunkUnit_MissingVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/wiki/rest/api/content":
+			if r.URL.Query().Get("type") != "page" {
+				sourcestest.WriteJSON(t, w, page())
+				return
+			}
+			sourcestest.WriteJSON(t, w, page(map[string]any{
+				"id":      "7",
+				"type":    "page",
+				"title":   "Rotated",
+				"version": map[string]any{"number": 3},
+				"body":    map[string]any{"storage": map[string]any{"value": "current"}},
+			}))
+		case "/wiki/rest/api/content/7":
+			// Version 2 was purged, version 1 is still there.
+			if r.URL.Query().Get("version") == "2" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"id":      "7",
+				"type":    "page",
+				"title":   "Rotated",
+				"version": map[string]any{"number": 1},
+				"body":    map[string]any{"storage": map[string]any{"value": "original"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	s := initSource(t, &sourcespb.Confluence{
+		Endpoint:   srv.URL + "/wiki",
+		Credential: &sourcespb.Confluence_Token{Token: "pat"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindSpace, ID: "OPS"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+
+	require.Len(t, reporter.ChunkErrs, 1)
+	assert.ErrorContains(t, reporter.ChunkErrs[0], "version 2")
+	assert.ErrorContains(t, reporter.ChunkErrs[0], "404")
+
+	var versions []string
+	for _, chunk := range reporter.Chunks {
+		versions = append(versions, chunk.SourceMetadata.GetConfluence().Version)
+	}
+	assert.Equal(t, []string{"3", "1"}, versions)
+}
@@ -98,23 +98,13 @@ type historyEntryInfo struct {
 	index       int
 	entry       v1.History
 	layerDigest string
-	base        string
-	tag         string
-}
-
-type layerInfo struct {
-	digest v1.Hash
-	base   string
-	tag    string
 }
 
 // Chunks emits data over a channel that is decoded and scanned for secrets.
 func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
 	ctx = context.WithValues(ctx, "source_type", s.Type(), "source_name", s.name)
 
-	workers := new(errgroup.Group)
-	workers.SetLimit(s.concurrency)
-
+	reporter := sources.ChanReporter{Ch: chunksChan}
 	for _, image := range s.conn.GetImages() {
 		if common.IsDone(ctx) {
 			return nil
@@ -128,44 +118,8 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ .
 
 		ctx = context.WithValues(ctx, "image", imgInfo.base, "tag", imgInfo.tag)
 
-		ctx.Logger().V(2).Info("scanning image history")
-
-		layers, err := imgInfo.image.Layers()
-		if err != nil {
-			ctx.Logger().Error(err, "error getting image layers")
-			return nil
-		}
-
-		historyEntries, err := getHistoryEntries(ctx, imgInfo, layers)
-		if err != nil {
-			ctx.Logger().Error(err, "error getting image history entries")
-			return nil
-		}
-
-		for _, historyEntry := range historyEntries {
-			if err := s.processHistoryEntry(ctx, historyEntry, chunksChan); err != nil {
-				ctx.Logger().Error(err, "error processing history entry")
-				return nil
-			}
-			dockerHistoryEntriesScanned.WithLabelValues(s.name).Inc()
-		}
-
-		ctx.Logger().V(2).Info("scanning image layers")
-
-		for _, layer := range layers {
-			workers.Go(func() error {
-				if err := s.processLayer(ctx, layer, imgInfo, chunksChan); err != nil {
-					ctx.Logger().Error(err, "error processing layer")
-					return nil
-				}
-				dockerLayersScanned.WithLabelValues(s.name).Inc()
-
-				return nil
-			})
-		}
-
-		if err := workers.Wait(); err != nil {
-			ctx.Logger().Error(err, "error processing layers")
+		if err := s.imageScanner(imgInfo).Scan(ctx, imgInfo.image, reporter); err != nil {
+			ctx.Logger().Error(err, "error scanning image")
 			return nil
 		}
 
@@ -175,6 +129,34 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ .
 	return nil
 }
 
+// imageScanner returns an ImageScanner that attributes chunks to the given
+// image using Docker source metadata.
+func (s *Source) imageScanner(imgInfo imageInfo) *ImageScanner {
+	return &ImageScanner{
+		Chunk: sources.Chunk{
+			SourceType: s.Type(),
+			SourceName: s.name,
+			SourceID:   s.SourceID(),
+			JobID:      s.JobID(),
+			Verify:     s.verify,
+		},
+		Metadata: func(file, layer string) *source_metadatapb.MetaData {
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Docker{
+					Docker: &source_metadatapb.Docker{
+						File:  file,
+						Image: imgInfo.base,
+						Tag:   imgInfo.tag,
+						Layer: layer,
+					},
+				},
+			}
+		},
+		Exclude:     s.isExcluded,
+		Concurrency: s.concurrency,
+	}
+}
+
 // processImage processes an individual image and prepares it for further processing.
 func (s *Source) processImage(ctx context.Context, image string) (imageInfo, error) {
 	var (
@@ -219,10 +201,68 @@ func (s *Source) processImage(ctx context.Context, image string) (imageInfo, err
 	return imgInfo, nil
 }
 
+// ImageScanner scans the configuration history and layers of a single image.
+// It backs the Docker source and is shared with registry sources, such as ECR,
+// that discover and authenticate images themselves.
+type ImageScanner struct {
+	// Chunk is the skeleton of every chunk emitted. Its SourceMetadata is
+	// replaced with the result of Metadata.
+	Chunk sources.Chunk
+	// Metadata returns the source metadata of a file within the image. The
+	// layer digest is empty for history entries that cannot be associated
+	// with a layer.
+	Metadata func(file, layer string) *source_metadatapb.MetaData
+	// Exclude optionally reports whether a file should be skipped.
+	Exclude func(ctx context.Context, file string) bool
+	// Concurrency is the number of layers scanned at once.
+	Concurrency int
+}
+
+// Scan emits chunks for every history entry and layer file of the image.
+// Errors processing an individual layer are logged and do not stop the scan.
+func (s *ImageScanner) Scan(ctx context.Context, img v1.Image, reporter sources.ChunkReporter) error {
+	ctx.Logger().V(2).Info("scanning image history")
+
+	layers, err := img.Layers()
+	if err != nil {
+		return fmt.Errorf("error getting image layers: %w", err)
+	}
+
+	historyEntries, err := getHistoryEntries(ctx, img, layers)
+	if err != nil {
+		return fmt.Errorf("error getting image history entries: %w", err)
+	}
+
+	for _, historyEntry := range historyEntries {
+		if err := s.processHistoryEntry(ctx, historyEntry, reporter); err != nil {
+			return fmt.Errorf("error processing history entry: %w", err)
+		}
+		dockerHistoryEntriesScanned.WithLabelValues(s.Chunk.SourceName).Inc()
+	}
+
+	ctx.Logger().V(2).Info("scanning image layers")
+
+	workers := new(errgroup.Group)
+	workers.SetLimit(max(s.Concurrency, 1))
+	for _, layer := range layers {
+		workers.Go(func() error {
+			if err := s.processLayer(ctx, layer, reporter); err != nil {
+				ctx.Logger().Error(err, "error processing layer")
+				return nil
+			}
+			dockerLayersScanned.WithLabelValues(s.Chunk.SourceName).Inc()
+
+			return nil
+		})
+	}
+
+	return workers.Wait()
+}
+
 // getHistoryEntries collates an image's configuration history together with the
 // corresponding layer digests for any non-empty layers.
-func getHistoryEntries(ctx context.Context, imgInfo imageInfo, layers []v1.Layer) ([]historyEntryInfo, error) {
-	config, err := imgInfo.image.ConfigFile()
+func getHistoryEntries(ctx context.Context, img v1.Image, layers []v1.Layer) ([]historyEntryInfo, error) {
+	config, err := img.ConfigFile()
 	if err != nil {
 		return nil, err
 	}
@@ -233,8 +273,6 @@ func getHistoryEntries(ctx context.Context, imgInfo imageInfo, layers []v1.Layer
 	layerIndex := 0
 	for historyIndex, entry := range history {
 		e := historyEntryInfo{
-			base:  imgInfo.base,
-			tag:   imgInfo.tag,
 			entry: entry,
 			index: historyIndex,
 		}
@@ -266,48 +304,28 @@ func getHistoryEntries(ctx context.Context, imgInfo imageInfo, layers []v1.Layer
 }
 
 // processHistoryEntry processes a history entry from the image configuration metadata.
-func (s *Source) processHistoryEntry(ctx context.Context, historyInfo historyEntryInfo, chunksChan chan *sources.Chunk) error {
+func (s *ImageScanner) processHistoryEntry(ctx context.Context, historyInfo historyEntryInfo, reporter sources.ChunkReporter) error {
 	// Make up an identifier for this entry that is moderately sensible. There is
 	// no file name to use here, so the path tries to be a little descriptive.
 	entryPath := fmt.Sprintf("image-metadata:history:%d:created-by", historyInfo.index)
 
-	chunk := &sources.Chunk{
-		SourceType: s.Type(),
-		SourceName: s.name,
-		SourceID:   s.SourceID(),
-		SourceMetadata: &source_metadatapb.MetaData{
-			Data: &source_metadatapb.MetaData_Docker{
-				Docker: &source_metadatapb.Docker{
-					File:  entryPath,
-					Image: historyInfo.base,
-					Tag:   historyInfo.tag,
-					Layer: historyInfo.layerDigest,
-				},
-			},
-		},
-		Verify: s.verify,
-		Data:   []byte(historyInfo.entry.CreatedBy),
-	}
+	chunk := s.Chunk
+	chunk.SourceMetadata = s.Metadata(entryPath, historyInfo.layerDigest)
+	chunk.Data = []byte(historyInfo.entry.CreatedBy)
 
 	ctx.Logger().V(2).Info("scanning image history entry", "index", historyInfo.index, "layer", historyInfo.layerDigest)
 
-	return common.CancellableWrite(ctx, chunksChan, chunk)
+	return reporter.ChunkOk(ctx, chunk)
 }
 
 // processLayer processes an individual layer of an image.
-func (s *Source) processLayer(ctx context.Context, layer v1.Layer, imgInfo imageInfo, chunksChan chan *sources.Chunk) error {
-	layerInfo := layerInfo{
-		base: imgInfo.base,
-		tag:  imgInfo.tag,
-	}
-
-	var err error
-	layerInfo.digest, err = layer.Digest()
+func (s *ImageScanner) processLayer(ctx context.Context, layer v1.Layer, reporter sources.ChunkReporter) error {
+	digest, err := layer.Digest()
 	if err != nil {
 		return err
 	}
 
-	ctx.Logger().WithValues("layer", layerInfo.digest.String()).V(2).Info("scanning layer")
+	ctx.Logger().WithValues("layer", digest.String()).V(2).Info("scanning layer")
 
 	rc, err := layer.Compressed()
 	if err != nil {
@@ -336,8 +354,8 @@ func (s *Source) processLayer(ctx context.Context, layer v1.Layer, imgInfo image
 			return err
 		}
 
-		info := chunkProcessingInfo{size: header.Size, name: header.Name, reader: tarReader, layer: layerInfo}
-		if err := s.processChunk(ctx, info, chunksChan); err != nil {
+		info := chunkProcessingInfo{size: header.Size, name: header.Name, reader: tarReader, layer: digest.String()}
+		if err := s.processChunk(ctx, info, reporter); err != nil {
 			return err
 		}
 	}
@@ -349,11 +367,11 @@ type chunkProcessingInfo struct {
 	size   int64
 	name   string
 	reader io.Reader
-	layer  layerInfo
+	layer  string
 }
 
 // processChunk processes an individual chunk of a layer.
-func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, chunksChan chan *sources.Chunk) error {
+func (s *ImageScanner) processChunk(ctx context.Context, info chunkProcessingInfo, reporter sources.ChunkReporter) error {
 	const filesizeLimitBytes int64 = 50 * 1024 * 1024 // 50MB
 	if info.size > filesizeLimitBytes {
 		ctx.Logger().V(2).Info("skipping file: size exceeds max allowed", "file", info.name, "size", info.size, "limit", filesizeLimitBytes)
@@ -362,7 +380,7 @@ func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, chu
 
 	// Check if the file should be excluded
 	filePath := "/" + info.name
-	if s.isExcluded(ctx, filePath) {
+	if s.Exclude != nil && s.Exclude(ctx, filePath) {
 		return nil
 	}
 
@@ -375,25 +393,11 @@ func (s *Source) processChunk(ctx context.Context, info chunkProcessingInfo, chu
 			continue
 		}
 
-		chunk := &sources.Chunk{
-			SourceType: s.Type(),
-			SourceName: s.name,
-			SourceID:   s.SourceID(),
-			SourceMetadata: &source_metadatapb.MetaData{
-				Data: &source_metadatapb.MetaData_Docker{
-					Docker: &source_metadatapb.Docker{
-						File:  "/" + info.name,
-						Image: info.layer.base,
-						Tag:   info.layer.tag,
-						Layer: info.layer.digest.String(),
-					},
-				},
-			},
-			Verify: s.verify,
-		}
+		chunk := s.Chunk
+		chunk.SourceMetadata = s.Metadata(filePath, info.layer)
 		chunk.Data = data.Bytes()
 
-		if err := common.CancellableWrite(ctx, chunksChan, chunk); err != nil {
+		if err := reporter.ChunkOk(ctx, chunk); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,229 @@
+package ecr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+const (
+	// targetPrefix is the X-Amz-Target prefix of the ECR JSON 1.1 API.
+	targetPrefix = "AmazonEC2ContainerRegistry_V20150921."
+	signingName  = "ecr"
+
+	// pageLen is the maximum number of results the ECR API returns per page.
+	pageLen = 1000
+)
+
+// client is a minimal, SigV4 signed client for the Amazon ECR API.
+// https://docs.aws.amazon.com/AmazonECR/latest/APIReference/Welcome.html
+type client struct {
+	httpClient  *http.Client
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+
+	// endpoint overrides the regional API endpoint when set.
+	endpoint string
+}
+
+type repository struct {
+	RepositoryName string `json:"repositoryName"`
+	RegistryID     string `json:"registryId"`
+}
+
+type imageID struct {
+	ImageDigest string `json:"imageDigest"`
+	ImageTag    string `json:"imageTag"`
+}
+
+// authorization holds the credentials used to pull from a registry.
+type authorization struct {
+	username string
+	password string
+	// host is the registry host to pull from, as advertised by ECR.
+	host      string
+	expiresAt time.Time
+}
+
+type apiError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// apiURL returns the API endpoint for the region.
+func (c *client) apiURL(region string) string {
+	if c.endpoint != "" {
+		return c.endpoint
+	}
+	if strings.HasPrefix(region, "cn-") {
+		return "https://api.ecr." + region + ".amazonaws.com.cn"
+	}
+	return "https://api.ecr." + region + ".amazonaws.com"
+}
+
+// call invokes an ECR API action in the region and decodes the response into
+// out.
+func (c *client) call(ctx context.Context, region, action string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode ECR request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(region)+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create ECR request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", targetPrefix+action)
+
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), signingName, region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign ECR request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to ECR: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Type != "" {
+			// The type may be qualified, e.g. "com.amazonaws.ecr#RepositoryNotFoundException".
+			errType := apiErr.Type[strings.LastIndex(apiErr.Type, "#")+1:]
+			return fmt.Errorf("%s failed with %s: %s", action, errType, apiErr.Message)
+		}
+		return fmt.Errorf("%s failed with status code %d", action, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode ECR response: %w", err)
+	}
+	return nil
+}
+
+// listRepositories returns every repository in the registry.
+func (c *client) listRepositories(ctx context.Context, reg registry) ([]repository, error) {
+	var (
+		repos     []repository
+		nextToken string
+	)
+	for {
+		in := map[string]any{"registryId": reg.accountID, "maxResults": pageLen}
+		if nextToken != "" {
+			in["nextToken"] = nextToken
+		}
+		var out struct {
+			Repositories []repository `json:"repositories"`
+			NextToken    string       `json:"nextToken"`
+		}
+		if err := c.call(ctx, reg.region, "DescribeRepositories", in, &out); err != nil {
+			return nil, fmt.Errorf("error listing repositories of %s: %w", reg.host, err)
+		}
+		repos = append(repos, out.Repositories...)
+
+		if out.NextToken == "" {
+			return repos, nil
+		}
+		nextToken = out.NextToken
+	}
+}
+
+// listTaggedImages returns the IDs of every tagged image in the repository.
+// An image with several tags is returned once per tag.
+func (c *client) listTaggedImages(ctx context.Context, reg registry, repo string) ([]imageID, error) {
+	var (
+		ids       []imageID
+		nextToken string
+	)
+	for {
+		in := map[string]any{
+			"registryId":     reg.accountID,
+			"repositoryName": repo,
+			"maxResults":     pageLen,
+			"filter":         map[string]string{"tagStatus": "TAGGED"},
+		}
+		if nextToken != "" {
+			in["nextToken"] = nextToken
+		}
+		var out struct {
+			ImageIDs  []imageID `json:"imageIds"`
+			NextToken string    `json:"nextToken"`
+		}
+		if err := c.call(ctx, reg.region, "ListImages", in, &out); err != nil {
+			return nil, fmt.Errorf("error listing images of %s/%s: %w", reg.host, repo, err)
+		}
+		ids = append(ids, out.ImageIDs...)
+
+		if out.NextToken == "" {
+			return ids, nil
+		}
+		nextToken = out.NextToken
+	}
+}
+
+// getAuthorization obtains a token to pull images from the registry.
+func (c *client) getAuthorization(ctx context.Context, reg registry) (authorization, error) {
+	var out struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+			ProxyEndpoint      string  `json:"proxyEndpoint"`
+		} `json:"authorizationData"`
+	}
+	in := map[string]any{"registryIds": []string{reg.accountID}}
+	if err := c.call(ctx, reg.region, "GetAuthorizationToken", in, &out); err != nil {
+		return authorization{}, fmt.Errorf("error getting authorization token for %s: %w", reg.host, err)
+	}
+	if len(out.AuthorizationData) == 0 {
+		return authorization{}, fmt.Errorf("no authorization data returned for %s", reg.host)
+	}
+	data := out.AuthorizationData[0]
+
+	// The token is the base64 encoding of "AWS:<password>".
+	decoded, err := base64.StdEncoding.DecodeString(data.AuthorizationToken)
+	if err != nil {
+		return authorization{}, fmt.Errorf("failed to decode authorization token for %s: %w", reg.host, err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return authorization{}, fmt.Errorf("malformed authorization token for %s", reg.host)
+	}
+
+	host := reg.host
+	if data.ProxyEndpoint != "" {
+		u, err := url.Parse(data.ProxyEndpoint)
+		if err != nil || u.Host == "" {
+			return authorization{}, fmt.Errorf("invalid proxy endpoint %q for %s", data.ProxyEndpoint, reg.host)
+		}
+		host = u.Host
+	}
+
+	return authorization{
+		username:  username,
+		password:  password,
+		host:      host,
+		expiresAt: time.Unix(int64(data.ExpiresAt), 0),
+	}, nil
+}
@@ -0,0 +1,337 @@
+package ecr
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/docker"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_ECR
+
+const unitKindImage = "image"
+
+// registryPattern matches the hostname of a private ECR registry and captures
+// the account ID and region.
+var registryPattern = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// registry is a private ECR registry. There is one per account and region.
+type registry struct {
+	host      string
+	accountID string
+	region    string
+}
+
+// parseRegistry parses a registry hostname, optionally prefixed with a scheme,
+// e.g. "123456789012.dkr.ecr.us-east-1.amazonaws.com".
+func parseRegistry(s string) (registry, error) {
+	host := strings.TrimSuffix(strings.TrimSpace(s), "/")
+	if _, after, ok := strings.Cut(host, "://"); ok {
+		host = after
+	}
+	m := registryPattern.FindStringSubmatch(host)
+	if m == nil {
+		return registry{}, fmt.Errorf("invalid ECR registry %q: expected <account>.dkr.ecr.<region>.amazonaws.com", s)
+	}
+	return registry{host: host, accountID: m[1], region: m[2]}, nil
+}
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	registries map[string]registry
+
+	client *client
+
+	authMu sync.Mutex
+	auths  map[string]authorization
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized ECR source.
+func (s *Source) Init(ctx context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.ECR
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	if len(conn.GetRegistries()) == 0 {
+		return fmt.Errorf("at least one registry is required")
+	}
+	s.registries = make(map[string]registry, len(conn.GetRegistries()))
+	for _, r := range conn.GetRegistries() {
+		reg, err := parseRegistry(r)
+		if err != nil {
+			return err
+		}
+		s.registries[reg.host] = reg
+	}
+
+	endpoint := strings.TrimRight(conn.GetEndpoint(), "/")
+	if endpoint != "" {
+		if _, err := url.ParseRequestURI(endpoint); err != nil {
+			return fmt.Errorf("invalid ECR endpoint %q: %w", endpoint, err)
+		}
+	}
+
+	var credsProvider aws.CredentialsProvider
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.ECR_AccessKey:
+		credsProvider = credentials.NewStaticCredentialsProvider(cred.AccessKey.GetKey(), cred.AccessKey.GetSecret(), "")
+		log.RedactGlobally(cred.AccessKey.GetSecret())
+	default:
+		// Follow the AWS SDK's normal logic to pick up credentials from the
+		// environment, the shared credentials file, etc.
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("error loading AWS config: %w", err)
+		}
+		credsProvider = cfg.Credentials
+	}
+
+	s.client = &client{
+		httpClient:  common.RetryableHTTPClientTimeout(60),
+		credentials: credsProvider,
+		signer:      v4.NewSigner(),
+		endpoint:    endpoint,
+	}
+	s.auths = make(map[string]authorization)
+	return nil
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var units []sources.SourceUnit
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	var scanned int64
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, unit := range units {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			image, _ := unit.SourceUnitID()
+			s.SetProgressComplete(i, len(units), fmt.Sprintf("Image: %s", image), "")
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+				return nil
+			}
+			ctx.Logger().V(2).Info("completed scan", "image", image, "num", atomic.AddInt64(&scanned, 1), "total", len(units))
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(units), len(units), "Completed ECR scan", "")
+
+	return nil
+}
+
+// Enumerate reports one unit per tagged image in every repository of the
+// configured registries. Images with several tags are only reported once,
+// under their first tag in lexical order.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	hosts := make([]string, 0, len(s.registries))
+	for host := range s.registries {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		reg := s.registries[host]
+		repos, err := s.client.listRepositories(ctx, reg)
+		if err != nil {
+			if err := reporter.UnitErr(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		sort.Slice(repos, func(i, j int) bool { return repos[i].RepositoryName < repos[j].RepositoryName })
+
+		for _, repo := range repos {
+			ids, err := s.client.listTaggedImages(ctx, reg, repo.RepositoryName)
+			if err != nil {
+				if err := reporter.UnitErr(ctx, err); err != nil {
+					return err
+				}
+				continue
+			}
+			ctx.Logger().V(2).Info("enumerated repository images", "registry", host, "repository", repo.RepositoryName, "count", len(ids))
+
+			sort.Slice(ids, func(i, j int) bool { return ids[i].ImageTag < ids[j].ImageTag })
+			seen := make(map[string]struct{}, len(ids))
+			for _, id := range ids {
+				if _, ok := seen[id.ImageDigest]; ok {
+					continue
+				}
+				seen[id.ImageDigest] = struct{}{}
+
+				unit := sources.CommonSourceUnit{Kind: unitKindImage, ID: host + "/" + repo.RepositoryName + ":" + id.ImageTag}
+				if err := reporter.UnitOk(ctx, unit); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ChunkUnit pulls the image and scans its history and layers.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	id, _ := unit.SourceUnitID()
+	host, repoAndTag, _ := strings.Cut(id, "/")
+	repo, tag, ok := strings.Cut(repoAndTag, ":")
+	reg, known := s.registries[host]
+	if !ok || !known {
+		return fmt.Errorf("invalid ECR image %q", id)
+	}
+	ctx = context.WithValues(ctx, "registry", host, "repository", repo, "tag", tag)
+
+	auth, err := s.authorization(ctx, reg)
+	if err != nil {
+		return err
+	}
+
+	ref, err := name.NewTag(auth.host + "/" + repo + ":" + tag)
+	if err != nil {
+		return fmt.Errorf("invalid image reference for %s: %w", id, err)
+	}
+	img, err := remote.Image(ref,
+		remote.WithContext(ctx),
+		remote.WithAuth(&authn.Basic{Username: auth.username, Password: auth.password}),
+	)
+	if err != nil {
+		return fmt.Errorf("error pulling %s: %w", id, err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return fmt.Errorf("error getting digest of %s: %w", id, err)
+	}
+
+	scanner := &docker.ImageScanner{
+		Chunk: sources.Chunk{
+			SourceType: s.Type(),
+			SourceName: s.name,
+			SourceID:   s.sourceID,
+			JobID:      s.jobID,
+			Verify:     s.verify,
+		},
+		Metadata: func(file, layer string) *source_metadatapb.MetaData {
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Ecr{
+					Ecr: &source_metadatapb.ECR{
+						File:       file,
+						Layer:      layer,
+						Image:      host + "/" + repo,
+						Registry:   host,
+						Region:     reg.region,
+						Repository: repo,
+						Tag:        tag,
+						Digest:     digest.String(),
+						Link:       consoleLink(reg, repo, digest.String()),
+					},
+				},
+			}
+		},
+		// Images are already scanned concurrently, so scan their layers one
+		// at a time.
+		Concurrency: 1,
+	}
+	if err := scanner.Scan(ctx, img, reporter); err != nil {
+		return fmt.Errorf("error scanning %s: %w", id, err)
+	}
+	ecrImagesScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// authorization returns the cached pull credentials for the registry,
+// requesting a new token if there are none or they are about to expire.
+func (s *Source) authorization(ctx context.Context, reg registry) (authorization, error) {
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+
+	if auth, ok := s.auths[reg.host]; ok && time.Until(auth.expiresAt) > 5*time.Minute {
+		return auth, nil
+	}
+	auth, err := s.client.getAuthorization(ctx, reg)
+	if err != nil {
+		return auth, err
+	}
+	log.RedactGlobally(auth.password)
+	s.auths[reg.host] = auth
+	return auth, nil
+}
+
+// consoleLink returns the AWS console URL of the image.
+func consoleLink(reg registry, repo, digest string) string {
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/ecr/repositories/private/%s/%s/_/image/%s/details?region=%s",
+		reg.region, reg.accountID, repo, digest, reg.region)
+}
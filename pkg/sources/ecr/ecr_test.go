@@ -0,0 +1,333 @@
+package ecr
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+const testRegistry = "123456789012.dkr.ecr.us-west-2.amazonaws.com"
+
+// writeJSON writes v with the content type of the AWS JSON protocol.
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	sourcestest.WriteJSON(t, w, v)
+}
+
+// pushImage pushes a single layer image containing the file to the registry
+// under each of the tags.
+func pushImage(t *testing.T, host, repo, file, content string, tags ...string) v1.Hash {
+	t.Helper()
+	layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		if err := tw.WriteHeader(&tar.Header{Name: file, Mode: 0644, Size: int64(len(content))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			return nil, err
+		}
+		if err := tw.Close(); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(&buf), nil
+	})
+	require.NoError(t, err)
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:   layer,
+		History: v1.History{CreatedBy: "COPY " + file + " /"},
+	})
+	require.NoError(t, err)
+
+	for _, tag := range tags {
+		ref, err := name.NewTag(host + "/" + repo + ":" + tag)
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, img))
+	}
+	digest, err := img.Digest()
+	require.NoError(t, err)
+	return digest
+}
+
+// newServers starts a registry and a fake ECR API advertising it.
+func newServers(t *testing.T) (api, reg *httptest.Server) {
+	t.Helper()
+	reg = httptest.NewServer(ggcrregistry.New())
+	t.Cleanup(reg.Close)
+
+	api = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIAEXAMPLE/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-west-2/ecr/aws4_request")
+
+		var in map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+
+		switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix) {
+		case "GetAuthorizationToken":
+			assert.Equal(t, []any{"123456789012"}, in["registryIds"])
+			writeJSON(t, w, map[string]any{
+				"authorizationData": []any{map[string]any{
+					"authorizationToken": base64.StdEncoding.EncodeToString([]byte("AWS:token")),
+					"expiresAt":          4102444800,
+					"proxyEndpoint":      reg.URL,
+				}},
+			})
+		case "DescribeRepositories":
+			assert.Equal(t, "123456789012", in["registryId"])
+			if in["nextToken"] == nil {
+				writeJSON(t, w, map[string]any{
+					"repositories": []any{map[string]any{"repositoryName": "team/api"}},
+					"nextToken":    "page2",
+				})
+				return
+			}
+			writeJSON(t, w, map[string]any{
+				"repositories": []any{map[string]any{"repositoryName": "app"}},
+			})
+		case "ListImages":
+			switch in["repositoryName"] {
+			case "app":
+				writeJSON(t, w, map[string]any{"imageIds": []any{
+					map[string]any{"imageDigest": "sha256:bbb", "imageTag": "v2"},
+					map[string]any{"imageDigest": "sha256:aaa", "imageTag": "v1"},
+					map[string]any{"imageDigest": "sha256:aaa", "imageTag": "latest"},
+				}})
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSON(t, w, map[string]any{
+					"__type":  "com.amazonaws.ecr#RepositoryNotFoundException",
+					"message": "repository does not exist",
+				})
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(api.Close)
+	return api, reg
+}
+
+func initSource(t *testing.T, api *httptest.Server) *Source {
+	t.Helper()
+	s := &Source{}
+	sourcestest.InitSource(t, s, &sourcespb.ECR{
+		Credential: &sourcespb.ECR_AccessKey{AccessKey: &credentialspb.KeySecret{Key: "AKIAEXAMPLE", Secret: "secret"}},
+		Registries: []string{testRegistry},
+		Endpoint:   api.URL,
+	})
+	return s
+}
+
+func TestParseRegistry(t *testing.T) {
+	reg, err := parseRegistry("https://" + testRegistry + "/")
+	require.NoError(t, err)
+	assert.Equal(t, registry{host: testRegistry, accountID: "123456789012", region: "us-west-2"}, reg)
+
+	reg, err = parseRegistry("123456789012.dkr.ecr.cn-north-1.amazonaws.com.cn")
+	require.NoError(t, err)
+	assert.Equal(t, "cn-north-1", reg.region)
+
+	_, err = parseRegistry("docker.io")
+	assert.Error(t, err)
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	api, _ := newServers(t)
+	s := initSource(t, api)
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	require.Len(t, reporter.UnitErrs, 1)
+	assert.ErrorContains(t, reporter.UnitErrs[0], "RepositoryNotFoundException")
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{Kind: unitKindImage, ID: testRegistry + "/app:latest"},
+		sources.CommonSourceUnit{Kind: unitKindImage, ID: testRegistry + "/app:v2"},
+	}, reporter.Units)
+}
+
+func TestSource_ChunkUnit(t *testing.T) {
+	api, reg := newServers(t)
+	host := strings.TrimPrefix(reg.URL, "http://")
+	digest := pushImage(t, host, "app", "config.env", "AWS_SECRET_ACCESS_KEY=hunter2\n", "v1")
+	s := initSource(t, api)
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindImage, ID: testRegistry + "/app:v1"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 2)
+
+	history := reporter.Chunks[0].SourceMetadata.GetEcr()
+	require.NotNil(t, history)
+	assert.Equal(t, "image-metadata:history:0:created-by", history.File)
+	assert.Equal(t, "COPY config.env /", string(reporter.Chunks[0].Data))
+
+	meta := reporter.Chunks[1].SourceMetadata.GetEcr()
+	require.NotNil(t, meta)
+	assert.Equal(t, "/config.env", meta.File)
+	assert.Equal(t, testRegistry, meta.Registry)
+	assert.Equal(t, "us-west-2", meta.Region)
+	assert.Equal(t, "app", meta.Repository)
+	assert.Equal(t, "v1", meta.Tag)
+	assert.Equal(t, digest.String(), meta.Digest)
+	assert.Equal(t, history.Layer, meta.Layer)
+	assert.Contains(t, meta.Link, "/ecr/repositories/private/123456789012/app/_/image/"+digest.String())
+	assert.Contains(t, string(reporter.Chunks[1].Data), "AWS_SECRET_ACCESS_KEY=hunter2")
+}
+
+func TestSource_ChunkUnit_UnknownRegistry(t *testing.T) {
+	api, _ := newServers(t)
+	s := initSource(t, api)
+
+	unit := sources.CommonSourceUnit{Kind: unitKindImage, ID: "210987654321.dkr.ecr.us-west-2.amazonaws.com/app:v1"}
+	assert.Error(t, s.ChunkUnit(context.Background(), unit, &sourcestest.TestReporter{}))
+}
+
+func TestSource_Enumerate_APIErrors(t *testing.T) {
+	tests := map[string]struct {
+		status  int
+		body    any
+		wantErr string
+	}{
+		"access denied": {
+			status:  http.StatusBadRequest,
+			body:    map[string]any{"__type": "AccessDeniedException", "message": "not authorized to perform: ecr:DescribeRepositories"},
+			wantErr: "DescribeRepositories failed with AccessDeniedException: not authorized",
+		},
+		"invalid signature": {
+			status:  http.StatusForbidden,
+			body:    map[string]any{"__type": "com.amazon.coral.service#InvalidSignatureException", "message": "signature mismatch"},
+			wantErr: "DescribeRepositories failed with InvalidSignatureException",
+		},
+		"no error body": {
+			status:  http.StatusForbidden,
+			wantErr: "DescribeRepositories failed with status code 403",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+				w.WriteHeader(tt.status)
+				if tt.body != nil {
+					sourcestest.WriteJSON(t, w, tt.body)
+				}
+			}))
+			t.Cleanup(api.Close)
+			s := initSource(t, api)
+
+			reporter := sourcestest.TestReporter{}
+			require.NoError(t, s.Enumerate(context.Background(), &reporter))
+			assert.Empty(t, reporter.Units)
+			require.Len(t, reporter.UnitErrs, 1)
+			assert.ErrorContains(t, reporter.UnitErrs[0], testRegistry)
+			assert.ErrorContains(t, reporter.UnitErrs[0], tt.wantErr)
+		})
+	}
+}
+
+func TestSource_Enumerate_ImagePages(t *testing.T) {
+	var tokens []any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix) {
+		case "DescribeRepositories":
+			writeJSON(t, w, map[string]any{"repositories": []any{map[string]any{"repositoryName": "app"}}})
+		case "ListImages":
+			assert.Equal(t, map[string]any{"tagStatus": "TAGGED"}, in["filter"])
+			tokens = append(tokens, in["nextToken"])
+			if in["nextToken"] == nil {
+				writeJSON(t, w, map[string]any{
+					"imageIds":  []any{map[string]any{"imageDigest": "sha256:aaa", "imageTag": "v1"}},
+					"nextToken": "page2",
+				})
+				return
+			}
+			// A tag of an image already listed on the previous page.
+			writeJSON(t, w, map[string]any{"imageIds": []any{
+				map[string]any{"imageDigest": "sha256:aaa", "imageTag": "stable"},
+				map[string]any{"imageDigest": "sha256:bbb", "imageTag": "v2"},
+			}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(api.Close)
+	s := initSource(t, api)
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []any{nil, "page2"}, tokens)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{Kind: unitKindImage, ID: testRegistry + "/app:stable"},
+		sources.CommonSourceUnit{Kind: unitKindImage, ID: testRegistry + "/app:v2"},
+	}, reporter.Units)
+}
+
+func TestSource_ChunkUnit_AuthorizationRefresh(t *testing.T) {
+	reg := httptest.NewServer(ggcrregistry.New())
+	t.Cleanup(reg.Close)
+	pushImage(t, strings.TrimPrefix(reg.URL, "http://"), "app", "app.env", "TOKEN=x\n", "v1")
+
+	tests := map[string]struct {
+		expiresIn time.Duration
+		want      int
+	}{
+		"valid token is reused":       {expiresIn: time.Hour, want: 1},
+		"expiring token is refreshed": {expiresIn: time.Minute, want: 2},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix) != "GetAuthorizationToken" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				calls++
+				writeJSON(t, w, map[string]any{"authorizationData": []any{map[string]any{
+					"authorizationToken": base64.StdEncoding.EncodeToString([]byte("AWS:token")),
+					"expiresAt":          time.Now().Add(tt.expiresIn).Unix(),
+					"proxyEndpoint":      reg.URL,
+				}}})
+			}))
+			t.Cleanup(api.Close)
+			s := initSource(t, api)
+
+			unit := sources.CommonSourceUnit{Kind: unitKindImage, ID: testRegistry + "/app:v1"}
+			for range 2 {
+				reporter := sourcestest.TestReporter{}
+				require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+				require.Empty(t, reporter.ChunkErrs)
+				require.NotEmpty(t, reporter.Chunks)
+			}
+			assert.Equal(t, tt.want, calls)
+		})
+	}
+}
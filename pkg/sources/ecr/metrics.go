@@ -0,0 +1,16 @@
+package ecr
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var ecrImagesScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: common.MetricsNamespace,
+	Subsystem: common.MetricsSubsystem,
+	Name:      "ecr_images_scanned",
+	Help:      "Total number of ECR images scanned.",
+},
+	[]string{"source_name"})
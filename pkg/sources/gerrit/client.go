@@ -0,0 +1,133 @@
+package gerrit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// projectPageLimit is the number of projects requested per page.
+const projectPageLimit = 500
+
+// xssiPrefix is prepended by Gerrit to every JSON response to prevent XSSI.
+// https://gerrit-review.googlesource.com/Documentation/rest-api.html#output
+var xssiPrefix = []byte(")]}'")
+
+// client is a minimal Gerrit REST client.
+type client struct {
+	httpClient *http.Client
+	// baseURL is the Gerrit endpoint. Authenticated requests are made against
+	// the /a/ prefix.
+	baseURL   string
+	authorize func(req *http.Request)
+}
+
+type projectInfo struct {
+	ID           string `json:"id"`
+	State        string `json:"state"`
+	MoreProjects bool   `json:"_more_projects"`
+}
+
+// apiURL returns the REST URL for path, adding the /a/ prefix when the
+// client is authenticated.
+func (c *client) apiURL(path string) string {
+	if c.authorize != nil {
+		return c.baseURL + "/a" + path
+	}
+	return c.baseURL + path
+}
+
+// getJSON performs a GET request against the provided URL and decodes the
+// JSON response into target.
+func (c *client) getJSON(ctx context.Context, reqURL string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Gerrit API request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request to Gerrit API: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("invalid Gerrit credentials (status %d)", resp.StatusCode)
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("insufficient permissions for %q (status %d)", req.URL.Path, resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("unexpected status code %d for %q", resp.StatusCode, req.URL.Path)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	if prefix, err := body.Peek(len(xssiPrefix)); err == nil && bytes.Equal(prefix, xssiPrefix) {
+		_, _ = body.Discard(len(xssiPrefix))
+	}
+	if err := json.NewDecoder(body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Gerrit API response: %w", err)
+	}
+	return nil
+}
+
+// currentAccount checks that the configured credentials are accepted.
+func (c *client) currentAccount(ctx context.Context) error {
+	var account struct {
+		ID int64 `json:"_account_id"`
+	}
+	return c.getJSON(ctx, c.apiURL("/accounts/self"), &account)
+}
+
+// getProject checks that the project exists and is visible.
+func (c *client) getProject(ctx context.Context, name string) error {
+	var project projectInfo
+	if err := c.getJSON(ctx, c.apiURL("/projects/"+url.PathEscape(name)), &project); err != nil {
+		return fmt.Errorf("error getting project %q: %w", name, err)
+	}
+	return nil
+}
+
+// listProjects returns the sorted names of every project visible to the
+// client, excluding hidden projects.
+func (c *client) listProjects(ctx context.Context) ([]string, error) {
+	var names []string
+	for skip := 0; ; skip += projectPageLimit {
+		query := url.Values{
+			"n": []string{strconv.Itoa(projectPageLimit)},
+			"S": []string{strconv.Itoa(skip)},
+		}
+		var page map[string]projectInfo
+		if err := c.getJSON(ctx, c.apiURL("/projects/?"+query.Encode()), &page); err != nil {
+			return nil, fmt.Errorf("error listing projects: %w", err)
+		}
+
+		more := false
+		for name, project := range page {
+			more = more || project.MoreProjects
+			if project.State == "HIDDEN" {
+				continue
+			}
+			names = append(names, name)
+		}
+		if !more || len(page) == 0 {
+			break
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,431 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/feature"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/giturl"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_GERRIT
+
+const (
+	unitKindProject = "project"
+
+	// changesRefspec fetches every patchset of every change, including
+	// unmerged and abandoned ones, which are not reachable from any branch.
+	// https://gerrit-review.googlesource.com/Documentation/concept-refs-for-namespaces.html
+	changesRefspec   = "+refs/changes/*:refs/remotes/origin/changes/*"
+	changesRefPrefix = "refs/remotes/origin/changes/"
+)
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	endpoint   string
+	authMethod string
+	user       string
+	password   string
+	projects   []string
+
+	client       *client
+	git          *git.Git
+	scanOptions  *git.ScanOptions
+	projectCache projectCache
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// patchset identifies the change and patchset a commit was uploaded as.
+type patchset struct {
+	change   int64
+	patchset int64
+}
+
+// projectRefs holds the patchsets of a cloned project, keyed by commit hash.
+type projectRefs struct {
+	name      string
+	patchsets map[string]patchset
+}
+
+type projectCache struct {
+	mu    sync.RWMutex
+	cache map[string]projectRefs
+}
+
+func (p *projectCache) put(repoURL string, refs projectRefs) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache[repoURL] = refs
+}
+
+func (p *projectCache) get(repoURL string) (projectRefs, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	refs, ok := p.cache[repoURL]
+	return refs, ok
+}
+
+func (p *projectCache) delete(repoURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.cache, repoURL)
+}
+
+// Init returns an initialized Gerrit source.
+func (s *Source) Init(ctx context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+	s.projectCache = projectCache{cache: make(map[string]projectRefs)}
+
+	if err := git.CmdCheck(); err != nil {
+		return err
+	}
+
+	var conn sourcespb.Gerrit
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.endpoint = strings.TrimRight(conn.GetEndpoint(), "/")
+	if s.endpoint == "" {
+		return fmt.Errorf("an endpoint is required for Gerrit")
+	}
+	if _, err := url.ParseRequestURI(s.endpoint); err != nil {
+		return fmt.Errorf("invalid Gerrit endpoint %q: %w", s.endpoint, err)
+	}
+	s.projects = conn.GetProjects()
+
+	var authorize func(req *http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Gerrit_BasicAuth:
+		// Gerrit expects the HTTP password generated in the user settings,
+		// which is not the same as the account password.
+		s.authMethod = "BASIC_AUTH"
+		s.user = cred.BasicAuth.GetUsername()
+		s.password = cred.BasicAuth.GetPassword()
+		if s.user == "" || s.password == "" {
+			return fmt.Errorf("invalid configuration given for source %q (%s): empty credential", name, s.Type().String())
+		}
+		log.RedactGlobally(s.password)
+		authorize = func(req *http.Request) { req.SetBasicAuth(s.user, s.password) }
+	case *sourcespb.Gerrit_Unauthenticated:
+		s.authMethod = "UNAUTHENTICATED"
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+
+	s.client = &client{
+		httpClient: common.RetryableHTTPClientTimeout(60),
+		baseURL:    s.endpoint,
+		authorize:  authorize,
+	}
+
+	cfg := &git.Config{
+		SourceName:   s.name,
+		JobID:        s.jobID,
+		SourceID:     s.sourceID,
+		SourceType:   s.Type(),
+		Verify:       s.verify,
+		SkipBinaries: conn.GetSkipBinaries(),
+		SkipArchives: conn.GetSkipArchives(),
+		Concurrency:  concurrency,
+		SourceMetadataFunc: func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
+			refs, ok := s.projectCache.get(repository)
+			if !ok {
+				refs.name = s.projectName(repository)
+			}
+			ps := refs.patchsets[commit]
+			return &source_metadatapb.MetaData{
+				Data: &source_metadatapb.MetaData_Gerrit{
+					Gerrit: &source_metadatapb.Gerrit{
+						Commit:       sanitizer.UTF8(commit),
+						File:         sanitizer.UTF8(file),
+						Email:        sanitizer.UTF8(email),
+						Project:      sanitizer.UTF8(refs.name),
+						Timestamp:    sanitizer.UTF8(timestamp),
+						Line:         line,
+						ChangeNumber: ps.change,
+						Patchset:     ps.patchset,
+					},
+				},
+			}
+		},
+	}
+	s.git = git.NewGit(cfg)
+
+	return nil
+}
+
+// WithScanOptions sets the git scan options used for every project.
+func (s *Source) WithScanOptions(scanOptions *git.ScanOptions) {
+	s.scanOptions = scanOptions
+}
+
+// cloneURL returns the HTTP clone URL of the project. Authenticated clones
+// use the /a/ prefix, like the REST API.
+func (s *Source) cloneURL(project string) string {
+	if s.client.authorize != nil {
+		return s.endpoint + "/a/" + project
+	}
+	return s.endpoint + "/" + project
+}
+
+// projectName is the inverse of cloneURL.
+func (s *Source) projectName(repoURL string) string {
+	name := strings.TrimPrefix(repoURL, s.endpoint)
+	if s.client.authorize != nil {
+		name = strings.TrimPrefix(name, "/a")
+	}
+	return strings.TrimPrefix(name, "/")
+}
+
+// Validate checks that the configured credentials are accepted and that any
+// explicitly configured projects are visible.
+func (s *Source) Validate(ctx context.Context) []error {
+	if s.client.authorize != nil {
+		if err := s.client.currentAccount(ctx); err != nil {
+			return []error{fmt.Errorf("gerrit authentication failed using method %v: %w", s.authMethod, err)}
+		}
+	}
+
+	projects, errs := normalizeProjects(s.projects)
+	for _, project := range projects {
+		if err := s.client.getProject(ctx, project); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var units []sources.SourceUnit
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	var scanned int64
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, unit := range units {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			project, _ := unit.SourceUnitID()
+			s.SetProgressComplete(i, len(units), fmt.Sprintf("Project: %s", project), "")
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+				return nil
+			}
+			ctx.Logger().V(2).Info("completed scan", "project", project, "num", atomic.AddInt64(&scanned, 1), "total", len(units))
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(units), len(units), "Completed Gerrit scan", "")
+
+	return nil
+}
+
+// Enumerate reports all Gerrit projects to be scanned to the reporter. If
+// none are configured, it lists every project visible to the credentials.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	projects, errs := normalizeProjects(s.projects)
+	for _, projectErr := range errs {
+		if err := reporter.UnitErr(ctx, projectErr); err != nil {
+			return err
+		}
+	}
+	if len(errs) > 0 && len(projects) == 0 {
+		return fmt.Errorf("all configured projects had validation issues")
+	}
+
+	if len(projects) == 0 {
+		var err error
+		if projects, err = s.client.listProjects(ctx); err != nil {
+			return err
+		}
+		ctx.Logger().V(2).Info("enumerated projects", "count", len(projects))
+	}
+
+	gerritProjectsEnumerated.WithLabelValues(s.name).Set(0)
+	for _, project := range projects {
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{Kind: unitKindProject, ID: project}); err != nil {
+			return err
+		}
+		gerritProjectsEnumerated.WithLabelValues(s.name).Inc()
+	}
+	return nil
+}
+
+// ChunkUnit clones the given Gerrit project along with every change ref and
+// scans it.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	project, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "repo", project)
+
+	var (
+		cloneURL = s.cloneURL(project)
+		args     []string
+		path     string
+		repo     *gogit.Repository
+		err      error
+	)
+	// Unless additional refs are skipped, every clone already fetches refs/*
+	// (and so refs/changes/*) into the same namespace; fetching them twice is
+	// rejected by git.
+	if feature.SkipAdditionalRefs.Load() {
+		args = []string{"-c", "remote.origin.fetch=" + changesRefspec}
+	}
+	if s.client.authorize != nil {
+		path, repo, err = git.CloneRepoUsingToken(ctx, s.password, cloneURL, "", s.user, false, args...)
+	} else {
+		path, repo, err = git.CloneRepoUsingUnauthenticated(ctx, cloneURL, "", args...)
+	}
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(path)
+
+	patchsets, err := changePatchsets(repo)
+	if err != nil {
+		ctx.Logger().V(1).Info("error reading change refs", "error", err)
+	}
+	gerritPatchsetsFetched.WithLabelValues(s.name).Add(float64(len(patchsets)))
+
+	repoURL := git.GetSafeRemoteURL(repo, "origin")
+	s.projectCache.put(repoURL, projectRefs{name: project, patchsets: patchsets})
+	defer s.projectCache.delete(repoURL)
+
+	if err := s.git.ScanRepo(ctx, repo, path, s.scanOptions, reporter); err != nil {
+		return err
+	}
+	gerritProjectsScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
+
+// changePatchsets maps the commit of every fetched patchset to its change and
+// patchset number. A commit that was uploaded more than once is attributed to
+// its earliest patchset.
+func changePatchsets(repo *gogit.Repository) (map[string]patchset, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	defer refs.Close()
+
+	patchsets := make(map[string]patchset)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		ps, ok := parseChangeRef(ref.Name().String())
+		if !ok || ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		commit := ref.Hash().String()
+		if existing, ok := patchsets[commit]; ok && (existing.change < ps.change ||
+			(existing.change == ps.change && existing.patchset < ps.patchset)) {
+			return nil
+		}
+		patchsets[commit] = ps
+		return nil
+	})
+	return patchsets, err
+}
+
+// parseChangeRef parses a fetched change ref of the form
+// refs/remotes/origin/changes/<last two digits>/<change>/<patchset>. Other
+// refs under changes/, such as the NoteDb "meta" ref, are not patchsets.
+func parseChangeRef(ref string) (patchset, bool) {
+	rest, ok := strings.CutPrefix(ref, changesRefPrefix)
+	if !ok {
+		return patchset{}, false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 3 {
+		return patchset{}, false
+	}
+	change, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return patchset{}, false
+	}
+	ps, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return patchset{}, false
+	}
+	return patchset{change: change, patchset: ps}, true
+}
+
+func normalizeProjects(projects []string) ([]string, []error) {
+	valid := make([]string, 0, len(projects))
+	var errs []error
+	for _, p := range projects {
+		project, err := giturl.NormalizeGerritProject(p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to normalize gerrit project %q: %w", p, err))
+			continue
+		}
+		valid = append(valid, project)
+	}
+	return valid, errs
+}
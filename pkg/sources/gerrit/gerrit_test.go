@@ -0,0 +1,247 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/feature"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// writeJSON writes v with the XSSI prefix Gerrit puts before every JSON
+// response.
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(")]}'\n"))
+	sourcestest.WriteJSON(t, w, v)
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/a/accounts/self":
+			writeJSON(t, w, map[string]any{"_account_id": 1000000})
+		case "/a/projects/":
+			if r.URL.Query().Get("S") == "0" {
+				writeJSON(t, w, map[string]any{
+					"platform/build": map[string]any{"id": "platform%2Fbuild", "state": "ACTIVE"},
+					"All-Projects":   map[string]any{"id": "All-Projects", "state": "ACTIVE", "_more_projects": true},
+				})
+				return
+			}
+			writeJSON(t, w, map[string]any{
+				"archive": map[string]any{"id": "archive", "state": "READ_ONLY"},
+				"secret":  map[string]any{"id": "secret", "state": "HIDDEN"},
+			})
+		case "/a/projects/platform/build":
+			writeJSON(t, w, map[string]any{"id": "platform%2Fbuild"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func initSource(t *testing.T, conn *sourcespb.Gerrit) *Source {
+	t.Helper()
+	if conn.Credential == nil {
+		conn.Credential = &sourcespb.Gerrit_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{Username: "admin", Password: "secret"},
+		}
+	}
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	return s
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	srv := newServer(t)
+
+	tests := map[string]struct {
+		conn *sourcespb.Gerrit
+		want []string
+	}{
+		"all projects": {
+			conn: &sourcespb.Gerrit{},
+			want: []string{"All-Projects", "archive", "platform/build"},
+		},
+		"configured projects": {
+			conn: &sourcespb.Gerrit{Projects: []string{"/platform/build.git"}},
+			want: []string{"platform/build"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.conn.Endpoint = srv.URL
+			s := initSource(t, tt.conn)
+			assert.Empty(t, s.Validate(context.Background()))
+
+			reporter := sourcestest.TestReporter{}
+			require.NoError(t, s.Enumerate(context.Background(), &reporter))
+			assert.Empty(t, reporter.UnitErrs)
+
+			var got []string
+			for _, unit := range reporter.Units {
+				id, kind := unit.SourceUnitID()
+				assert.Equal(t, sources.SourceUnitKind(unitKindProject), kind)
+				got = append(got, id)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSource_Validate_BadCredentials(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, &sourcespb.Gerrit{
+		Endpoint: srv.URL,
+		Credential: &sourcespb.Gerrit_BasicAuth{
+			BasicAuth: &credentialspb.BasicAuth{Username: "admin", Password: "wrong"},
+		},
+	})
+	assert.NotEmpty(t, s.Validate(context.Background()))
+}
+
+func TestSource_Enumerate_Anonymous(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/projects/":
+			// Anonymous requests don't use the /a/ prefix. Responses without
+			// the XSSI prefix are accepted too.
+			skip := r.URL.Query().Get("S")
+			pages = append(pages, skip)
+			if skip == "0" {
+				sourcestest.WriteJSON(t, w, map[string]any{"tools": map[string]any{"_more_projects": true}})
+				return
+			}
+			// The last page can be empty even when the previous one had more.
+			sourcestest.WriteJSON(t, w, map[string]any{})
+		case "/projects/private":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	s := initSource(t, &sourcespb.Gerrit{
+		Endpoint:   srv.URL,
+		Credential: &sourcespb.Gerrit_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}},
+	})
+	assert.Equal(t, srv.URL+"/tools", s.cloneURL("tools"))
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Equal(t, []sources.SourceUnit{sources.CommonSourceUnit{Kind: unitKindProject, ID: "tools"}}, reporter.Units)
+	assert.Equal(t, []string{"0", fmt.Sprint(projectPageLimit)}, pages)
+
+	s = initSource(t, &sourcespb.Gerrit{
+		Endpoint:   srv.URL,
+		Projects:   []string{"private"},
+		Credential: &sourcespb.Gerrit_Unauthenticated{Unauthenticated: &credentialspb.Unauthenticated{}},
+	})
+	errs := s.Validate(context.Background())
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "insufficient permissions")
+}
+
+func TestSource_ChunkUnit_Patchsets(t *testing.T) {
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=dev", "GIT_AUTHOR_EMAIL=dev@example.com",
+			"GIT_COMMITTER_NAME=dev", "GIT_COMMITTER_EMAIL=dev@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	run("init", "--quiet", "--initial-branch=master", work)
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README"), []byte("hello\n"), 0644))
+	run("-C", work, "add", ".")
+	run("-C", work, "commit", "--quiet", "-m", "initial")
+	require.NoError(t, os.WriteFile(filepath.Join(work, "config.env"), []byte("PASSWORD=hunter2\n"), 0644))
+	run("-C", work, "add", ".")
+	run("-C", work, "commit", "--quiet", "-m", "add config")
+
+	// Only the review ref points at the second commit, like an abandoned change.
+	project := filepath.Join(dir, "tools")
+	run("clone", "--quiet", "--bare", work, project)
+	run("-C", project, "update-ref", "refs/changes/45/12345/2", "master")
+	run("-C", project, "update-ref", "refs/heads/master", "master~1")
+
+	for _, skipAdditionalRefs := range []bool{false, true} {
+		t.Run(fmt.Sprintf("skip additional refs %t", skipAdditionalRefs), func(t *testing.T) {
+			feature.SkipAdditionalRefs.Store(skipAdditionalRefs)
+			t.Cleanup(func() { feature.SkipAdditionalRefs.Store(false) })
+
+			s := initSource(t, &sourcespb.Gerrit{
+				Endpoint:   dir,
+				Credential: &sourcespb.Gerrit_Unauthenticated{},
+			})
+
+			reporter := sourcestest.TestReporter{}
+			unit := sources.CommonSourceUnit{Kind: unitKindProject, ID: "tools"}
+			require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+			require.Empty(t, reporter.ChunkErrs)
+
+			var found bool
+			for _, chunk := range reporter.Chunks {
+				meta := chunk.SourceMetadata.GetGerrit()
+				require.NotNil(t, meta)
+				assert.Equal(t, "tools", meta.Project)
+				if meta.File != "config.env" {
+					continue
+				}
+				found = true
+				assert.Equal(t, int64(12345), meta.ChangeNumber)
+				assert.Equal(t, int64(2), meta.Patchset)
+			}
+			assert.True(t, found, "unmerged patchset was not scanned")
+		})
+	}
+}
+
+func TestParseChangeRef(t *testing.T) {
+	tests := map[string]struct {
+		ref  string
+		want patchset
+		ok   bool
+	}{
+		"patchset":   {ref: "refs/remotes/origin/changes/45/12345/2", want: patchset{change: 12345, patchset: 2}, ok: true},
+		"meta ref":   {ref: "refs/remotes/origin/changes/45/12345/meta"},
+		"branch":     {ref: "refs/remotes/origin/master"},
+		"short path": {ref: "refs/remotes/origin/changes/45/12345"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := parseChangeRef(tt.ref)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
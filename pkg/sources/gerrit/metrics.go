@@ -0,0 +1,34 @@
+package gerrit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var (
+	gerritProjectsEnumerated = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "gerrit_projects_enumerated",
+		Help:      "Total number of Gerrit projects enumerated.",
+	},
+		[]string{"source_name"})
+
+	gerritProjectsScanned = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "gerrit_projects_scanned",
+		Help:      "Total number of Gerrit projects scanned.",
+	},
+		[]string{"source_name"})
+
+	gerritPatchsetsFetched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: common.MetricsNamespace,
+		Subsystem: common.MetricsSubsystem,
+		Name:      "gerrit_patchsets_fetched",
+		Help:      "Total number of Gerrit patchset refs fetched.",
+	},
+		[]string{"source_name"})
+)
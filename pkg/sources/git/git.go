@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
@@ -70,9 +71,24 @@ type Git struct {
 	skipArchives       bool
 	repoCommitsScanned uint64 // Atomic counter for commits scanned in the current repo
 
+	// scanLFS enables scanning the objects referenced by LFS pointers, which
+	// are downloaded from lfsEndpoint if they aren't in the local LFS store.
+	// Batch requests to lfsEndpoint are authenticated with lfsUserInfo.
+	scanLFS     bool
+	lfsEndpoint string
+	lfsUserInfo *url.Userinfo
+	lfsClient   *http.Client
+
+	// cloneFunc clones submodules on the same host as their superproject
+	// with the source's authentication.
+	cloneFunc CloneFunc
+
 	parser *gitparse.Parser
 }
 
+// CloneFunc clones a repository with a source's authentication.
+type CloneFunc func(ctx context.Context, repoURL, dir string, args ...string) (string, *git.Repository, error)
+
 // Config for a Git source.
 type Config struct {
 	Concurrency        int
@@ -85,6 +101,16 @@ type Config struct {
 	Verify       bool
 	SkipBinaries bool
 	SkipArchives bool
+	// ScanLFS enables scanning the objects referenced by Git LFS pointers.
+	// Objects missing from the local LFS store are downloaded from
+	// LFSEndpoint, if set, authenticated with LFSUserInfo, if set.
+	ScanLFS     bool
+	LFSEndpoint string
+	LFSUserInfo *url.Userinfo
+	// CloneFunc, if set, clones the submodules that are on the same host as
+	// the scanned repository, so that they're authenticated like it was.
+	// Other submodules are cloned with the credentials in their URL, if any.
+	CloneFunc CloneFunc
 
 	// UseCustomContentWriter indicates whether to use a custom contentWriter.
 	// When set to true, the parser will use a custom contentWriter provided through the WithContentWriter option.
@@ -104,6 +130,12 @@ func NewGit(config *Config) *Git {
 		parser = gitparse.NewParser()
 	}
 
+	// The LFS client is only needed, and only created, when LFS objects are scanned.
+	var lfsClient *http.Client
+	if config.ScanLFS {
+		lfsClient = common.RetryableHTTPClient()
+	}
+
 	return &Git{
 		sourceType:         config.SourceType,
 		sourceName:         config.SourceName,
@@ -115,6 +147,11 @@ func NewGit(config *Config) *Git {
 		concurrency:        semaphore.NewWeighted(int64(config.Concurrency)),
 		skipBinaries:       config.SkipBinaries,
 		skipArchives:       config.SkipArchives,
+		scanLFS:            config.ScanLFS,
+		lfsEndpoint:        config.LFSEndpoint,
+		lfsUserInfo:        config.LFSUserInfo,
+		lfsClient:          lfsClient,
+		cloneFunc:          config.CloneFunc,
 		parser:             parser,
 	}
 }
@@ -190,6 +227,24 @@ func (s *Source) Init(aCtx context.Context, name string, jobId sources.JobID, so
 	if isBare := conn.GetBare(); isBare {
 		opts = append(opts, ScanOptionBare(isBare))
 	}
+	if conn.GetStashes() {
+		opts = append(opts, ScanOptionStashes(true))
+	}
+	if conn.GetReflog() {
+		opts = append(opts, ScanOptionReflog(true))
+	}
+	if conn.GetDangling() {
+		opts = append(opts, ScanOptionDangling(true))
+	}
+	if conn.GetSubmodules() {
+		opts = append(opts, ScanOptionSubmodules(true))
+	}
+	if snapshot := conn.GetSnapshot(); snapshot != "" {
+		opts = append(opts, ScanOptionSnapshot(snapshot))
+	}
+	if revisions := conn.GetPushedRevisions(); len(revisions) > 0 {
+		opts = append(opts, ScanOptionPushedRevisions(revisions))
+	}
 	s.withScanOptions(NewScanOptions(opts...))
 
 	s.conn = &conn
@@ -210,6 +265,9 @@ func (s *Source) Init(aCtx context.Context, name string, jobId sources.JobID, so
 		Verify:       s.verify,
 		SkipBinaries: conn.GetSkipBinaries(),
 		SkipArchives: conn.GetSkipArchives(),
+		ScanLFS:      conn.GetLfs(),
+		LFSEndpoint:  conn.GetLfsEndpoint(),
+		LFSUserInfo:  lfsUserInfo(&conn),
 		Concurrency:  concurrency,
 		SourceMetadataFunc: func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
 			return &source_metadatapb.MetaData{
@@ -384,6 +442,8 @@ type cloneParams struct {
 	args      []string
 	clonePath string
 	authInUrl bool
+	// bearerToken, if set, is sent as a bearer token instead of userInfo.
+	bearerToken string
 }
 
 // CloneRepo orchestrates the cloning of a given Git repository, returning its local path
@@ -392,10 +452,14 @@ type cloneParams struct {
 // The core cloning logic is delegated to a nested function, which returns errors to the
 // outer function for centralized error handling and cleanup.
 func CloneRepo(ctx context.Context, userInfo *url.Userinfo, gitURL string, dir string, authInUrl bool, args ...string) (string, *git.Repository, error) {
+	return cloneRepo(ctx, cloneParams{userInfo: userInfo, gitURL: gitURL, args: args, clonePath: dir, authInUrl: authInUrl})
+}
+
+func cloneRepo(ctx context.Context, params cloneParams) (string, *git.Repository, error) {
 	var (
+		dir          = params.clonePath
 		isDefaultDir = dir == ""
 		clonePath    = dir
-		params       = cloneParams{userInfo, gitURL, args, clonePath, authInUrl}
 		err          error
 	)
 	if isDefaultDir {
@@ -445,11 +509,15 @@ func executeClone(ctx context.Context, params cloneParams) (*git.Repository, err
 
 	var gitArgs []string
 
-	if params.authInUrl {
+	switch {
+	case params.bearerToken != "":
+		cloneURL.User = nil
+		gitArgs = append(gitArgs, "-c", "http.extraHeader=Authorization: Bearer "+params.bearerToken)
+	case params.authInUrl:
 		if cloneURL.User == nil {
 			cloneURL.User = params.userInfo
 		}
-	} else { // default
+	default:
 		cloneURL.User = nil // remove user information from the url
 
 		pass, ok := params.userInfo.Password()
@@ -613,6 +681,12 @@ func CloneRepoUsingToken(ctx context.Context, token, gitUrl, dir, user string, a
 	return CloneRepo(ctx, userInfo, gitUrl, dir, authInUrl, args...)
 }
 
+// CloneRepoUsingBearerToken clones a repo using a provided bearer token, such
+// as an OAuth access token.
+func CloneRepoUsingBearerToken(ctx context.Context, token, gitUrl, dir string, args ...string) (string, *git.Repository, error) {
+	return cloneRepo(ctx, cloneParams{gitURL: gitUrl, args: args, clonePath: dir, bearerToken: token})
+}
+
 // CloneRepoUsingUnauthenticated clones a repo with no authentication required.
 func CloneRepoUsingUnauthenticated(ctx context.Context, url string, dir string, args ...string) (string, *git.Repository, error) {
 	return CloneRepo(ctx, nil, url, dir, false, args...)
@@ -678,7 +752,18 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 		logValues = append(logValues, "max_depth", scanOptions.MaxDepth)
 	}
 
-	diffChan, err := s.parser.RepoPath(repoCtx, path, scanOptions.HeadHash, scanOptions.BaseHash == "", scanOptions.ExcludeGlobs, scanOptions.Bare)
+	var (
+		diffChan chan *gitparse.Diff
+		err      error
+	)
+	if len(scanOptions.PushedRevisions) > 0 {
+		// The refs haven't been updated yet, so the commits that aren't
+		// reachable from any of them are exactly those added by the push.
+		logValues = append(logValues, "pushed_revisions", scanOptions.PushedRevisions)
+		diffChan, err = s.parser.RevisionsPath(repoCtx, path, scanOptions.PushedRevisions, false, scanOptions.ExcludeGlobs, scanOptions.Bare)
+	} else {
+		diffChan, err = s.parser.RepoPath(repoCtx, path, scanOptions.HeadHash, scanOptions.BaseHash == "", scanOptions.ExcludeGlobs, scanOptions.Bare)
+	}
 	if err != nil {
 		return err
 	}
@@ -688,8 +773,37 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 
 	logger.Info("scanning repo", logValues...)
 
+	gitDir := getGitDir(path, scanOptions)
+	var submodules *submoduleRefs
+	if scanOptions.Submodules {
+		submodules = newSubmoduleRefs(nil)
+	}
+	if err := s.scanDiffs(repoCtx, diffChan, gitDir, remoteURL, "", scanOptions, submodules, reporter); err != nil {
+		return err
+	}
+	if err := s.scanUnreachable(repoCtx, path, gitDir, remoteURL, scanOptions, reporter); err != nil {
+		return err
+	}
+	if submodules == nil {
+		return nil
+	}
+	return s.scanSubmodules(repoCtx, newSuperproject(repo, path, gitDir), scanOptions, submodules, reporter)
+}
+
+// scanDiffs scans the commits and diffs of a git log. The origin is set for
+// commits that aren't in the history of any branch or tag. If submodules is
+// not nil, the submodule entries that the diffs add or update are collected
+// into it.
+func (s *Git) scanDiffs(
+	ctx context.Context,
+	diffChan chan *gitparse.Diff,
+	gitDir, remoteURL, origin string,
+	scanOptions *ScanOptions,
+	submodules *submoduleRefs,
+	reporter sources.ChunkReporter,
+) error {
 	var (
-		gitDir         = getGitDir(path, scanOptions)
+		logger         = ctx.Logger()
 		depth          int64
 		lastCommitHash string
 	)
@@ -721,7 +835,7 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 			// Scan the commit metadata.
 			// See https://github.com/trufflesecurity/trufflehog/issues/2683
 			var (
-				metadata = s.sourceMetadataFunc("", email, fullHash, when, remoteURL, 0)
+				metadata = s.metadata(origin, "", email, fullHash, when, remoteURL, 0)
 				sb       strings.Builder
 			)
 			sb.WriteString(email)
@@ -759,11 +873,11 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 			if s.skipBinaries || feature.ForceSkipBinaries.Load() {
 				logger.V(5).Info("skipping binary file",
 					"commit", commitHash.String()[:7],
-					"path", path)
+					"path", fileName)
 				continue
 			}
 
-			metadata := s.sourceMetadataFunc(fileName, email, fullHash, when, remoteURL, 0)
+			metadata := s.metadata(origin, fileName, email, fullHash, when, remoteURL, 0)
 			chunkSkel := &sources.Chunk{
 				SourceName:     s.sourceName,
 				SourceID:       s.sourceID,
@@ -785,12 +899,12 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 		}
 
 		if diff.Len() > sources.ChunkSize+sources.PeekSize {
-			s.gitChunk(ctx, diff, fileName, email, fullHash, when, remoteURL, reporter)
+			s.gitChunk(ctx, diff, fileName, email, fullHash, when, remoteURL, origin, reporter)
 			continue
 		}
 
 		chunkData := func(d *gitparse.Diff) error {
-			metadata := s.sourceMetadataFunc(fileName, email, fullHash, when, remoteURL, int64(diff.LineStart))
+			metadata := s.metadata(origin, fileName, email, fullHash, when, remoteURL, int64(diff.LineStart))
 
 			reader, err := d.ReadCloser()
 			if err != nil {
@@ -821,7 +935,31 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 				Data:           data,
 				Verify:         s.verify,
 			}
-			return reporter.ChunkOk(ctx, chunk)
+			if err := reporter.ChunkOk(ctx, chunk); err != nil {
+				return err
+			}
+
+			// Scan the object that an LFS pointer references, which isn't part
+			// of the repository itself.
+			if s.scanLFS && mayBeLFSPointer(data) {
+				chunkSkel := &sources.Chunk{
+					SourceName:     s.sourceName,
+					SourceID:       s.sourceID,
+					JobID:          s.jobID,
+					SourceType:     s.sourceType,
+					SourceMetadata: s.metadata(origin, fileName, email, fullHash, when, remoteURL, 0),
+					Verify:         s.verify,
+				}
+				if err := s.handleLFSPointer(ctx, gitDir, reporter, chunkSkel, plumbing.NewHash(fullHash), fileName); err != nil {
+					logger.Error(err, "error handling LFS object", "commit", fullHash, "path", fileName)
+				}
+			}
+
+			// Submodules are scanned once the whole log has been.
+			if submodules != nil && isGitlinkDiff(data) {
+				submodules.add(fullHash, fileName)
+			}
+			return nil
 		}
 		if err := chunkData(diff); err != nil {
 			return err
@@ -830,7 +968,7 @@ func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string
 	return nil
 }
 
-func (s *Git) gitChunk(ctx context.Context, diff *gitparse.Diff, fileName, email, hash, when, urlMetadata string, reporter sources.ChunkReporter) {
+func (s *Git) gitChunk(ctx context.Context, diff *gitparse.Diff, fileName, email, hash, when, urlMetadata, origin string, reporter sources.ChunkReporter) {
 	reader, err := diff.ReadCloser()
 	if err != nil {
 		ctx.Logger().Error(err, "error creating reader for chunk", "filename", fileName, "commit", hash, "file", diff.PathB)
@@ -849,7 +987,7 @@ func (s *Git) gitChunk(ctx context.Context, diff *gitparse.Diff, fileName, email
 			// Add oversize chunk info
 			if newChunkBuffer.Len() > 0 {
 				// Send the existing fragment.
-				metadata := s.sourceMetadataFunc(fileName, email, hash, when, urlMetadata, int64(diff.LineStart+lastOffset))
+				metadata := s.metadata(origin, fileName, email, hash, when, urlMetadata, int64(diff.LineStart+lastOffset))
 				chunk := sources.Chunk{
 					SourceName:     s.sourceName,
 					SourceID:       s.sourceID,
@@ -869,7 +1007,7 @@ func (s *Git) gitChunk(ctx context.Context, diff *gitparse.Diff, fileName, email
 			}
 			if len(line) > sources.ChunkSize {
 				// Send the oversize line.
-				metadata := s.sourceMetadataFunc(fileName, email, hash, when, urlMetadata, int64(diff.LineStart+offset))
+				metadata := s.metadata(origin, fileName, email, hash, when, urlMetadata, int64(diff.LineStart+offset))
 				chunk := sources.Chunk{
 					SourceName:     s.sourceName,
 					SourceID:       s.sourceID,
@@ -893,7 +1031,7 @@ func (s *Git) gitChunk(ctx context.Context, diff *gitparse.Diff, fileName, email
 	}
 	// Send anything still in the new chunk buffer
 	if newChunkBuffer.Len() > 0 {
-		metadata := s.sourceMetadataFunc(fileName, email, hash, when, urlMetadata, int64(diff.LineStart+lastOffset))
+		metadata := s.metadata(origin, fileName, email, hash, when, urlMetadata, int64(diff.LineStart+lastOffset))
 		chunk := sources.Chunk{
 			SourceName:     s.sourceName,
 			SourceID:       s.sourceID,
@@ -910,6 +1048,16 @@ func (s *Git) gitChunk(ctx context.Context, diff *gitparse.Diff, fileName, email
 	}
 }
 
+// metadata returns the source metadata of a file in a commit. The origin is
+// set for commits that aren't in the history of any branch or tag.
+func (s *Git) metadata(origin, file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
+	metadata := s.sourceMetadataFunc(file, email, commit, timestamp, repository, line)
+	if git := metadata.GetGit(); git != nil && origin != "" {
+		git.Origin = origin
+	}
+	return metadata
+}
+
 // ScanStaged chunks staged changes.
 func (s *Git) ScanStaged(ctx context.Context, repo *git.Repository, path string, scanOptions *ScanOptions, reporter sources.ChunkReporter) error {
 	// Get the URL metadata for reporting (may be empty).
@@ -1054,12 +1202,17 @@ func (s *Git) ScanRepo(ctx context.Context, repo *git.Repository, repoPath strin
 	// Reset the repo-specific commit counter
 	atomic.StoreUint64(&s.repoCommitsScanned, 0)
 
-	if err := s.ScanCommits(ctx, repo, repoPath, scanOptions, reporter); err != nil {
+	scan := s.ScanCommits
+	if scanOptions.Snapshot != "" {
+		scan = s.ScanSnapshot
+	}
+	if err := scan(ctx, repo, repoPath, scanOptions, reporter); err != nil {
 		// Record that we've failed to scan this repo
 		s.metrics.RecordRepoScanned(statusFailure)
 		return err
 	}
-	if !scanOptions.Bare {
+	// Staged changes aren't part of a snapshot.
+	if !scanOptions.Bare && scanOptions.Snapshot == "" {
 		if err := s.ScanStaged(ctx, repo, repoPath, scanOptions, reporter); err != nil {
 			ctx.Logger().V(1).Info("error scanning unstaged changes", "error", err)
 		}
@@ -1362,7 +1515,7 @@ func HandleBinary(
 	commitHash plumbing.Hash,
 	path string,
 	skipArchives bool,
-) (err error) {
+) error {
 	fileCtx := context.WithValues(ctx, "commit", commitHash.String()[:7], "path", path)
 	fileCtx.Logger().V(5).Info("handling binary file")
 
@@ -1371,6 +1524,19 @@ func HandleBinary(
 		return nil
 	}
 
+	return handleBlob(fileCtx, gitDir, reporter, chunkSkel, commitHash.String()+":"+path, skipArchives)
+}
+
+// handleBlob scans the blob named by the object, e.g. "<commit>:<path>" or
+// the blob's hash, with the handler for its file type.
+func handleBlob(
+	ctx context.Context,
+	gitDir string,
+	reporter sources.ChunkReporter,
+	chunkSkel *sources.Chunk,
+	object string,
+	skipArchives bool,
+) (err error) {
 	const (
 		cmdTimeout = 60 * time.Second
 		waitDelay  = 5 * time.Second
@@ -1390,10 +1556,10 @@ func HandleBinary(
 
 	// Create a timeout context for the 'git cat-file' command to ensure it does not run indefinitely.
 	// This prevents potential resource exhaustion by terminating the command if it exceeds the specified duration.
-	catFileCtx, cancel := context.WithTimeoutCause(fileCtx, cmdTimeout, errors.New("git cat-file timeout"))
+	catFileCtx, cancel := context.WithTimeoutCause(ctx, cmdTimeout, errors.New("git cat-file timeout"))
 	defer cancel()
 
-	cmd := exec.CommandContext(catFileCtx, "git", "-C", gitDir, "cat-file", "blob", commitHash.String()+":"+path)
+	cmd := exec.CommandContext(catFileCtx, "git", "-C", gitDir, "cat-file", "blob", object)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	cmd.WaitDelay = waitDelay // give the command a chance to finish before the timeout :)
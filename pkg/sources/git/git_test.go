@@ -3,6 +3,8 @@ package git
 import (
 	"bytes"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -528,6 +530,22 @@ func TestGitURLParse(t *testing.T) {
 	}
 }
 
+func TestCloneRepoUsingBearerToken(t *testing.T) {
+	ctx := context.Background()
+
+	var authHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	// The clone fails against the fake server, but only after sending the header.
+	_, _, err := CloneRepoUsingBearerToken(ctx, "s3cr3t", srv.URL+"/repo.git", "")
+	assert.Error(t, err)
+	assert.Equal(t, "Bearer s3cr3t", authHeader)
+}
+
 func TestEnumerate(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
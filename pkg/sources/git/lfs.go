@@ -0,0 +1,317 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	bufferedfilewriter "github.com/trufflesecurity/trufflehog/v3/pkg/writers/buffered_file_writer"
+)
+
+const (
+	// lfsPointerVersion is the first line of every Git LFS pointer file.
+	// See https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md.
+	lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+	// lfsPointerMaxSize is the maximum size of a pointer file, per the spec.
+	lfsPointerMaxSize = 1024
+	// lfsObjectTimeout bounds fetching and scanning a single LFS object.
+	lfsObjectTimeout = 5 * time.Minute
+	lfsMediaType     = "application/vnd.git-lfs+json"
+)
+
+// errLFSObjectNotFound is returned when an LFS object is neither in the local
+// LFS store nor available from the LFS endpoint.
+var errLFSObjectNotFound = errors.New("LFS object not found")
+
+// lfsPointer references an object stored with Git LFS.
+type lfsPointer struct {
+	oid  string // SHA-256 of the object
+	size int64
+}
+
+// mayBeLFSPointer reports whether the content of a diff may belong to an LFS
+// pointer. Diffs of modified pointers only contain the changed lines, so the
+// whole file has to be parsed to be sure.
+func mayBeLFSPointer(data []byte) bool {
+	if len(data) > lfsPointerMaxSize {
+		return false
+	}
+	oid := []byte("oid sha256:")
+	return bytes.HasPrefix(data, oid) || bytes.Contains(data, append([]byte("\n"), oid...))
+}
+
+// parseLFSPointer parses the content of a file as an LFS pointer.
+func parseLFSPointer(data []byte) (lfsPointer, bool) {
+	var ptr lfsPointer
+	if len(data) > lfsPointerMaxSize || !bytes.HasPrefix(data, []byte(lfsPointerVersion+"\n")) {
+		return ptr, false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch key {
+		case "oid":
+			oid, ok := strings.CutPrefix(value, "sha256:")
+			if _, err := hex.DecodeString(oid); !ok || err != nil || len(oid) != 64 {
+				return ptr, false
+			}
+			ptr.oid = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return ptr, false
+			}
+			ptr.size = size
+		}
+	}
+	return ptr, ptr.oid != ""
+}
+
+// lfsObjectPath returns the path of the object in the LFS store of the git
+// directory.
+func lfsObjectPath(gitDir string, ptr lfsPointer) string {
+	return filepath.Join(gitDir, "lfs", "objects", ptr.oid[0:2], ptr.oid[2:4], ptr.oid)
+}
+
+// handleLFSPointer scans the LFS object referenced by the file at path in the
+// commit, if the file is an LFS pointer. The object is read from the local LFS
+// store or, if it's missing and an endpoint is configured, downloaded.
+func (s *Git) handleLFSPointer(
+	ctx context.Context,
+	gitDir string,
+	reporter sources.ChunkReporter,
+	chunkSkel *sources.Chunk,
+	commitHash plumbing.Hash,
+	path string,
+) error {
+	if common.SkipFile(path) {
+		return nil
+	}
+
+	blob, err := catFile(ctx, gitDir, commitHash, path)
+	if err != nil {
+		return err
+	}
+	ptr, ok := parseLFSPointer(blob)
+	if !ok {
+		return nil
+	}
+
+	objCtx, cancel := context.WithTimeout(ctx, lfsObjectTimeout)
+	defer cancel()
+	objCtx = context.WithValues(objCtx, "commit", commitHash.String()[:7], "path", path, "lfs_oid", ptr.oid)
+
+	obj, err := s.openLFSObject(objCtx, gitDir, ptr)
+	if errors.Is(err, errLFSObjectNotFound) {
+		objCtx.Logger().V(2).Info("skipping LFS object that is not available")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	objCtx.Logger().V(5).Info("handling LFS object", "size", ptr.size)
+	return handlers.HandleFile(objCtx, obj, chunkSkel, reporter, handlers.WithSkipArchives(s.skipArchives))
+}
+
+// catFile returns the content of the file at path in the commit, up to the
+// maximum size of an LFS pointer.
+func catFile(ctx context.Context, gitDir string, commitHash plumbing.Hash, path string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", "-C", gitDir, "cat-file", "blob", commitHash.String()+":"+path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error running git cat-file: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting git cat-file: %w\n%s", err, stderr.Bytes())
+	}
+
+	data, readErr := io.ReadAll(io.LimitReader(stdout, lfsPointerMaxSize+1))
+	// Discard the rest of larger files so that the command can exit.
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := errors.Join(readErr, cmd.Wait()); err != nil {
+		return nil, fmt.Errorf("error reading file from git: %w\n%s", err, stderr.Bytes())
+	}
+	return data, nil
+}
+
+// openLFSObject opens the object from the local LFS store, or downloads it
+// from the LFS endpoint.
+func (s *Git) openLFSObject(ctx context.Context, gitDir string, ptr lfsPointer) (io.ReadCloser, error) {
+	f, err := os.Open(lfsObjectPath(gitDir, ptr))
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error opening LFS object: %w", err)
+	}
+	if s.lfsEndpoint == "" {
+		return nil, errLFSObjectNotFound
+	}
+	return s.downloadLFSObject(ctx, ptr)
+}
+
+// lfsBatchObject is an object in a request to or response from the LFS batch
+// API. See https://github.com/git-lfs/git-lfs/blob/main/docs/api/batch.md.
+type lfsBatchObject struct {
+	OID     string `json:"oid"`
+	Size    int64  `json:"size"`
+	Actions *struct {
+		Download *struct {
+			Href   string            `json:"href"`
+			Header map[string]string `json:"header"`
+		} `json:"download"`
+	} `json:"actions,omitempty"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// downloadLFSObject requests the object's download URL from the LFS batch API
+// and downloads it.
+func (s *Git) downloadLFSObject(ctx context.Context, ptr lfsPointer) (io.ReadCloser, error) {
+	body, err := json.Marshal(map[string]any{
+		"operation": "download",
+		"transfers": []string{"basic"},
+		"objects":   []lfsBatchObject{{OID: ptr.oid, Size: ptr.size}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(s.lfsEndpoint, "/") + "/objects/batch"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating LFS batch request: %w", err)
+	}
+	req.Header.Set("Accept", lfsMediaType)
+	req.Header.Set("Content-Type", lfsMediaType)
+	if s.lfsUserInfo != nil {
+		password, _ := s.lfsUserInfo.Password()
+		req.SetBasicAuth(s.lfsUserInfo.Username(), password)
+	}
+
+	resp, err := s.lfsClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting LFS object: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LFS batch API responded with unexpected status: %s", resp.Status)
+	}
+
+	var batch struct {
+		Objects []lfsBatchObject `json:"objects"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("error decoding LFS batch response: %w", err)
+	}
+
+	for _, obj := range batch.Objects {
+		if obj.OID != ptr.oid {
+			continue
+		}
+		if obj.Error != nil {
+			if obj.Error.Code == http.StatusNotFound || obj.Error.Code == http.StatusGone {
+				return nil, errLFSObjectNotFound
+			}
+			return nil, fmt.Errorf("LFS object error %d: %s", obj.Error.Code, obj.Error.Message)
+		}
+		if obj.Actions == nil || obj.Actions.Download == nil {
+			return nil, errLFSObjectNotFound
+		}
+		return s.fetchLFSObject(ctx, obj.Actions.Download.Href, obj.Actions.Download.Header, ptr)
+	}
+	return nil, errLFSObjectNotFound
+}
+
+// fetchLFSObject downloads the object from href, which the batch API returned
+// with the headers to authenticate the download, if any. The object is
+// buffered and only returned if its size and SHA-256 match the pointer.
+func (s *Git) fetchLFSObject(ctx context.Context, href string, header map[string]string, ptr lfsPointer) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, href, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating LFS download request: %w", err)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.lfsClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading LFS object: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LFS download responded with unexpected status: %s", resp.Status)
+	}
+
+	w := bufferedfilewriter.New()
+	hash := sha256.New()
+	// Read one byte more than the pointer says the object holds, to tell
+	// whether it's larger.
+	n, copyErr := io.Copy(w, io.TeeReader(io.LimitReader(resp.Body, ptr.size+1), hash))
+	if err := errors.Join(copyErr, w.CloseForWriting()); err != nil {
+		return nil, fmt.Errorf("error downloading LFS object: %w", err)
+	}
+	obj, err := w.ReadCloser()
+	if err != nil {
+		return nil, fmt.Errorf("error reading LFS object: %w", err)
+	}
+	if obj == nil {
+		// Nothing was written.
+		obj = io.NopCloser(bytes.NewReader(nil))
+	}
+
+	if n != ptr.size {
+		_ = obj.Close()
+		return nil, fmt.Errorf("LFS object size mismatch: got %d bytes, expected %d", n, ptr.size)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(got, ptr.oid) {
+		_ = obj.Close()
+		return nil, fmt.Errorf("LFS object checksum mismatch: got sha256:%s", got)
+	}
+	return obj, nil
+}
+
+// lfsUserInfo returns the credentials to authenticate LFS batch requests
+// with: the basic auth credential of the connection, or else the credentials
+// in its URI.
+func lfsUserInfo(conn *sourcespb.Git) *url.Userinfo {
+	if cred := conn.GetBasicAuth(); cred != nil {
+		return url.UserPassword(cred.GetUsername(), cred.GetPassword())
+	}
+	u, err := url.Parse(conn.GetUri())
+	if err != nil || u.User == nil {
+		return nil
+	}
+	if _, ok := u.User.Password(); !ok {
+		return nil
+	}
+	return u.User
+}
@@ -0,0 +1,62 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func TestScanPushedRevisions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config.txt"), []byte(content), 0o644))
+		git("add", "config.txt")
+		git("commit", "-q", "-m", "update config")
+	}
+
+	git("init", "-q", "-b", "main")
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+	commit("existing-secret\n")
+	// Commits that no ref points to yet, as in a pre-receive hook.
+	commit("first-pushed-secret\n")
+	commit("second-pushed-secret\n")
+	pushed := git("rev-parse", "HEAD")
+	git("reset", "-q", "--hard", "HEAD~2")
+
+	anyConn, err := anypb.New(&sourcespb.Git{Directories: []string{dir}, PushedRevisions: []string{pushed}})
+	require.NoError(t, err)
+	s := Source{}
+	require.NoError(t, s.Init(ctx, "test pushed revisions", 0, 0, false, anyConn, 1))
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(ctx, SourceUnit{ID: dir, Kind: UnitDir}, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+
+	var found []string
+	for _, chunk := range reporter.Chunks {
+		for _, line := range strings.Split(string(chunk.Data), "\n") {
+			if strings.HasSuffix(line, "-secret") {
+				found = append(found, line)
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"first-pushed-secret", "second-pushed-secret"}, found)
+}
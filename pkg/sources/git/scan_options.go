@@ -14,6 +14,20 @@ type ScanOptions struct {
 	Bare         bool
 	ExcludeGlobs []string
 	LogOptions   *git.LogOptions
+	// Stashes, Reflog and Dangling include commits that aren't in the
+	// history of any branch or tag: stash entries, commits only referenced
+	// by reflogs, and dangling commits and blobs.
+	Stashes  bool
+	Reflog   bool
+	Dangling bool
+	// Submodules includes the submodules referenced by the scanned commits,
+	// at the commits they are pinned to.
+	Submodules bool
+	// Snapshot is a ref whose whole tree is scanned instead of the history.
+	Snapshot string
+	// PushedRevisions are the new tips of the refs updated by a push. Only
+	// the commits they add to the repository are scanned.
+	PushedRevisions []string
 }
 
 type ScanOption func(*ScanOptions)
@@ -60,6 +74,42 @@ func ScanOptionBare(bare bool) ScanOption {
 	}
 }
 
+func ScanOptionStashes(stashes bool) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.Stashes = stashes
+	}
+}
+
+func ScanOptionReflog(reflog bool) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.Reflog = reflog
+	}
+}
+
+func ScanOptionDangling(dangling bool) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.Dangling = dangling
+	}
+}
+
+func ScanOptionSubmodules(submodules bool) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.Submodules = submodules
+	}
+}
+
+func ScanOptionSnapshot(ref string) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.Snapshot = ref
+	}
+}
+
+func ScanOptionPushedRevisions(revisions []string) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.PushedRevisions = revisions
+	}
+}
+
 func NewScanOptions(options ...ScanOption) *ScanOptions {
 	scanOptions := &ScanOptions{
 		Filter:   common.FilterEmpty(),
@@ -0,0 +1,299 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/gobwas/glob"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/feature"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// treeEntry is a file in the tree of a commit.
+type treeEntry struct {
+	mode   string
+	kind   string // blob, or commit for submodules
+	object string
+	path   string
+}
+
+// ScanSnapshot scans every file in the tree of the snapshot ref, rather than
+// the changes made by each commit of the history. Findings are reported with
+// the commit the ref points to.
+func (s *Git) ScanSnapshot(ctx context.Context, repo *git.Repository, path string, scanOptions *ScanOptions, reporter sources.ChunkReporter) error {
+	remoteURL := GetSafeRemoteURL(repo, "origin")
+	if ctx.Value("repo") == nil {
+		if remoteURL != "" {
+			ctx = context.WithValue(ctx, "repo", remoteURL)
+		} else {
+			ctx = context.WithValue(ctx, "repo", path)
+		}
+	}
+	ctx = context.WithValues(ctx, "snapshot", scanOptions.Snapshot)
+	gitDir := getGitDir(path, scanOptions)
+
+	out, err := gitOutput(ctx, gitDir, "rev-parse", "--verify", "--end-of-options", scanOptions.Snapshot+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("error resolving snapshot ref: %w", err)
+	}
+	hash := strings.TrimSpace(string(out))
+	commit, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return fmt.Errorf("error reading snapshot commit: %w", err)
+	}
+	email := fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email)
+	when := commit.Author.When.UTC().Format("2006-01-02 15:04:05 -0700")
+
+	entries, err := treeEntries(ctx, gitDir, hash)
+	if err != nil {
+		return err
+	}
+	excludes := make([]glob.Glob, 0, len(scanOptions.ExcludeGlobs))
+	for _, pattern := range scanOptions.ExcludeGlobs {
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid exclude glob %q: %w", pattern, err)
+		}
+		excludes = append(excludes, g)
+	}
+
+	ctx.Logger().Info("scanning repo snapshot", "commit", hash, "files", len(entries))
+	s.metrics.RecordCommitScanned()
+	atomic.AddUint64(&s.repoCommitsScanned, 1)
+
+	var submodules *submoduleRefs
+	if scanOptions.Submodules {
+		submodules = newSubmoduleRefs(nil)
+	}
+
+	var blobs []treeEntry
+	for _, entry := range entries {
+		if !scanOptions.Filter.Pass(entry.path) || isExcluded(excludes, entry.path) {
+			continue
+		}
+		switch {
+		case entry.kind == "commit":
+			if submodules != nil {
+				submodules.add(hash, entry.path)
+			}
+		case entry.kind != "blob" || entry.mode == "120000":
+			// Symbolic links only hold the path of their target.
+		case common.SkipFile(entry.path):
+		case (s.skipBinaries || feature.ForceSkipBinaries.Load()) && common.IsBinary(entry.path):
+		default:
+			blobs = append(blobs, entry)
+		}
+	}
+
+	if err := s.scanBlobs(ctx, gitDir, blobs, func(entry treeEntry) *source_metadatapb.MetaData {
+		return s.metadata("", entry.path, email, hash, when, remoteURL, 0)
+	}, reporter); err != nil {
+		return err
+	}
+
+	if submodules == nil {
+		return nil
+	}
+	return s.scanSubmodules(ctx, newSuperproject(repo, path, gitDir), scanOptions, submodules, reporter)
+}
+
+// treeEntries lists the files of the commit's tree, recursively.
+func treeEntries(ctx context.Context, gitDir, commit string) ([]treeEntry, error) {
+	out, err := gitOutput(ctx, gitDir, "ls-tree", "-r", "-z", "--full-tree", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []treeEntry
+	for _, line := range bytes.Split(out, []byte{0}) {
+		// e.g. 100644 blob 4479006a1b6e6b5c7c0d5e0b8d6e0e5f8a1b2c3d	path/to/file
+		info, path, ok := strings.Cut(string(line), "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 3 {
+			continue
+		}
+		entries = append(entries, treeEntry{mode: fields[0], kind: fields[1], object: fields[2], path: path})
+	}
+	return entries, nil
+}
+
+// isExcluded reports whether the path, or any of its parent directories,
+// matches one of the exclude globs, like an exclude pathspec.
+func isExcluded(excludes []glob.Glob, path string) bool {
+	for _, g := range excludes {
+		for p := path; ; {
+			if g.Match(p) {
+				return true
+			}
+			i := strings.LastIndexByte(p, '/')
+			if i < 0 {
+				break
+			}
+			p = p[:i]
+		}
+	}
+	return false
+}
+
+// scanBlobs streams the blobs from a single `git cat-file --batch` process
+// through the handlers.
+func (s *Git) scanBlobs(
+	ctx context.Context,
+	gitDir string,
+	blobs []treeEntry,
+	metadata func(treeEntry) *source_metadatapb.MetaData,
+	reporter sources.ChunkReporter,
+) (err error) {
+	if len(blobs) == 0 {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", gitDir, "cat-file", "--batch")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("error running git cat-file: %w", err)
+	}
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error running git cat-file: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting git cat-file: %w\n%s", err, stderr.Bytes())
+	}
+	defer func() {
+		_ = stdin.Close()
+		_, _ = io.Copy(io.Discard, stdoutPipe)
+		if waitErr := cmd.Wait(); waitErr != nil && err == nil {
+			err = fmt.Errorf("error running git cat-file: %w\n%s", waitErr, stderr.Bytes())
+		}
+	}()
+	stdout := bufio.NewReader(stdoutPipe)
+
+	for _, entry := range blobs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Objects are written one at a time, as cat-file flushes each one
+		// as soon as it's read.
+		if _, err := fmt.Fprintln(stdin, entry.object); err != nil {
+			return fmt.Errorf("error writing to git cat-file: %w", err)
+		}
+		size, err := readBatchHeader(stdout)
+		if errors.Is(err, errObjectMissing) {
+			ctx.Logger().V(2).Info("skipping missing object", "path", entry.path, "object", entry.object)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		chunkSkel := &sources.Chunk{
+			SourceName:     s.sourceName,
+			SourceID:       s.sourceID,
+			JobID:          s.jobID,
+			SourceType:     s.sourceType,
+			SourceMetadata: metadata(entry),
+			Verify:         s.verify,
+		}
+		blob := io.LimitReader(stdout, size)
+		fileCtx := context.WithValues(ctx, "path", entry.path)
+		if err := handlers.HandleFile(fileCtx, blob, chunkSkel, newLineReporter(reporter), handlers.WithSkipArchives(s.skipArchives)); err != nil {
+			fileCtx.Logger().Error(err, "error handling file")
+		}
+
+		// Discard whatever the handlers didn't read, and the newline that
+		// follows every object.
+		if _, err := io.Copy(io.Discard, blob); err != nil {
+			return fmt.Errorf("error reading from git cat-file: %w", err)
+		}
+		if _, err := stdout.Discard(1); err != nil {
+			return fmt.Errorf("error reading from git cat-file: %w", err)
+		}
+	}
+	return nil
+}
+
+// errObjectMissing is returned by readBatchHeader for missing objects, e.g.
+// in partial clones.
+var errObjectMissing = errors.New("object missing")
+
+// readBatchHeader reads the header that `git cat-file --batch` outputs before
+// each object, and returns the size of the object.
+func readBatchHeader(r *bufio.Reader) (int64, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return 0, fmt.Errorf("error reading from git cat-file: %w", err)
+	}
+	// e.g. 4479006a1b6e6b5c7c0d5e0b8d6e0e5f8a1b2c3d blob 58
+	fields := strings.Fields(header)
+	if len(fields) == 2 && fields[1] == "missing" {
+		return 0, errObjectMissing
+	}
+	if len(fields) != 3 {
+		return 0, fmt.Errorf("unexpected git cat-file output: %q", header)
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected git cat-file output: %q", header)
+	}
+	return size, nil
+}
+
+// lineReporter sets the line that each chunk of a file starts at, which the
+// handlers don't track. The chunks must be reported in order.
+type lineReporter struct {
+	sources.ChunkReporter
+	line int64
+}
+
+func newLineReporter(reporter sources.ChunkReporter) *lineReporter {
+	return &lineReporter{ChunkReporter: reporter, line: 1}
+}
+
+func (r *lineReporter) ChunkOk(ctx context.Context, chunk sources.Chunk) error {
+	// The handlers share the metadata of the chunk skeleton between chunks.
+	if metadata, ok := proto.Clone(chunk.SourceMetadata).(*source_metadatapb.MetaData); ok && metadata != nil {
+		setLine(metadata, r.line)
+		chunk.SourceMetadata = metadata
+	}
+
+	// The end of each chunk is peeked from the start of the next one.
+	data := chunk.Data
+	if len(data) > sources.ChunkSize {
+		data = data[:sources.ChunkSize]
+	}
+	r.line += int64(bytes.Count(data, []byte("\n")))
+	return r.ChunkReporter.ChunkOk(ctx, chunk)
+}
+
+// setLine sets the line of the source metadata, whichever git hosting source
+// it belongs to.
+func setLine(meta *source_metadatapb.MetaData, line int64) {
+	m := meta.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("data"))
+	if field == nil || field.Kind() != protoreflect.MessageKind {
+		return
+	}
+	data := m.Mutable(field).Message()
+	if fd := data.Descriptor().Fields().ByName("line"); fd != nil && fd.Kind() == protoreflect.Int64Kind {
+		data.Set(fd, protoreflect.ValueOfInt64(line))
+	}
+}
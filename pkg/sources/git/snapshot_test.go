@@ -0,0 +1,117 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newSnapshotRepo creates a repository whose tree at the v1 tag differs from
+// the tree at HEAD. It returns its directory and the line of large-secret in
+// large.txt.
+func newSnapshotRepo(t *testing.T) (string, int64) {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(file, content string) {
+		t.Helper()
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	git("init", "-q", "-b", "main")
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+
+	// Spread over several chunks, to check that lines are tracked across them.
+	var large strings.Builder
+	line := int64(1)
+	for large.Len() < 2*sources.ChunkSize {
+		large.WriteString("nothing to see here\n")
+		line++
+	}
+	large.WriteString("large-secret\n")
+	write("large.txt", large.String())
+	write("config/app.txt", "name: app\nunchanged-secret\n")
+	write("vendor/lib.txt", "vendored-secret\n")
+	write("removed.txt", "removed-secret\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial commit")
+	git("tag", "v1")
+
+	git("rm", "-q", "removed.txt")
+	write("added.txt", "added-secret\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "second commit")
+	return dir, line
+}
+
+func TestScanSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir, largeLine := newSnapshotRepo(t)
+
+	type finding struct {
+		file string
+		line int64
+	}
+	scan := func(conn *sourcespb.Git) map[string]finding {
+		t.Helper()
+		conn.Directories = []string{dir}
+		anyConn, err := anypb.New(conn)
+		require.NoError(t, err)
+
+		s := Source{}
+		require.NoError(t, s.Init(ctx, "test snapshot", 0, 0, false, anyConn, 1))
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(ctx, SourceUnit{ID: dir, Kind: UnitDir}, &reporter))
+		require.Empty(t, reporter.ChunkErrs)
+
+		found := make(map[string]finding)
+		for _, chunk := range reporter.Chunks {
+			git := chunk.SourceMetadata.GetGit()
+			for i, line := range bytes.Split(chunk.Data, []byte("\n")) {
+				if bytes.HasSuffix(line, []byte("-secret")) {
+					found[string(line)] = finding{file: git.GetFile(), line: git.GetLine() + int64(i)}
+				}
+			}
+		}
+		return found
+	}
+
+	assert.Equal(t, map[string]finding{
+		"large-secret":     {file: "large.txt", line: largeLine},
+		"unchanged-secret": {file: "config/app.txt", line: 2},
+		"vendored-secret":  {file: "vendor/lib.txt", line: 1},
+		"added-secret":     {file: "added.txt", line: 1},
+	}, scan(&sourcespb.Git{Snapshot: "HEAD"}))
+
+	found := scan(&sourcespb.Git{Snapshot: "v1", ExcludeGlobs: "vendor"})
+	assert.Contains(t, found, "removed-secret")
+	assert.NotContains(t, found, "added-secret")
+	assert.NotContains(t, found, "vendored-secret")
+
+	s := Source{}
+	anyConn, err := anypb.New(&sourcespb.Git{Directories: []string{dir}, Snapshot: "no-such-ref"})
+	require.NoError(t, err)
+	require.NoError(t, s.Init(ctx, "test snapshot", 0, 0, false, anyConn, 1))
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.ChunkUnit(ctx, SourceUnit{ID: dir, Kind: UnitDir}, &reporter))
+	assert.NotEmpty(t, reporter.ChunkErrs)
+}
@@ -0,0 +1,405 @@
+package git
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// gitlinkPrefix starts the content of a gitlink, the entry of a submodule in
+// the tree of its superproject, as shown by `git log --patch`. It's followed
+// by the commit the submodule is pinned to.
+const gitlinkPrefix = "Subproject commit "
+
+// gitlink is a submodule entry added or updated by a commit of a superproject.
+type gitlink struct {
+	commit string // commit of the superproject
+	path   string
+}
+
+// submoduleRefs collects the gitlinks of the scanned commits of a
+// superproject.
+type submoduleRefs struct {
+	gitlinks []gitlink
+	// scanned holds the submodule commits that have already been scanned,
+	// keyed by URL and commit. It's shared with nested submodules so that
+	// each commit is only scanned once.
+	scanned map[string]struct{}
+}
+
+func newSubmoduleRefs(scanned map[string]struct{}) *submoduleRefs {
+	if scanned == nil {
+		scanned = make(map[string]struct{})
+	}
+	return &submoduleRefs{scanned: scanned}
+}
+
+func (r *submoduleRefs) add(commit, path string) {
+	r.gitlinks = append(r.gitlinks, gitlink{commit: commit, path: path})
+}
+
+// submodule is a submodule pinned to a commit by a commit of its superproject.
+type submodule struct {
+	path               string // path of the submodule in the superproject
+	url                string // URL to clone the submodule from
+	commit             string
+	superprojectCommit string
+	// sameOrigin is set if the submodule is on the same remote host as its
+	// superproject, with the same scheme.
+	sameOrigin bool
+}
+
+// superproject is a repository whose submodules are scanned.
+type superproject struct {
+	gitDir string
+	// repository is reported in the metadata of the submodules' findings.
+	repository string
+	// baseURL is the URL that relative submodule URLs are resolved against:
+	// the remote of the superproject or, if it has none, its path.
+	baseURL string
+}
+
+func newSuperproject(repo *git.Repository, path, gitDir string) superproject {
+	sp := superproject{gitDir: gitDir, repository: path, baseURL: path}
+	if remote, err := repo.Remote("origin"); err == nil && len(remote.Config().URLs) > 0 {
+		sp.baseURL = remote.Config().URLs[0]
+		sp.repository = GetSafeRemoteURL(repo, "origin")
+	}
+	return sp
+}
+
+// isGitlinkDiff reports whether the content of a diff is that of a gitlink.
+// A regular file could have the same content, so the type of the entry has to
+// be checked to be sure.
+func isGitlinkDiff(data []byte) bool {
+	hash, ok := bytes.CutPrefix(bytes.TrimSpace(data), []byte(gitlinkPrefix))
+	return ok && isCommitHash(string(hash))
+}
+
+func isCommitHash(hash string) bool {
+	// SHA-1 or SHA-256 repositories.
+	if len(hash) != 40 && len(hash) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// scanSubmodules clones and scans the submodules that the collected gitlinks
+// pin, and then their own submodules. Findings in a submodule are reported
+// with the coordinates of both the submodule and the gitlink.
+func (s *Git) scanSubmodules(
+	ctx context.Context,
+	sp superproject,
+	scanOptions *ScanOptions,
+	refs *submoduleRefs,
+	reporter sources.ChunkReporter,
+) error {
+	// Each submodule is cloned once, however many commits it's pinned to.
+	var urls []string
+	pins := make(map[string][]submodule)
+	for _, sm := range resolveSubmodules(ctx, sp, refs) {
+		if _, ok := pins[sm.url]; !ok {
+			urls = append(urls, sm.url)
+		}
+		pins[sm.url] = append(pins[sm.url], sm)
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	ctx.Logger().V(1).Info("scanning submodules", "submodules", len(urls))
+
+	for _, cloneURL := range urls {
+		if err := s.scanSubmodule(ctx, sp, cloneURL, pins[cloneURL], scanOptions, refs.scanned, reporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanSubmodule clones a submodule and scans its history up to each of the
+// commits it's pinned to, without scanning any commit twice.
+func (s *Git) scanSubmodule(
+	ctx context.Context,
+	sp superproject,
+	cloneURL string,
+	pins []submodule,
+	scanOptions *ScanOptions,
+	scanned map[string]struct{},
+	reporter sources.ChunkReporter,
+) error {
+	safeURL, _, err := stripPassword(cloneURL)
+	if err != nil {
+		safeURL = cloneURL
+	}
+	ctx = context.WithValues(ctx, "submodule", safeURL)
+
+	// Submodules on the superproject's host are cloned with the source's
+	// authentication, if it has any. Otherwise, credentials resolved from the
+	// superproject's remote are kept in the URL, as that's where they came
+	// from.
+	var clonePath string
+	if s.cloneFunc != nil && pins[0].sameOrigin && isHTTPURL(cloneURL) {
+		clonePath, _, err = s.cloneFunc(ctx, cloneURL, "")
+	} else {
+		clonePath, _, err = CloneRepo(ctx, nil, cloneURL, "", true)
+	}
+	if err != nil {
+		ctx.Logger().Error(err, "error cloning submodule")
+		return nil
+	}
+	defer os.RemoveAll(clonePath)
+
+	// The range of the scan only applies to the superproject.
+	opts := *scanOptions
+	opts.BaseHash = ""
+	opts.MaxDepth = 0
+	opts.Bare = false
+
+	var scannedCommits []string
+	for _, sm := range pins {
+		var args []string
+		if len(scannedCommits) > 0 {
+			args = append([]string{"--not"}, scannedCommits...)
+		}
+
+		diffChan, err := s.parser.RepoPath(ctx, clonePath, sm.commit, true, opts.ExcludeGlobs, false, args...)
+		if err != nil {
+			ctx.Logger().Error(err, "error scanning submodule", "commit", sm.commit)
+			continue
+		}
+
+		sub := s.forSubmodule(sp.repository, sm)
+		nested := newSubmoduleRefs(scanned)
+		if err := sub.scanDiffs(ctx, diffChan, clonePath, safeURL, "", &opts, nested, reporter); err != nil {
+			return err
+		}
+		scannedCommits = append(scannedCommits, sm.commit)
+
+		nestedSP := superproject{gitDir: clonePath, repository: safeURL, baseURL: cloneURL}
+		if err := sub.scanSubmodules(ctx, nestedSP, &opts, nested, reporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forSubmodule returns a copy of s that reports findings in the submodule
+// with the metadata of the source. Git metadata also holds the coordinates of
+// the submodule's gitlink in the superproject.
+func (s *Git) forSubmodule(superprojectRepository string, sm submodule) *Git {
+	sub := *s
+	sub.sourceMetadataFunc = func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
+		metadata := s.sourceMetadataFunc(file, email, commit, timestamp, repository, line)
+		if md := metadata.GetGit(); md != nil {
+			md.SuperprojectRepository = sanitizer.UTF8(superprojectRepository)
+			md.SuperprojectCommit = sm.superprojectCommit
+			md.SubmodulePath = sanitizer.UTF8(sm.path)
+		}
+		return metadata
+	}
+	return &sub
+}
+
+// resolveSubmodules returns the submodules that the gitlinks pin and that
+// haven't been scanned yet. Their URLs are read from the .gitmodules file of
+// the superproject commit that added or updated the gitlink.
+func resolveSubmodules(ctx context.Context, sp superproject, refs *submoduleRefs) []submodule {
+	var (
+		submodules []submodule
+		urls       = make(map[string]map[string]string)
+	)
+	for _, link := range refs.gitlinks {
+		logger := ctx.Logger().WithValues("commit", link.commit, "path", link.path)
+
+		commit, err := gitlinkCommit(ctx, sp.gitDir, link)
+		if err != nil {
+			logger.Error(err, "error reading submodule entry")
+			continue
+		}
+		if commit == "" {
+			// A regular file that looks like a gitlink.
+			continue
+		}
+
+		commitURLs, ok := urls[link.commit]
+		if !ok {
+			if commitURLs, err = submoduleURLs(ctx, sp.gitDir, link.commit); err != nil {
+				logger.V(2).Info("error reading .gitmodules", "error", err)
+			}
+			urls[link.commit] = commitURLs
+		}
+		rawURL, ok := commitURLs[link.path]
+		if !ok {
+			logger.V(2).Info("skipping submodule that is not in .gitmodules")
+			continue
+		}
+
+		cloneURL, err := resolveSubmoduleURL(sp.baseURL, rawURL)
+		if err != nil {
+			logger.V(2).Info("skipping submodule", "error", err)
+			continue
+		}
+
+		key := cloneURL + "@" + commit
+		if _, ok := refs.scanned[key]; ok {
+			continue
+		}
+		refs.scanned[key] = struct{}{}
+		submodules = append(submodules, submodule{
+			path:               link.path,
+			url:                cloneURL,
+			commit:             commit,
+			superprojectCommit: link.commit,
+			sameOrigin:         sameOrigin(sp.baseURL, cloneURL),
+		})
+	}
+	return submodules
+}
+
+// gitlinkCommit returns the commit that the gitlink pins the submodule to, or
+// an empty string if the entry isn't a gitlink.
+func gitlinkCommit(ctx context.Context, gitDir string, link gitlink) (string, error) {
+	out, err := gitOutput(ctx, gitDir, "ls-tree", link.commit, "--", link.path)
+	if err != nil {
+		return "", err
+	}
+	// e.g. 160000 commit 96f8dc21b550d3709c96147ecedeb83ec0a7a3df	vendor/lib
+	info, _, _ := strings.Cut(string(out), "\t")
+	fields := strings.Fields(info)
+	if len(fields) != 3 || fields[1] != "commit" {
+		return "", nil
+	}
+	return fields[2], nil
+}
+
+// submoduleURLs returns the URLs of the submodules in the .gitmodules file of
+// the commit, keyed by path.
+func submoduleURLs(ctx context.Context, gitDir, commit string) (map[string]string, error) {
+	out, err := gitOutput(ctx, gitDir,
+		"config", "--blob", commit+":.gitmodules", "--null",
+		"--get-regexp", `^submodule\..*\.(path|url)$`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	urls := make(map[string]string)
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		// e.g. submodule.vendor/lib.path\nvendor/lib
+		key, value, ok := strings.Cut(string(entry), "\n")
+		if !ok {
+			continue
+		}
+		name := strings.TrimPrefix(key, "submodule.")
+		if name, ok := strings.CutSuffix(name, ".path"); ok {
+			paths[name] = value
+		} else if name, ok := strings.CutSuffix(name, ".url"); ok {
+			urls[name] = value
+		}
+	}
+
+	result := make(map[string]string, len(paths))
+	for name, p := range paths {
+		if u, ok := urls[name]; ok {
+			result[p] = u
+		}
+	}
+	return result, nil
+}
+
+// resolveSubmoduleURL resolves the URL of a submodule against the base URL of
+// its superproject, like git does. Submodules of remote superprojects can't
+// be local paths, and are cloned with the credentials of the superproject if
+// they're on the same host with the same scheme, so that credentials are never
+// sent over plain HTTP when the superproject was cloned over HTTPS.
+func resolveSubmoduleURL(base, rawURL string) (string, error) {
+	if rawURL == "" || strings.HasPrefix(rawURL, "-") {
+		return "", fmt.Errorf("invalid submodule URL: %q", rawURL)
+	}
+	localBase := !isRemoteURL(base)
+
+	if strings.HasPrefix(rawURL, "./") || strings.HasPrefix(rawURL, "../") {
+		if localBase {
+			return filepath.Join(base, rawURL), nil
+		}
+		u, err := GitURLParse(base)
+		if err != nil {
+			return "", err
+		}
+		// The superproject URL is treated as a directory, e.g. ../lib
+		// relative to https://example.com/org/app.git is
+		// https://example.com/org/lib.
+		u.Path = path.Join(u.Path, rawURL)
+		return u.String(), nil
+	}
+
+	if !isRemoteURL(rawURL) {
+		if !localBase {
+			return "", fmt.Errorf("local submodule of a remote repository: %s", rawURL)
+		}
+		return rawURL, nil
+	}
+
+	u, err := GitURLParse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "http", "https", "ssh", "git":
+	default:
+		return "", fmt.Errorf("unsupported submodule URL scheme: %s", u.Scheme)
+	}
+	if !localBase && u.User == nil {
+		if b, err := GitURLParse(base); err == nil && b.Scheme == u.Scheme && b.Host == u.Host {
+			u.User = b.User
+		}
+	}
+	return u.String(), nil
+}
+
+// sameOrigin reports whether both git URLs are remote URLs with the same
+// scheme and host.
+func sameOrigin(a, b string) bool {
+	if !isRemoteURL(a) || !isRemoteURL(b) {
+		return false
+	}
+	ua, err := GitURLParse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := GitURLParse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Scheme == ub.Scheme && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// isHTTPURL reports whether the git URL is an HTTP(S) URL.
+func isHTTPURL(gitURL string) bool {
+	scheme, _, _ := strings.Cut(gitURL, "://")
+	return scheme == "http" || scheme == "https"
+}
+
+// isRemoteURL reports whether the git URL is a remote URL, rather than a local
+// path or file URL.
+func isRemoteURL(gitURL string) bool {
+	if scheme, _, ok := strings.Cut(gitURL, "://"); ok {
+		return scheme != "file"
+	}
+	// scp-like syntax, e.g. git@github.com:org/repo.git.
+	before, _, ok := strings.Cut(gitURL, ":")
+	return ok && before != "" && !strings.Contains(before, "/")
+}
@@ -0,0 +1,206 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// Origins of commits and blobs that aren't in the history of any branch or
+// tag, and so are missed by `git log --all`.
+const (
+	originStash    = "stash"
+	originReflog   = "reflog"
+	originDangling = "dangling"
+)
+
+// scanUnreachable scans the stash entries, reflog-only commits and dangling
+// objects of the repository, if enabled by the scan options.
+func (s *Git) scanUnreachable(
+	ctx context.Context,
+	path, gitDir, remoteURL string,
+	scanOptions *ScanOptions,
+	reporter sources.ChunkReporter,
+) error {
+	if !scanOptions.Stashes && !scanOptions.Reflog && !scanOptions.Dangling {
+		return nil
+	}
+
+	// The depth and base of the scan only apply to the history of refs.
+	opts := *scanOptions
+	opts.MaxDepth = 0
+	opts.BaseHash = ""
+
+	if scanOptions.Stashes {
+		commits, err := stashCommits(ctx, gitDir)
+		if err != nil {
+			ctx.Logger().Error(err, "error listing stash entries")
+		}
+		if err := s.scanRevisions(ctx, path, gitDir, remoteURL, originStash, commits, true, &opts, reporter); err != nil {
+			return err
+		}
+	}
+
+	if scanOptions.Reflog {
+		commits, err := reflogCommits(ctx, gitDir)
+		if err != nil {
+			ctx.Logger().Error(err, "error listing reflog entries")
+		}
+		if err := s.scanRevisions(ctx, path, gitDir, remoteURL, originReflog, commits, false, &opts, reporter); err != nil {
+			return err
+		}
+	}
+
+	if scanOptions.Dangling {
+		commits, blobs, err := danglingObjects(ctx, gitDir)
+		if err != nil {
+			ctx.Logger().Error(err, "error listing dangling objects")
+		}
+		if err := s.scanRevisions(ctx, path, gitDir, remoteURL, originDangling, commits, false, &opts, reporter); err != nil {
+			return err
+		}
+		for _, blob := range blobs {
+			metadata := s.metadata(originDangling, "", "", "", "", remoteURL, 0)
+			if git := metadata.GetGit(); git != nil {
+				git.Blob = blob
+			}
+			chunkSkel := &sources.Chunk{
+				SourceName:     s.sourceName,
+				SourceID:       s.sourceID,
+				JobID:          s.jobID,
+				SourceType:     s.sourceType,
+				SourceMetadata: metadata,
+				Verify:         s.verify,
+			}
+			blobCtx := context.WithValues(ctx, "blob", blob)
+			if err := handleBlob(blobCtx, gitDir, reporter, chunkSkel, blob, s.skipArchives); err != nil {
+				blobCtx.Logger().Error(err, "error handling dangling blob")
+			}
+		}
+	}
+	return nil
+}
+
+// scanRevisions scans the commits that aren't in the history of any ref. If
+// noWalk is set, their ancestors are not scanned.
+func (s *Git) scanRevisions(
+	ctx context.Context,
+	path, gitDir, remoteURL, origin string,
+	commits []string,
+	noWalk bool,
+	scanOptions *ScanOptions,
+	reporter sources.ChunkReporter,
+) error {
+	if len(commits) == 0 {
+		return nil
+	}
+	ctx.Logger().V(1).Info("scanning commits outside of the history of refs", "origin", origin, "commits", len(commits))
+
+	diffChan, err := s.parser.RevisionsPath(ctx, path, commits, noWalk, scanOptions.ExcludeGlobs, scanOptions.Bare)
+	if err != nil {
+		return err
+	}
+	return s.scanDiffs(ctx, diffChan, gitDir, remoteURL, origin, scanOptions, nil, reporter)
+}
+
+// stashCommits returns the commits of every stash entry, including those
+// that record its staged and untracked files.
+func stashCommits(ctx context.Context, gitDir string) ([]string, error) {
+	if _, err := gitOutput(ctx, gitDir, "rev-parse", "--quiet", "--verify", "refs/stash"); err != nil {
+		// There are no stash entries.
+		return nil, nil
+	}
+
+	out, err := gitOutput(ctx, gitDir, "log", "--walk-reflogs", "--format=%H %P", "refs/stash")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []string
+	for _, line := range lines(out) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// The first parent is the commit the stash was created on, which
+		// is scanned with the history of refs.
+		commits = append(commits, fields[0])
+		if len(fields) > 2 {
+			commits = append(commits, fields[2:]...)
+		}
+	}
+	return dedupe(commits), nil
+}
+
+// reflogCommits returns the commits referenced by the reflogs of all refs.
+func reflogCommits(ctx context.Context, gitDir string) ([]string, error) {
+	out, err := gitOutput(ctx, gitDir, "reflog", "show", "--all", "--format=%H")
+	if err != nil {
+		return nil, err
+	}
+	return dedupe(lines(out)), nil
+}
+
+// danglingObjects returns the commits and blobs that aren't referenced by any
+// ref, reflog or other object, e.g. after a force-push or `git reset`.
+func danglingObjects(ctx context.Context, gitDir string) (commits, blobs []string, err error) {
+	out, err := gitOutput(ctx, gitDir, "fsck", "--dangling", "--no-progress")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, line := range lines(out) {
+		// e.g. dangling commit 7a95bbf0199e280a0e42dbb1d1a3f56cdd0f6e05
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "dangling" {
+			continue
+		}
+		switch fields[1] {
+		case "commit":
+			commits = append(commits, fields[2])
+		case "blob":
+			blobs = append(blobs, fields[2])
+		}
+	}
+	return commits, blobs, nil
+}
+
+// gitOutput runs the git command in the git directory and returns its output.
+func gitOutput(ctx context.Context, gitDir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", gitDir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running git %s: %w\n%s", args[0], err, stderr.Bytes())
+	}
+	return out, nil
+}
+
+func lines(out []byte) []string {
+	var result []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func dedupe(items []string) []string {
+	seen := make(map[string]struct{}, len(items))
+	result := items[:0]
+	for _, item := range items {
+		if _, ok := seen[item]; ok {
+			continue
+		}
+		seen[item] = struct{}{}
+		result = append(result, item)
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newUnreachableRepo creates a repository whose secrets are only in stash
+// entries, reflogs and dangling objects.
+func newUnreachableRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config.txt"), []byte(content), 0o644))
+	}
+
+	git("init", "-q", "-b", "main")
+	git("config", "user.name", "test")
+	git("config", "user.email", "test@example.com")
+	write("nothing to see here\n")
+	git("add", "config.txt")
+	git("commit", "-q", "-m", "initial commit")
+
+	// A commit that is reset away and expired from the reflogs is dangling.
+	write("dangling-commit-secret\n")
+	git("commit", "-q", "-am", "dangling")
+	git("reset", "-q", "--hard", "HEAD~1")
+	git("reflog", "expire", "--expire=now", "--all")
+
+	// A commit that is reset away is still in the reflogs.
+	write("reflog-secret\n")
+	git("commit", "-q", "-am", "reflog")
+	git("reset", "-q", "--hard", "HEAD~1")
+
+	// Only the latest stash entry is referenced by refs/stash.
+	write("old-stash-secret\n")
+	git("stash", "-q")
+	write("stash-secret\n")
+	git("stash", "-q")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob.txt"), []byte("dangling-blob-secret\n"), 0o644))
+	git("hash-object", "-w", "blob.txt")
+	require.NoError(t, os.Remove(filepath.Join(dir, "blob.txt")))
+	return dir
+}
+
+func TestScanUnreachable(t *testing.T) {
+	ctx := context.Background()
+	dir := newUnreachableRepo(t)
+
+	scan := func(conn *sourcespb.Git) map[string]string {
+		t.Helper()
+		conn.Directories = []string{dir}
+		anyConn, err := anypb.New(conn)
+		require.NoError(t, err)
+
+		s := Source{}
+		require.NoError(t, s.Init(ctx, "test unreachable", 0, 0, false, anyConn, 1))
+		reporter := sourcestest.TestReporter{}
+		require.NoError(t, s.ChunkUnit(ctx, SourceUnit{ID: dir, Kind: UnitDir}, &reporter))
+		require.Empty(t, reporter.ChunkErrs)
+
+		// The origin of the chunk each secret was found in.
+		found := make(map[string]string)
+		for _, chunk := range reporter.Chunks {
+			for _, line := range strings.Split(string(chunk.Data), "\n") {
+				if strings.HasSuffix(line, "-secret") {
+					found[line] = chunk.SourceMetadata.GetGit().GetOrigin()
+				}
+			}
+		}
+		return found
+	}
+
+	assert.Empty(t, scan(&sourcespb.Git{}))
+	assert.Equal(t, map[string]string{
+		"stash-secret":     originStash,
+		"old-stash-secret": originStash,
+	}, scan(&sourcespb.Git{Stashes: true}))
+	assert.Equal(t, map[string]string{
+		"reflog-secret": originReflog,
+	}, scan(&sourcespb.Git{Reflog: true}))
+	assert.Equal(t, map[string]string{
+		"dangling-commit-secret": originDangling,
+		"dangling-blob-secret":   originDangling,
+	}, scan(&sourcespb.Git{Dangling: true}))
+}
@@ -296,6 +296,7 @@ func (s *Source) Init(aCtx context.Context, name string, jobID sources.JobID, so
 				},
 			}
 		},
+		CloneFunc:              s.connector.Clone,
 		UseCustomContentWriter: s.useCustomContentWriter,
 		AuthInUrl:              s.useAuthInUrl,
 	}
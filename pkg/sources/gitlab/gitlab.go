@@ -217,6 +217,7 @@ func (s *Source) Init(ctx context.Context, name string, jobId sources.JobID, sou
 				},
 			}
 		},
+		CloneFunc:              s.cloneRepo,
 		UseCustomContentWriter: s.useCustomContentWriter,
 		AuthInUrl:              s.useAuthInUrl,
 	}
@@ -650,17 +651,7 @@ func (s *Source) scanRepos(ctx context.Context, chunksChan chan *sources.Chunk)
 			var path string
 			var repo *gogit.Repository
 			var err error
-			if s.authMethod == "UNAUTHENTICATED" {
-				path, repo, err = git.CloneRepoUsingUnauthenticated(ctx, repoURL, "")
-			} else {
-				// If a username is not provided we need to use a default one in order to clone a private repo.
-				// Not setting "placeholder" as s.user on purpose in case any downstream services rely on a "" value for s.user.
-				user := s.user
-				if user == "" {
-					user = "placeholder"
-				}
-				path, repo, err = git.CloneRepoUsingToken(ctx, s.token, repoURL, "", user, s.useAuthInUrl)
-			}
+			path, repo, err = s.cloneRepo(ctx, repoURL, "")
 			if err != nil {
 				scanErrs.Add(err)
 				return nil
@@ -826,6 +817,20 @@ func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) e
 	return s.getAllProjectRepos(ctx, apiClient, ignoreRepo, reporter)
 }
 
+// cloneRepo clones a repository with the configured authentication.
+func (s *Source) cloneRepo(ctx context.Context, repoURL, dir string, args ...string) (string, *gogit.Repository, error) {
+	if s.authMethod == "UNAUTHENTICATED" {
+		return git.CloneRepoUsingUnauthenticated(ctx, repoURL, dir, args...)
+	}
+	// If a username is not provided we need to use a default one in order to clone a private repo.
+	// Not setting "placeholder" as s.user on purpose in case any downstream services rely on a "" value for s.user.
+	user := s.user
+	if user == "" {
+		user = "placeholder"
+	}
+	return git.CloneRepoUsingToken(ctx, s.token, repoURL, dir, user, s.useAuthInUrl, args...)
+}
+
 // ChunkUnit downloads and reports chunks for the given GitLab repository unit.
 func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
 	repoURL, _ := unit.SourceUnitID()
@@ -833,17 +838,7 @@ func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporte
 	var path string
 	var repo *gogit.Repository
 	var err error
-	if s.authMethod == "UNAUTHENTICATED" {
-		path, repo, err = git.CloneRepoUsingUnauthenticated(ctx, repoURL, "")
-	} else {
-		// If a username is not provided we need to use a default one in order to clone a private repo.
-		// Not setting "placeholder" as s.user on purpose in case any downstream services rely on a "" value for s.user.
-		user := s.user
-		if user == "" {
-			user = "placeholder"
-		}
-		path, repo, err = git.CloneRepoUsingToken(ctx, s.token, repoURL, "", user, s.useAuthInUrl)
-	}
+	path, repo, err = s.cloneRepo(ctx, repoURL, "")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,396 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// maxResults is the number of issues, comments or changelog entries
+// requested per page.
+const maxResults = 50
+
+// issueFields are the issue fields requested from the search API.
+const issueFields = "summary,description,comment,attachment,reporter,created"
+
+// apiClient is a minimal client for the Jira REST API (v2), which is shared
+// by Jira Cloud and Data Center. Issues are searched through the v3 API on
+// Jira Cloud, where the v2 search is deprecated.
+type apiClient struct {
+	httpClient *http.Client
+	baseURL    string
+	authorize  func(req *http.Request)
+	// cloud is set for Jira Cloud sites.
+	cloud bool
+}
+
+// isCloud reports whether the base URL belongs to a Jira Cloud site.
+func isCloud(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.HasSuffix(host, ".atlassian.net") || host == "api.atlassian.com"
+}
+
+type user struct {
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type project struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// richText is plain text in the v2 API and an Atlassian Document Format
+// (ADF) document in the v3 API. Either way, it unmarshals to its text.
+type richText string
+
+func (t *richText) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = richText(text)
+		return nil
+	}
+
+	var doc adfNode
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	doc.writeText(&sb)
+	*t = richText(sb.String())
+	return nil
+}
+
+// adfNode is a node of an Atlassian Document Format document.
+type adfNode struct {
+	Type    string         `json:"type"`
+	Text    string         `json:"text"`
+	Attrs   map[string]any `json:"attrs"`
+	Marks   []adfNode      `json:"marks"`
+	Content []adfNode      `json:"content"`
+}
+
+// writeText writes the text of the node and its children, including link
+// targets, with a newline after every block.
+func (n adfNode) writeText(sb *strings.Builder) {
+	sb.WriteString(n.Text)
+	for _, attrs := range append([]map[string]any{n.Attrs}, marksAttrs(n.Marks)...) {
+		for _, key := range []string{"url", "href"} {
+			if v, ok := attrs[key].(string); ok && v != "" {
+				sb.WriteString(" " + v)
+			}
+		}
+	}
+	for _, child := range n.Content {
+		child.writeText(sb)
+	}
+	if n.Type != "text" {
+		sb.WriteString("\n")
+	}
+}
+
+func marksAttrs(marks []adfNode) []map[string]any {
+	attrs := make([]map[string]any, 0, len(marks))
+	for _, m := range marks {
+		attrs = append(attrs, m.Attrs)
+	}
+	return attrs
+}
+
+type comment struct {
+	ID      string   `json:"id"`
+	Author  user     `json:"author"`
+	Body    richText `json:"body"`
+	Created string   `json:"created"`
+}
+
+type attachment struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Author   user   `json:"author"`
+	Created  string `json:"created"`
+	// Content is the absolute download URL of the attachment.
+	Content string `json:"content"`
+}
+
+type changelogItem struct {
+	Field      string `json:"field"`
+	FromString string `json:"fromString"`
+	ToString   string `json:"toString"`
+}
+
+type history struct {
+	ID      string          `json:"id"`
+	Author  user            `json:"author"`
+	Created string          `json:"created"`
+	Items   []changelogItem `json:"items"`
+}
+
+// page is the pagination info of a paged list.
+type page struct {
+	StartAt    int `json:"startAt"`
+	MaxResults int `json:"maxResults"`
+	Total      int `json:"total"`
+}
+
+type issue struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string   `json:"summary"`
+		Description richText `json:"description"`
+		Created     string   `json:"created"`
+		Reporter    user     `json:"reporter"`
+		// Comment holds the first page of comments only.
+		Comment struct {
+			page
+			Comments []comment `json:"comments"`
+		} `json:"comment"`
+		Attachment []attachment `json:"attachment"`
+	} `json:"fields"`
+	// Changelog holds the first page of the changelog only.
+	Changelog struct {
+		page
+		Histories []history `json:"histories"`
+	} `json:"changelog"`
+}
+
+type searchResults struct {
+	page
+	Issues []issue `json:"issues"`
+}
+
+// searchJQLResults is a page of the v3 enhanced JQL search, which is paged
+// by token rather than offset.
+type searchJQLResults struct {
+	Issues        []issue `json:"issues"`
+	NextPageToken string  `json:"nextPageToken"`
+	IsLast        bool    `json:"isLast"`
+}
+
+type commentResults struct {
+	page
+	Comments []comment `json:"comments"`
+}
+
+type changelogResults struct {
+	page
+	Values []history `json:"values"`
+}
+
+// getJSON performs a GET request against the given API path and decodes the
+// JSON response into target.
+func (c *apiClient) getJSON(ctx context.Context, path string, query url.Values, target any) error {
+	resp, err := c.get(ctx, c.baseURL+path, query)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Jira API response: %w", err)
+	}
+	return nil
+}
+
+// get performs an authenticated GET request and returns the response if it
+// was successful. The caller is responsible for closing the body.
+func (c *apiClient) get(ctx context.Context, reqURL string, query url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jira API request: %w", err)
+	}
+	if query != nil {
+		req.URL.RawQuery = query.Encode()
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Jira API: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %q", resp.StatusCode, req.URL.Path)
+	}
+	return resp, nil
+}
+
+// currentUser checks that the configured credentials are accepted.
+func (c *apiClient) currentUser(ctx context.Context) error {
+	return c.getJSON(ctx, "/rest/api/2/myself", nil, &user{})
+}
+
+// listProjects returns every project visible to the configured credentials.
+func (c *apiClient) listProjects(ctx context.Context) ([]project, error) {
+	var projects []project
+	if err := c.getJSON(ctx, "/rest/api/2/project", nil, &projects); err != nil {
+		return nil, fmt.Errorf("error listing projects: %w", err)
+	}
+	return projects, nil
+}
+
+// getProject returns a single project by key.
+func (c *apiClient) getProject(ctx context.Context, key string) (project, error) {
+	var p project
+	err := c.getJSON(ctx, "/rest/api/2/project/"+url.PathEscape(key), nil, &p)
+	return p, err
+}
+
+// listIssues visits every issue in the project, including its comments,
+// attachments and changelog.
+func (c *apiClient) listIssues(ctx context.Context, projectKey string, visit func(issue) error) error {
+	query := url.Values{
+		"jql":        []string{fmt.Sprintf("project = %q ORDER BY key ASC", projectKey)},
+		"fields":     []string{issueFields},
+		"expand":     []string{"changelog"},
+		"maxResults": []string{strconv.Itoa(maxResults)},
+	}
+	visitComplete := func(i issue) error {
+		if err := c.completeIssue(ctx, &i); err != nil {
+			return err
+		}
+		return visit(i)
+	}
+	if c.cloud {
+		return c.searchJQL(ctx, projectKey, query, visitComplete)
+	}
+
+	for startAt := 0; ; {
+		query.Set("startAt", strconv.Itoa(startAt))
+
+		var page searchResults
+		if err := c.getJSON(ctx, "/rest/api/2/search", query, &page); err != nil {
+			return fmt.Errorf("error searching issues in project %q: %w", projectKey, err)
+		}
+		for _, i := range page.Issues {
+			if err := visitComplete(i); err != nil {
+				return err
+			}
+		}
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			return nil
+		}
+	}
+}
+
+// searchJQL visits every issue matched by the query through the v3 enhanced
+// JQL search of Jira Cloud.
+func (c *apiClient) searchJQL(ctx context.Context, projectKey string, query url.Values, visit func(issue) error) error {
+	for {
+		var page searchJQLResults
+		if err := c.getJSON(ctx, "/rest/api/3/search/jql", query, &page); err != nil {
+			return fmt.Errorf("error searching issues in project %q: %w", projectKey, err)
+		}
+		for _, i := range page.Issues {
+			if err := visit(i); err != nil {
+				return err
+			}
+		}
+		if page.IsLast || page.NextPageToken == "" || len(page.Issues) == 0 {
+			return nil
+		}
+		query.Set("nextPageToken", page.NextPageToken)
+	}
+}
+
+// completeIssue fetches the comments and changelog entries that didn't fit in
+// the first page returned with the issue.
+func (c *apiClient) completeIssue(ctx context.Context, i *issue) error {
+	if comments := &i.Fields.Comment; comments.Total > len(comments.Comments) {
+		all, err := c.listComments(ctx, i.Key)
+		if err != nil {
+			return err
+		}
+		comments.Comments = all
+	}
+	if changelog := &i.Changelog; changelog.Total > len(changelog.Histories) {
+		all, err := c.listChangelog(ctx, i.Key)
+		if err != nil {
+			return err
+		}
+		changelog.Histories = all
+	}
+	return nil
+}
+
+// listComments returns every comment of the issue.
+func (c *apiClient) listComments(ctx context.Context, issueKey string) ([]comment, error) {
+	var comments []comment
+	path := "/rest/api/2/issue/" + url.PathEscape(issueKey) + "/comment"
+	for startAt := 0; ; {
+		query := url.Values{
+			"startAt":    []string{strconv.Itoa(startAt)},
+			"maxResults": []string{strconv.Itoa(maxResults)},
+		}
+		var page commentResults
+		if err := c.getJSON(ctx, path, query, &page); err != nil {
+			return nil, fmt.Errorf("error listing comments of issue %q: %w", issueKey, err)
+		}
+		comments = append(comments, page.Comments...)
+		startAt += len(page.Comments)
+		if len(page.Comments) == 0 || startAt >= page.Total {
+			return comments, nil
+		}
+	}
+}
+
+// listChangelog returns every changelog entry of the issue. Data Center has
+// no paged changelog endpoint, but returns the complete changelog when it is
+// expanded on a single issue.
+func (c *apiClient) listChangelog(ctx context.Context, issueKey string) ([]history, error) {
+	if !c.cloud {
+		var i issue
+		query := url.Values{"fields": []string{"none"}, "expand": []string{"changelog"}}
+		if err := c.getJSON(ctx, "/rest/api/2/issue/"+url.PathEscape(issueKey), query, &i); err != nil {
+			return nil, fmt.Errorf("error getting changelog of issue %q: %w", issueKey, err)
+		}
+		return i.Changelog.Histories, nil
+	}
+
+	var histories []history
+	path := "/rest/api/2/issue/" + url.PathEscape(issueKey) + "/changelog"
+	for startAt := 0; ; {
+		query := url.Values{
+			"startAt":    []string{strconv.Itoa(startAt)},
+			"maxResults": []string{strconv.Itoa(maxResults)},
+		}
+		var page changelogResults
+		if err := c.getJSON(ctx, path, query, &page); err != nil {
+			return nil, fmt.Errorf("error listing changelog of issue %q: %w", issueKey, err)
+		}
+		histories = append(histories, page.Values...)
+		startAt += len(page.Values)
+		if len(page.Values) == 0 || startAt >= page.Total {
+			return histories, nil
+		}
+	}
+}
+
+// download fetches an attachment from its absolute content URL.
+func (c *apiClient) download(ctx context.Context, contentURL string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, contentURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
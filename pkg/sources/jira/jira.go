@@ -0,0 +1,365 @@
+package jira
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/roundtripper"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_JIRA
+
+const unitKindProject = "project"
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	baseURL        string
+	projects       []string
+	ignoreProjects map[string]struct{}
+
+	client *apiClient
+
+	resumeInfoMutex sync.Mutex
+	resumeInfoSlice []string
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Jira source.
+func (s *Source) Init(ctx context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.JIRA
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.baseURL = strings.TrimRight(conn.GetEndpoint(), "/")
+	if _, err := url.ParseRequestURI(s.baseURL); err != nil {
+		return fmt.Errorf("invalid Jira endpoint %q: %w", conn.GetEndpoint(), err)
+	}
+	s.projects = conn.GetProjects()
+	s.ignoreProjects = make(map[string]struct{}, len(conn.GetIgnoreProjects()))
+	for _, key := range conn.GetIgnoreProjects() {
+		s.ignoreProjects[strings.ToUpper(key)] = struct{}{}
+	}
+
+	var authorize func(req *http.Request)
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.JIRA_Unauthenticated:
+	case *sourcespb.JIRA_BasicAuth:
+		user, password := cred.BasicAuth.GetUsername(), cred.BasicAuth.GetPassword()
+		if password == "" {
+			return fmt.Errorf("invalid configuration given for source %q (%s): empty password", name, s.Type().String())
+		}
+		log.RedactGlobally(password)
+		authorize = func(req *http.Request) { req.SetBasicAuth(user, password) }
+	case *sourcespb.JIRA_Token:
+		authorize = bearerAuth(cred.Token)
+	case *sourcespb.JIRA_Oauth:
+		authorize = bearerAuth(cred.Oauth.GetAccessToken())
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	if authorize == nil && conn.GetUnauthenticated() == nil {
+		return fmt.Errorf("invalid configuration given for source %q (%s): empty token", name, s.Type().String())
+	}
+
+	var opts []func(*roundtripper.RoundTripper)
+	// If the user has specified to skip TLS verification, we add the WithInsecureTLS option.
+	if conn.GetInsecureSkipVerifyTls() {
+		opts = append(opts, roundtripper.WithInsecureTLS())
+	}
+	const retryDelay = time.Second * 30
+	opts = append(opts,
+		roundtripper.WithLogger(ctx.Logger()),
+		roundtripper.WithRetryable(
+			roundtripper.WithShouldRetry5XXDuration(retryDelay),
+		),
+	)
+
+	s.client = &apiClient{
+		httpClient: &http.Client{Transport: roundtripper.NewRoundTripper(nil, opts...)},
+		baseURL:    s.baseURL,
+		authorize:  authorize,
+		cloud:      isCloud(s.baseURL),
+	}
+
+	return nil
+}
+
+// bearerAuth returns an authorizer for the given token, or nil if the token
+// is empty.
+func bearerAuth(token string) func(req *http.Request) {
+	if token == "" {
+		return nil
+	}
+	log.RedactGlobally(token)
+	return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+}
+
+// Validate checks that the configured credentials are accepted and that any
+// explicitly configured projects exist.
+func (s *Source) Validate(ctx context.Context) []error {
+	if s.client.authorize != nil {
+		if err := s.client.currentUser(ctx); err != nil {
+			return []error{fmt.Errorf("jira authentication failed: %w", err)}
+		}
+	}
+
+	var errs []error
+	for _, key := range s.projects {
+		if _, err := s.client.getProject(ctx, key); err != nil {
+			errs = append(errs, fmt.Errorf("could not get Jira project %q: %w", key, err))
+		}
+	}
+	return errs
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var projects []string
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			key, _ := unit.SourceUnitID()
+			projects = append(projects, key)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+	sort.Strings(projects)
+
+	// If there is resume information available, limit this scan to only the projects that still need scanning.
+	projectsToScan, progressIndexOffset := sources.FilterReposToResume(projects, s.GetProgress().EncodedResumeInfo)
+
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, key := range projectsToScan {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			s.setProgressCompleteWithProject(i, progressIndexOffset, len(projects), key)
+			// Ensure the project is removed from the resume info after being scanned.
+			defer func() {
+				s.resumeInfoMutex.Lock()
+				defer s.resumeInfoMutex.Unlock()
+				s.resumeInfoSlice = sources.RemoveRepoFromResumeInfo(s.resumeInfoSlice, key)
+			}()
+
+			unit := sources.CommonSourceUnit{Kind: unitKindProject, ID: key}
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+			}
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(projects), len(projects), "Completed Jira scan", "")
+
+	return nil
+}
+
+// setProgressCompleteWithProject calls the s.SetProgressComplete after safely setting up the encoded resume info string.
+func (s *Source) setProgressCompleteWithProject(index, offset, total int, key string) {
+	s.resumeInfoMutex.Lock()
+	defer s.resumeInfoMutex.Unlock()
+
+	// Add the project to the resume info slice.
+	s.resumeInfoSlice = append(s.resumeInfoSlice, key)
+	sort.Strings(s.resumeInfoSlice)
+
+	// Make the resume info string from the slice.
+	encodedResumeInfo := sources.EncodeResumeInfo(s.resumeInfoSlice)
+
+	s.SetProgressComplete(index+offset, total, fmt.Sprintf("Project: %s", key), encodedResumeInfo)
+}
+
+// Enumerate reports every Jira project to be scanned. If no projects are
+// configured, all projects visible to the credentials are listed.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	keys := s.projects
+	if len(keys) == 0 {
+		projects, err := s.client.listProjects(ctx)
+		if err != nil {
+			return err
+		}
+		for _, p := range projects {
+			keys = append(keys, p.Key)
+		}
+	}
+
+	for _, key := range keys {
+		if _, ok := s.ignoreProjects[strings.ToUpper(key)]; ok {
+			ctx.Logger().V(3).Info("skipping project", "project", key, "reason", "ignored in config")
+			continue
+		}
+		if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{Kind: unitKindProject, ID: key}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChunkUnit scans the description, comments, changelog and attachments of
+// every issue in a project.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	key, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "project", key)
+
+	return s.client.listIssues(ctx, key, func(is issue) error {
+		if common.IsDone(ctx) {
+			return ctx.Err()
+		}
+		return s.scanIssue(ctx, is, reporter)
+	})
+}
+
+func (s *Source) scanIssue(ctx context.Context, is issue, reporter sources.ChunkReporter) error {
+	issueLink := s.baseURL + "/browse/" + is.Key
+
+	description := is.Fields.Summary + "\n" + string(is.Fields.Description)
+	if err := reporter.ChunkOk(ctx, s.chunk(is.Key, "description", issueLink, is.Fields.Reporter, is.Fields.Created, description)); err != nil {
+		return err
+	}
+
+	for _, c := range is.Fields.Comment.Comments {
+		link := fmt.Sprintf("%s?focusedCommentId=%s", issueLink, url.QueryEscape(c.ID))
+		if err := reporter.ChunkOk(ctx, s.chunk(is.Key, "comment", link, c.Author, c.Created, string(c.Body))); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range is.Changelog.Histories {
+		var sb strings.Builder
+		for _, item := range h.Items {
+			if item.FromString == "" && item.ToString == "" {
+				continue
+			}
+			fmt.Fprintf(&sb, "%s\n%s\n%s\n", item.Field, item.FromString, item.ToString)
+		}
+		if sb.Len() == 0 {
+			continue
+		}
+		link := issueLink + "?page=com.atlassian.jira.plugin.system.issuetabpanels:changehistory-tabpanel"
+		if err := reporter.ChunkOk(ctx, s.chunk(is.Key, "changelog", link, h.Author, h.Created, sb.String())); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range is.Fields.Attachment {
+		if err := s.scanAttachment(ctx, is.Key, a, reporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Source) chunk(issueKey, location, link string, author user, timestamp, data string) sources.Chunk {
+	return sources.Chunk{
+		SourceType:     s.Type(),
+		SourceName:     s.name,
+		SourceID:       s.sourceID,
+		JobID:          s.jobID,
+		SourceMetadata: s.metadata(issueKey, location, link, author, timestamp),
+		Data:           []byte(data),
+		Verify:         s.verify,
+	}
+}
+
+func (s *Source) metadata(issueKey, location, link string, author user, timestamp string) *source_metadatapb.MetaData {
+	return &source_metadatapb.MetaData{
+		Data: &source_metadatapb.MetaData_Jira{
+			Jira: &source_metadatapb.Jira{
+				Issue:     issueKey,
+				Author:    sanitizer.UTF8(author.DisplayName),
+				Link:      link,
+				Location:  location,
+				Email:     sanitizer.UTF8(author.EmailAddress),
+				Timestamp: sanitizer.UTF8(timestamp),
+			},
+		},
+	}
+}
+
+// scanAttachment downloads an attachment and passes it through the file
+// handlers. Download and handling errors are reported to the reporter.
+func (s *Source) scanAttachment(ctx context.Context, issueKey string, a attachment, reporter sources.ChunkReporter) error {
+	if a.Content == "" {
+		return nil
+	}
+	fileCtx := context.WithValues(ctx, "issue", issueKey, "attachment", a.Filename)
+
+	rc, err := s.client.download(fileCtx, a.Content)
+	if err != nil {
+		return reporter.ChunkErr(fileCtx, fmt.Errorf("error downloading attachment %q: %w", a.Filename, err))
+	}
+	defer rc.Close()
+
+	chunkSkel := &sources.Chunk{
+		SourceType:     s.Type(),
+		SourceName:     s.name,
+		SourceID:       s.sourceID,
+		JobID:          s.jobID,
+		SourceMetadata: s.metadata(issueKey, "attachment", a.Content, a.Author, a.Created),
+		Verify:         s.verify,
+	}
+	if err := handlers.HandleFile(fileCtx, rc, chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(fileCtx, fmt.Errorf("error handling attachment %q: %w", a.Filename, err))
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package jira
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer jira-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/rest/api/2/myself":
+			sourcestest.WriteJSON(t, w, map[string]any{"displayName": "Me"})
+		case "/rest/api/2/project":
+			sourcestest.WriteJSON(t, w, []map[string]any{{"key": "OPS"}, {"key": "DEV"}, {"key": "OLD"}})
+		case "/rest/api/2/search":
+			if r.URL.Query().Get("jql") != `project = "OPS" ORDER BY key ASC` {
+				sourcestest.WriteJSON(t, w, map[string]any{"total": 0})
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"startAt": 0,
+				"total":   1,
+				"issues": []any{map[string]any{
+					"id":  "10001",
+					"key": "OPS-1",
+					"fields": map[string]any{
+						"summary":     "Rotate keys",
+						"description": "old key: hunter2",
+						"created":     "2024-01-01T00:00:00.000+0000",
+						"reporter":    map[string]any{"displayName": "Alice", "emailAddress": "alice@example.com"},
+						"comment": map[string]any{"comments": []any{
+							map[string]any{"id": "7", "body": "new key: hunter3", "author": map[string]any{"displayName": "Bob"}},
+						}},
+						"attachment": []any{map[string]any{
+							"id":       "99",
+							"filename": "env.txt",
+							"content":  srv.URL + "/secure/attachment/99/env.txt",
+						}},
+					},
+					"changelog": map[string]any{"histories": []any{map[string]any{
+						"id":    "1",
+						"items": []any{map[string]any{"field": "description", "fromString": "first key", "toString": "old key: hunter2"}},
+					}}},
+				}},
+			})
+		case "/secure/attachment/99/env.txt":
+			_, _ = w.Write([]byte("TOKEN=attached"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func initSource(t *testing.T, conn *sourcespb.JIRA) *Source {
+	t.Helper()
+	if conn.Credential == nil {
+		conn.Credential = &sourcespb.JIRA_Token{Token: "jira-token"}
+	}
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	return s
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, &sourcespb.JIRA{Endpoint: srv.URL, IgnoreProjects: []string{"old"}})
+	assert.Empty(t, s.Validate(context.Background()))
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{Kind: unitKindProject, ID: "OPS"},
+		sources.CommonSourceUnit{Kind: unitKindProject, ID: "DEV"},
+	}, reporter.Units)
+}
+
+func TestSource_ChunkUnit(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, &sourcespb.JIRA{Endpoint: srv.URL})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindProject, ID: "OPS"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 4)
+
+	var locations, links []string
+	for _, chunk := range reporter.Chunks {
+		meta := chunk.SourceMetadata.GetJira()
+		require.NotNil(t, meta)
+		assert.Equal(t, "OPS-1", meta.Issue)
+		locations = append(locations, meta.Location)
+		links = append(links, meta.Link)
+	}
+	assert.Equal(t, []string{"description", "comment", "changelog", "attachment"}, locations)
+	assert.Equal(t, srv.URL+"/browse/OPS-1", links[0])
+	assert.Equal(t, srv.URL+"/browse/OPS-1?focusedCommentId=7", links[1])
+
+	assert.Equal(t, "alice@example.com", reporter.Chunks[0].SourceMetadata.GetJira().Email)
+	assert.Contains(t, string(reporter.Chunks[2].Data), "first key")
+	assert.Equal(t, "TOKEN=attached", string(reporter.Chunks[3].Data))
+}
+
+// newPagedServer serves a project with one issue per search page, whose
+// comments and changelog don't fit in the search results.
+func newPagedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	comments := []any{
+		map[string]any{"id": "1", "body": "comment 1"},
+		map[string]any{"id": "2", "body": "comment 2"},
+		map[string]any{"id": "3", "body": "comment 3"},
+	}
+	histories := []any{
+		map[string]any{"id": "1", "items": []any{map[string]any{"field": "summary", "toString": "history 1"}}},
+		map[string]any{"id": "2", "items": []any{map[string]any{"field": "summary", "toString": "history 2"}}},
+	}
+	newIssue := func(key string, description any) map[string]any {
+		return map[string]any{
+			"key": key,
+			"fields": map[string]any{
+				"summary":     key,
+				"description": description,
+				"comment":     map[string]any{"total": len(comments), "comments": comments[:1]},
+			},
+			"changelog": map[string]any{"total": len(histories), "histories": histories[:1]},
+		}
+	}
+	// pageOf returns the page of items requested by the startAt query parameter.
+	pageOf := func(r *http.Request, items []any) (int, []any) {
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		return startAt, items[startAt:min(startAt+2, len(items))]
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			sourcestest.WriteJSON(t, w, map[string]any{"total": 1, "issues": []any{newIssue("OPS-1", "plain description")}})
+		case "/rest/api/3/search/jql":
+			if r.URL.Query().Get("nextPageToken") == "" {
+				adf := map[string]any{"type": "doc", "content": []any{map[string]any{
+					"type":    "paragraph",
+					"content": []any{map[string]any{"type": "text", "text": "adf description"}},
+				}}}
+				sourcestest.WriteJSON(t, w, map[string]any{"nextPageToken": "next", "issues": []any{newIssue("OPS-1", adf)}})
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{"isLast": true, "issues": []any{newIssue("OPS-2", nil)}})
+		case "/rest/api/2/issue/OPS-1/comment", "/rest/api/2/issue/OPS-2/comment":
+			startAt, page := pageOf(r, comments)
+			sourcestest.WriteJSON(t, w, map[string]any{"startAt": startAt, "total": len(comments), "comments": page})
+		case "/rest/api/2/issue/OPS-1/changelog", "/rest/api/2/issue/OPS-2/changelog":
+			startAt, page := pageOf(r, histories)
+			sourcestest.WriteJSON(t, w, map[string]any{"startAt": startAt, "total": len(histories), "values": page})
+		case "/rest/api/2/issue/OPS-1":
+			sourcestest.WriteJSON(t, w, map[string]any{"key": "OPS-1", "changelog": map[string]any{"total": len(histories), "histories": histories}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSource_ChunkUnit_Paged(t *testing.T) {
+	tests := []struct {
+		name  string
+		cloud bool
+		want  []string
+	}{
+		{
+			name: "data center",
+			want: []string{
+				"OPS-1\nplain description", "comment 1", "comment 2", "comment 3", "summary\n\nhistory 1\n", "summary\n\nhistory 2\n",
+			},
+		},
+		{
+			name:  "cloud",
+			cloud: true,
+			want: []string{
+				"OPS-1\nadf description\n\n", "comment 1", "comment 2", "comment 3", "summary\n\nhistory 1\n", "summary\n\nhistory 2\n",
+				"OPS-2\n", "comment 1", "comment 2", "comment 3", "summary\n\nhistory 1\n", "summary\n\nhistory 2\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newPagedServer(t)
+			s := initSource(t, &sourcespb.JIRA{Endpoint: srv.URL})
+			s.client.cloud = tt.cloud
+
+			reporter := sourcestest.TestReporter{}
+			unit := sources.CommonSourceUnit{Kind: unitKindProject, ID: "OPS"}
+			require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+			require.Empty(t, reporter.ChunkErrs)
+
+			var got []string
+			for _, chunk := range reporter.Chunks {
+				got = append(got, string(chunk.Data))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSource_Chunks_Resume(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, &sourcespb.JIRA{Endpoint: srv.URL})
+	// DEV finished and OLD was in progress when the scan stopped; OLD and OPS
+	// (which sorts after it) must be scanned.
+	s.SetProgressComplete(0, 3, "", sources.EncodeResumeInfo([]string{"OLD"}))
+
+	chunksCh := make(chan *sources.Chunk, 16)
+	require.NoError(t, s.Chunks(context.Background(), chunksCh))
+	close(chunksCh)
+
+	var issues []string
+	for chunk := range chunksCh {
+		issues = append(issues, chunk.SourceMetadata.GetJira().Issue)
+	}
+	assert.Len(t, issues, 4)
+	assert.Equal(t, "", s.GetProgress().EncodedResumeInfo)
+}
+
+func TestSource_Validate_BadCredentials(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, &sourcespb.JIRA{
+		Endpoint:   srv.URL,
+		Credential: &sourcespb.JIRA_Token{Token: "wrong"},
+	})
+	assert.NotEmpty(t, s.Validate(context.Background()))
+}
+
+func TestSource_ChunkUnit_OverstatedTotal(t *testing.T) {
+	// Issues can be deleted or hidden between pages, so the reported total may
+	// never be reached. An empty page must end the search.
+	var starts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/2/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		startAt := r.URL.Query().Get("startAt")
+		starts = append(starts, startAt)
+		var issues []any
+		if startAt != "2" {
+			issues = append(issues, map[string]any{"key": "OPS-" + startAt, "fields": map[string]any{"summary": "issue"}})
+		}
+		sourcestest.WriteJSON(t, w, map[string]any{"total": 5, "issues": issues})
+	}))
+	t.Cleanup(srv.Close)
+	s := initSource(t, &sourcespb.JIRA{Endpoint: srv.URL})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindProject, ID: "OPS"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	assert.Len(t, reporter.Chunks, 2)
+	assert.Equal(t, []string{"0", "1", "2"}, starts)
+}
+
+func TestSource_ChunkUnit_ForbiddenAttachment(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			sourcestest.WriteJSON(t, w, map[string]any{"total": 1, "issues": []any{map[string]any{
+				"key": "OPS-1",
+				"fields": map[string]any{
+					"summary": "Restricted",
+					"attachment": []any{
+						map[string]any{"id": "1", "filename": "secret.txt", "content": srv.URL + "/secure/attachment/1/secret.txt"},
+						map[string]any{"id": "2", "filename": "public.txt", "content": srv.URL + "/secure/attachment/2/public.txt"},
+					},
+				},
+			}}})
+		case "/secure/attachment/1/secret.txt":
+			w.WriteHeader(http.StatusForbidden)
+		case "/secure/attachment/2/public.txt":
+			_, _ = w.Write([]byte("public"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s := initSource(t, &sourcespb.JIRA{Endpoint: srv.URL})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindProject, ID: "OPS"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+
+	require.Len(t, reporter.ChunkErrs, 1)
+	assert.ErrorContains(t, reporter.ChunkErrs[0], `"secret.txt"`)
+	assert.ErrorContains(t, reporter.ChunkErrs[0], "403")
+	require.Len(t, reporter.Chunks, 2)
+	assert.Equal(t, "public", string(reporter.Chunks[1].Data))
+}
@@ -0,0 +1,126 @@
+package npm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// searchPageLen is the maximum number of results the search API returns per page.
+const searchPageLen = 250
+
+// client is a minimal client for the npm registry API.
+// https://github.com/npm/registry/blob/main/docs/REGISTRY-API.md
+type client struct {
+	// httpClient is used for API requests and downloadClient for tarballs,
+	// which are not subject to a timeout.
+	httpClient     *http.Client
+	downloadClient *http.Client
+	registry       string
+}
+
+type packument struct {
+	Name     string                    `json:"name"`
+	Versions map[string]packageVersion `json:"versions"`
+}
+
+type packageVersion struct {
+	Version string `json:"version"`
+	Dist    struct {
+		Tarball string `json:"tarball"`
+	} `json:"dist"`
+	NPMUser struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"_npmUser"`
+}
+
+// get performs a GET request and returns the response if it was successful.
+// The caller is responsible for closing the body.
+func (c *client) get(ctx context.Context, httpClient *http.Client, reqURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create npm registry request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to npm registry: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %q", resp.StatusCode, req.URL.Path)
+	}
+	return resp, nil
+}
+
+func (c *client) getJSON(ctx context.Context, reqURL string, target any) error {
+	resp, err := c.get(ctx, c.httpClient, reqURL)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode npm registry response: %w", err)
+	}
+	return nil
+}
+
+// getPackage returns the metadata of every published version of a package.
+func (c *client) getPackage(ctx context.Context, name string) (packument, error) {
+	var pkg packument
+	if err := c.getJSON(ctx, c.registry+"/"+url.PathEscape(name), &pkg); err != nil {
+		return pkg, fmt.Errorf("error getting package %q: %w", name, err)
+	}
+	return pkg, nil
+}
+
+// listMaintainerPackages returns the names of the packages maintained by the
+// user.
+func (c *client) listMaintainerPackages(ctx context.Context, maintainer string) ([]string, error) {
+	var names []string
+	for from := 0; ; from += searchPageLen {
+		query := url.Values{
+			"text": []string{"maintainer:" + maintainer},
+			"size": []string{strconv.Itoa(searchPageLen)},
+			"from": []string{strconv.Itoa(from)},
+		}
+		var page struct {
+			Objects []struct {
+				Package struct {
+					Name string `json:"name"`
+				} `json:"package"`
+			} `json:"objects"`
+			Total int `json:"total"`
+		}
+		if err := c.getJSON(ctx, c.registry+"/-/v1/search?"+query.Encode(), &page); err != nil {
+			return nil, fmt.Errorf("error searching packages of maintainer %q: %w", maintainer, err)
+		}
+		for _, obj := range page.Objects {
+			names = append(names, obj.Package.Name)
+		}
+		if len(page.Objects) == 0 || from+len(page.Objects) >= page.Total {
+			return names, nil
+		}
+	}
+}
+
+// download returns the contents of a tarball. The caller is responsible for
+// closing the returned reader.
+func (c *client) download(ctx context.Context, tarball string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, c.downloadClient, tarball)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,16 @@
+package npm
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var npmVersionsScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: common.MetricsNamespace,
+	Subsystem: common.MetricsSubsystem,
+	Name:      "npm_versions_scanned",
+	Help:      "Total number of npm package versions scanned.",
+},
+	[]string{"source_name"})
@@ -0,0 +1,204 @@
+package npm
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func newTarball(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "package/.npmrc", Mode: 0644, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	version := func(v string) map[string]any {
+		return map[string]any{
+			"version":  v,
+			"dist":     map[string]any{"tarball": srv.URL + "/@acme/widgets/-/widgets-" + v + ".tgz"},
+			"_npmUser": map[string]any{"name": "acme-bot", "email": "bot@acme.dev"},
+		}
+	}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/-/v1/search":
+			assert.Equal(t, "maintainer:acme-bot", r.URL.Query().Get("text"))
+			if r.URL.Query().Get("from") == "0" {
+				sourcestest.WriteJSON(t, w, map[string]any{
+					"objects": []any{map[string]any{"package": map[string]any{"name": "@acme/widgets"}}},
+					"total":   2,
+				})
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"objects": []any{map[string]any{"package": map[string]any{"name": "acme-cli"}}},
+				"total":   2,
+			})
+		case "/@acme%2Fwidgets":
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"name":     "@acme/widgets",
+				"versions": map[string]any{"1.0.1": version("1.0.1"), "1.0.0": version("1.0.0")},
+			})
+		case "/@acme/widgets/-/widgets-1.0.0.tgz":
+			_, _ = w.Write(newTarball(t, "//registry.npmjs.org/:_authToken=npm_abc123\n"))
+		case "/@acme/widgets/-/widgets-1.0.1.tgz":
+			_, _ = w.Write(newTarball(t, "registry=https://registry.npmjs.org/\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func initSource(t *testing.T, srv *httptest.Server, conn *sourcespb.NPMUnauthenticatedPackage) *Source {
+	t.Helper()
+	conn.Registry = srv.URL
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	return s
+}
+
+func TestSource_Init_RequiresPackages(t *testing.T) {
+	anyConn, err := anypb.New(&sourcespb.NPMUnauthenticatedPackage{})
+	require.NoError(t, err)
+	assert.Error(t, (&Source{}).Init(context.Background(), "test source", 0, 0, false, anyConn, 1))
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, srv, &sourcespb.NPMUnauthenticatedPackage{
+		Packages:    []string{"@acme/widgets"},
+		Maintainers: []string{"acme-bot"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{Kind: unitKindPackage, ID: "@acme/widgets"},
+		sources.CommonSourceUnit{Kind: unitKindPackage, ID: "acme-cli"},
+	}, reporter.Units)
+}
+
+func TestSource_ChunkUnit(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, srv, &sourcespb.NPMUnauthenticatedPackage{Packages: []string{"@acme/widgets"}})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindPackage, ID: "@acme/widgets"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 2)
+
+	meta := reporter.Chunks[0].SourceMetadata.GetNpm()
+	require.NotNil(t, meta)
+	assert.Equal(t, "@acme/widgets", meta.Package)
+	assert.Equal(t, "1.0.0", meta.Release)
+	assert.Equal(t, "widgets-1.0.0.tgz", meta.File)
+	assert.Equal(t, srv.URL+"/@acme/widgets/-/widgets-1.0.0.tgz", meta.Link)
+	assert.Equal(t, "bot@acme.dev", meta.Email)
+	assert.Contains(t, string(reporter.Chunks[0].Data), "_authToken=npm_abc123")
+
+	assert.Equal(t, "1.0.1", reporter.Chunks[1].SourceMetadata.GetNpm().Release)
+}
+
+func TestSource_Enumerate_SearchPaging(t *testing.T) {
+	var froms []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/-/v1/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("text") == "maintainer:ghost" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		froms = append(froms, q.Get("from"))
+		if q.Get("from") != "0" {
+			// The search index can report more results than it returns.
+			sourcestest.WriteJSON(t, w, map[string]any{"objects": []any{}, "total": 1000})
+			return
+		}
+		objects := make([]any, searchPageLen)
+		for i := range objects {
+			objects[i] = map[string]any{"package": map[string]any{"name": fmt.Sprintf("pkg-%03d", i)}}
+		}
+		sourcestest.WriteJSON(t, w, map[string]any{"objects": objects, "total": 1000})
+	}))
+	t.Cleanup(srv.Close)
+
+	s := initSource(t, srv, &sourcespb.NPMUnauthenticatedPackage{
+		Packages:    []string{"pkg-000"},
+		Maintainers: []string{"ghost", "acme-bot"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Equal(t, []string{"0", fmt.Sprint(searchPageLen)}, froms)
+
+	// Packages found through a maintainer are only reported once.
+	assert.Len(t, reporter.Units, searchPageLen)
+	require.Len(t, reporter.UnitErrs, 1)
+	assert.ErrorContains(t, reporter.UnitErrs[0], `maintainer "ghost"`)
+}
+
+func TestSource_ChunkUnit_UnavailableTarballs(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/left-pad":
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"name": "left-pad",
+				"versions": map[string]any{
+					"0.0.1": map[string]any{"version": "0.0.1"},
+					"0.0.2": map[string]any{"version": "0.0.2", "dist": map[string]any{"tarball": srv.URL + "/left-pad/-/left-pad-0.0.2.tgz"}},
+					"0.0.3": map[string]any{"version": "0.0.3", "dist": map[string]any{"tarball": srv.URL + "/left-pad/-/left-pad-0.0.3.tgz"}},
+				},
+			})
+		case "/left-pad/-/left-pad-0.0.3.tgz":
+			_, _ = w.Write(newTarball(t, "token=abc"))
+		default:
+			// Tarballs of unpublished versions are gone.
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s := initSource(t, srv, &sourcespb.NPMUnauthenticatedPackage{Packages: []string{"left-pad"}})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindPackage, ID: "left-pad"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+
+	require.Len(t, reporter.ChunkErrs, 1)
+	assert.ErrorContains(t, reporter.ChunkErrs[0], "left-pad@0.0.2")
+	require.Len(t, reporter.Chunks, 1)
+	assert.Equal(t, "0.0.3", reporter.Chunks[0].SourceMetadata.GetNpm().Release)
+
+	err := s.ChunkUnit(context.Background(), sources.CommonSourceUnit{Kind: unitKindPackage, ID: "missing"}, &reporter)
+	assert.ErrorContains(t, err, "unexpected status code 404")
+}
@@ -0,0 +1,141 @@
+package pypi
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// client is a minimal client for the PyPI JSON and XML-RPC APIs.
+// https://warehouse.pypa.io/api-reference/
+type client struct {
+	// httpClient is used for API requests and downloadClient for release
+	// files, which are not subject to a timeout.
+	httpClient     *http.Client
+	downloadClient *http.Client
+	registry       string
+}
+
+type project struct {
+	Releases map[string][]releaseFile `json:"releases"`
+}
+
+type releaseFile struct {
+	Filename    string `json:"filename"`
+	URL         string `json:"url"`
+	PackageType string `json:"packagetype"`
+}
+
+// do performs the request and returns the response if it was successful.
+// The caller is responsible for closing the body.
+func (c *client) do(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to PyPI: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %q", resp.StatusCode, req.URL.Path)
+	}
+	return resp, nil
+}
+
+// getProject returns the files of every release of a project.
+func (c *client) getProject(ctx context.Context, name string) (project, error) {
+	var p project
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.registry+"/pypi/"+url.PathEscape(name)+"/json", nil)
+	if err != nil {
+		return p, fmt.Errorf("failed to create PyPI request: %w", err)
+	}
+	resp, err := c.do(c.httpClient, req)
+	if err != nil {
+		return p, fmt.Errorf("error getting project %q: %w", name, err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return p, fmt.Errorf("failed to decode PyPI response: %w", err)
+	}
+	return p, nil
+}
+
+// userPackagesRequest calls the user_packages XML-RPC method, which is the
+// only API listing the projects of a user.
+// https://warehouse.pypa.io/api-reference/xml-rpc.html#user-packages-user
+const userPackagesRequest = `<?xml version="1.0"?>
+<methodCall>
+<methodName>user_packages</methodName>
+<params><param><value><string>%s</string></value></param></params>
+</methodCall>`
+
+type userPackagesResponse struct {
+	Roles []struct {
+		Values []string `xml:"array>data>value>string"`
+	} `xml:"params>param>value>array>data>value"`
+	Fault *struct{} `xml:"fault"`
+}
+
+// listUserPackages returns the names of the projects the user owns or
+// maintains.
+func (c *client) listUserPackages(ctx context.Context, user string) ([]string, error) {
+	var escaped bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(user)); err != nil {
+		return nil, err
+	}
+	body := fmt.Sprintf(userPackagesRequest, escaped.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.registry+"/pypi", bytes.NewBufferString(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PyPI request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/xml")
+
+	resp, err := c.do(c.httpClient, req)
+	if err != nil {
+		return nil, fmt.Errorf("error listing projects of user %q: %w", user, err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	var result userPackagesResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode PyPI response: %w", err)
+	}
+	if result.Fault != nil {
+		return nil, fmt.Errorf("error listing projects of user %q: XML-RPC fault", user)
+	}
+
+	// Each role is a [role, project] pair.
+	var names []string
+	for _, role := range result.Roles {
+		if len(role.Values) == 2 {
+			names = append(names, role.Values[1])
+		}
+	}
+	return names, nil
+}
+
+// download returns the contents of a release file. The caller is responsible
+// for closing the returned reader.
+func (c *client) download(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PyPI request: %w", err)
+	}
+	resp, err := c.do(c.downloadClient, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,16 @@
+package pypi
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+)
+
+var pypiFilesScanned = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: common.MetricsNamespace,
+	Subsystem: common.MetricsSubsystem,
+	Name:      "pypi_files_scanned",
+	Help:      "Total number of PyPI release files scanned.",
+},
+	[]string{"source_name"})
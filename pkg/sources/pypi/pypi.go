@@ -0,0 +1,243 @@
+package pypi
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_PYPI_UNAUTHD_PACKAGES
+
+const (
+	// DefaultRegistry is the public Python Package Index.
+	DefaultRegistry = "https://pypi.org"
+
+	unitKindPackage = "package"
+)
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	packages []string
+	users    []string
+
+	client *client
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized PyPI source.
+func (s *Source) Init(_ context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+
+	var conn sourcespb.PyPIUnauthenticatedPackage
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.packages = conn.GetPackages()
+	s.users = conn.GetUsers()
+	if len(s.packages) == 0 && len(s.users) == 0 {
+		return fmt.Errorf("at least one package or user is required")
+	}
+
+	registry := strings.TrimRight(conn.GetRegistry(), "/")
+	if registry == "" {
+		registry = DefaultRegistry
+	}
+	if _, err := url.ParseRequestURI(registry); err != nil {
+		return fmt.Errorf("invalid PyPI registry %q: %w", registry, err)
+	}
+
+	s.client = &client{
+		httpClient:     common.RetryableHTTPClientTimeout(60),
+		downloadClient: common.RetryableHTTPClient(),
+		registry:       registry,
+	}
+	return nil
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var units []sources.SourceUnit
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	var scanned int64
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, unit := range units {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			pkg, _ := unit.SourceUnitID()
+			s.SetProgressComplete(i, len(units), fmt.Sprintf("Package: %s", pkg), "")
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+				return nil
+			}
+			ctx.Logger().V(2).Info("completed scan", "package", pkg, "num", atomic.AddInt64(&scanned, 1), "total", len(units))
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(units), len(units), "Completed PyPI scan", "")
+
+	return nil
+}
+
+// Enumerate reports the configured packages and every package owned or
+// maintained by the configured users to the reporter.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	seen := make(map[string]struct{})
+	report := func(pkg string) error {
+		if _, ok := seen[pkg]; ok {
+			return nil
+		}
+		seen[pkg] = struct{}{}
+		return reporter.UnitOk(ctx, sources.CommonSourceUnit{Kind: unitKindPackage, ID: pkg})
+	}
+
+	for _, pkg := range s.packages {
+		if err := report(pkg); err != nil {
+			return err
+		}
+	}
+	for _, user := range s.users {
+		pkgs, err := s.client.listUserPackages(ctx, user)
+		if err != nil {
+			if err := reporter.UnitErr(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		ctx.Logger().V(2).Info("enumerated user packages", "user", user, "count", len(pkgs))
+		for _, pkg := range pkgs {
+			if err := report(pkg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ChunkUnit downloads every file (wheels, sdists and eggs) of every release
+// of the package and passes it through the archive handlers.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	name, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "package", name)
+
+	p, err := s.client.getProject(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	releases := make([]string, 0, len(p.Releases))
+	for r := range p.Releases {
+		releases = append(releases, r)
+	}
+	sort.Strings(releases)
+
+	for _, release := range releases {
+		for _, file := range p.Releases[release] {
+			if common.IsDone(ctx) {
+				return ctx.Err()
+			}
+			if err := s.scanFile(ctx, name, release, file, reporter); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanFile scans a single release file. Errors scanning the file are
+// reported to the reporter; the returned error is only non-nil if the
+// reporter failed.
+func (s *Source) scanFile(ctx context.Context, name, release string, file releaseFile, reporter sources.ChunkReporter) error {
+	ctx = context.WithValues(ctx, "release", release, "file", file.Filename)
+
+	rc, err := s.client.download(ctx, file.URL)
+	if err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("error downloading %s: %w", file.Filename, err))
+	}
+	defer rc.Close()
+
+	chunkSkel := &sources.Chunk{
+		SourceType: s.Type(),
+		SourceName: s.name,
+		SourceID:   s.sourceID,
+		JobID:      s.jobID,
+		SourceMetadata: &source_metadatapb.MetaData{
+			Data: &source_metadatapb.MetaData_Pypi{
+				Pypi: &source_metadatapb.PyPi{
+					File:    sanitizer.UTF8(file.Filename),
+					Package: sanitizer.UTF8(name),
+					Release: sanitizer.UTF8(release),
+					Link:    file.URL,
+				},
+			},
+		},
+		Verify: s.verify,
+	}
+	if err := handlers.HandleFile(ctx, rc, chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(ctx, fmt.Errorf("error handling %s: %w", file.Filename, err))
+	}
+	pypiFilesScanned.WithLabelValues(s.name).Inc()
+	return nil
+}
@@ -0,0 +1,213 @@
+package pypi
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+func newWheel(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("acme/settings.py")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+const userPackagesResult = `<?xml version='1.0'?>
+<methodResponse>
+<params>
+<param>
+<value><array><data>
+<value><array><data>
+<value><string>Owner</string></value>
+<value><string>acme-sdk</string></value>
+</data></array></value>
+<value><array><data>
+<value><string>Maintainer</string></value>
+<value><string>acme-cli</string></value>
+</data></array></value>
+</data></array></value>
+</param>
+</params>
+</methodResponse>`
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pypi":
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), "<methodName>user_packages</methodName>")
+			assert.Contains(t, string(body), "<string>acme</string>")
+			w.Header().Set("Content-Type", "text/xml")
+			_, _ = w.Write([]byte(userPackagesResult))
+		case "/pypi/acme-sdk/json":
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"info": map[string]any{"name": "acme-sdk"},
+				"releases": map[string]any{
+					"0.1.0": []any{map[string]any{
+						"filename":    "acme_sdk-0.1.0-py3-none-any.whl",
+						"url":         srv.URL + "/packages/acme_sdk-0.1.0-py3-none-any.whl",
+						"packagetype": "bdist_wheel",
+					}},
+					"0.2.0": []any{},
+				},
+			})
+		case "/packages/acme_sdk-0.1.0-py3-none-any.whl":
+			_, _ = w.Write(newWheel(t, "API_TOKEN = 'hunter2'\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func initSource(t *testing.T, srv *httptest.Server, conn *sourcespb.PyPIUnauthenticatedPackage) *Source {
+	t.Helper()
+	conn.Registry = srv.URL
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	return s
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, srv, &sourcespb.PyPIUnauthenticatedPackage{
+		Packages: []string{"acme-sdk"},
+		Users:    []string{"acme"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+	assert.Empty(t, reporter.UnitErrs)
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{Kind: unitKindPackage, ID: "acme-sdk"},
+		sources.CommonSourceUnit{Kind: unitKindPackage, ID: "acme-cli"},
+	}, reporter.Units)
+}
+
+func TestSource_ChunkUnit(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, srv, &sourcespb.PyPIUnauthenticatedPackage{Packages: []string{"acme-sdk"}})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindPackage, ID: "acme-sdk"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 1)
+
+	meta := reporter.Chunks[0].SourceMetadata.GetPypi()
+	require.NotNil(t, meta)
+	assert.Equal(t, "acme-sdk", meta.Package)
+	assert.Equal(t, "0.1.0", meta.Release)
+	assert.Equal(t, "acme_sdk-0.1.0-py3-none-any.whl", meta.File)
+	assert.Equal(t, srv.URL+"/packages/acme_sdk-0.1.0-py3-none-any.whl", meta.Link)
+	assert.Contains(t, string(reporter.Chunks[0].Data), "API_TOKEN = 'hunter2'")
+}
+
+func TestSource_ChunkUnit_MissingPackage(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, srv, &sourcespb.PyPIUnauthenticatedPackage{Packages: []string{"missing"}})
+
+	unit := sources.CommonSourceUnit{Kind: unitKindPackage, ID: "missing"}
+	assert.Error(t, s.ChunkUnit(context.Background(), unit, &sourcestest.TestReporter{}))
+}
+
+const faultResult = `<?xml version='1.0'?>
+<methodResponse>
+<fault>
+<value><struct>
+<member><name>faultCode</name><value><int>-32500</int></value></member>
+<member><name>faultString</name><value><string>RuntimeError: user not found</string></value></member>
+</struct></value>
+</fault>
+</methodResponse>`
+
+func TestSource_Enumerate_UserErrors(t *testing.T) {
+	var users []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		users = append(users, string(body))
+
+		w.Header().Set("Content-Type", "text/xml")
+		if bytes.Contains(body, []byte("<string>acme</string>")) {
+			_, _ = w.Write([]byte(userPackagesResult))
+			return
+		}
+		_, _ = w.Write([]byte(faultResult))
+	}))
+	t.Cleanup(srv.Close)
+
+	s := initSource(t, srv, &sourcespb.PyPIUnauthenticatedPackage{
+		Users:    []string{"<ghost&co>", "acme"},
+		Packages: []string{"acme-cli"},
+	})
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+	// The user name is escaped in the XML-RPC request.
+	require.Len(t, users, 2)
+	assert.Contains(t, users[0], "<string>&lt;ghost&amp;co&gt;</string>")
+
+	require.Len(t, reporter.UnitErrs, 1)
+	assert.ErrorContains(t, reporter.UnitErrs[0], "XML-RPC fault")
+	assert.Equal(t, []sources.SourceUnit{
+		sources.CommonSourceUnit{Kind: unitKindPackage, ID: "acme-cli"},
+		sources.CommonSourceUnit{Kind: unitKindPackage, ID: "acme-sdk"},
+	}, reporter.Units)
+}
+
+func TestSource_ChunkUnit_MissingReleaseFile(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pypi/acme-sdk/json":
+			file := func(name, packageType string) map[string]any {
+				return map[string]any{"filename": name, "url": srv.URL + "/packages/" + name, "packagetype": packageType}
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{"releases": map[string]any{
+				"1.0.0": []any{
+					file("acme_sdk-1.0.0.tar.gz", "sdist"),
+					file("acme_sdk-1.0.0-py3-none-any.whl", "bdist_wheel"),
+				},
+			}})
+		case "/packages/acme_sdk-1.0.0-py3-none-any.whl":
+			_, _ = w.Write(newWheel(t, "SECRET = 'x'\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s := initSource(t, srv, &sourcespb.PyPIUnauthenticatedPackage{Packages: []string{"acme-sdk"}})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindPackage, ID: "acme-sdk"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+
+	// A missing file doesn't stop the other files of the release.
+	require.Len(t, reporter.ChunkErrs, 1)
+	assert.ErrorContains(t, reporter.ChunkErrs[0], "acme_sdk-1.0.0.tar.gz")
+	require.Len(t, reporter.Chunks, 1)
+	assert.Equal(t, "acme_sdk-1.0.0-py3-none-any.whl", reporter.Chunks[0].SourceMetadata.GetPypi().File)
+}
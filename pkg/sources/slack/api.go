@@ -0,0 +1,162 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+)
+
+// defaultEndpoint is the Slack Web API base URL.
+const defaultEndpoint = "https://slack.com/api"
+
+// pageLimit is the number of items requested per page from cursor-paginated
+// methods. Slack recommends no more than 200.
+const pageLimit = 200
+
+// apiClient is a minimal client for the Slack Web API.
+type apiClient struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// rateLimitError is returned when Slack responds with HTTP 429.
+type rateLimitError struct {
+	method     string
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("slack rate limit exceeded for %s, retry after %s", e.method, e.retryAfter)
+}
+
+type responseMetadata struct {
+	NextCursor string `json:"next_cursor"`
+}
+
+// response is the envelope shared by every Web API method.
+type response struct {
+	OK               bool             `json:"ok"`
+	Error            string           `json:"error"`
+	ResponseMetadata responseMetadata `json:"response_metadata"`
+}
+
+type authTestResponse struct {
+	response
+	URL    string `json:"url"`
+	Team   string `json:"team"`
+	UserID string `json:"user_id"`
+}
+
+type channel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	IsPrivate   bool   `json:"is_private"`
+	IsExtShared bool   `json:"is_ext_shared"`
+	IsArchived  bool   `json:"is_archived"`
+}
+
+type conversationsListResponse struct {
+	response
+	Channels []channel `json:"channels"`
+}
+
+type conversationsInfoResponse struct {
+	response
+	Channel channel `json:"channel"`
+}
+
+type file struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	User               string `json:"user"`
+	URLPrivateDownload string `json:"url_private_download"`
+	Permalink          string `json:"permalink"`
+}
+
+type message struct {
+	Type       string `json:"type"`
+	User       string `json:"user"`
+	Text       string `json:"text"`
+	TS         string `json:"ts"`
+	ThreadTS   string `json:"thread_ts"`
+	ReplyCount int    `json:"reply_count"`
+	Files      []file `json:"files"`
+}
+
+type messagesResponse struct {
+	response
+	Messages []message `json:"messages"`
+	HasMore  bool      `json:"has_more"`
+}
+
+// call invokes a Web API method and decodes the response into target, which
+// must embed response. A *rateLimitError is returned on HTTP 429.
+func (c *apiClient) call(ctx context.Context, method string, params url.Values, target interface{ envelope() *response }) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+method, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Slack API request: %w", err)
+	}
+	req.URL.RawQuery = params.Encode()
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode Slack API response for %s: %w", method, err)
+	}
+	if env := target.envelope(); !env.OK {
+		return fmt.Errorf("slack API method %s failed: %s", method, env.Error)
+	}
+	return nil
+}
+
+// do sends an authenticated request and checks the HTTP status. The caller is
+// responsible for closing the body.
+func (c *apiClient) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Slack: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return nil, &rateLimitError{method: req.URL.Path, retryAfter: time.Duration(seconds) * time.Second}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d for %q", resp.StatusCode, req.URL.Path)
+	}
+	return resp, nil
+}
+
+// download fetches a private file URL.
+func (c *apiClient) download(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Slack file request: %w", err)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (r *response) envelope() *response { return r }
@@ -0,0 +1,493 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_SLACK
+
+const unitKindChannel = "channel"
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	channels   []string
+	ignoreList []string
+
+	client *apiClient
+	// workspaceURL is the workspace URL returned by auth.test, used to
+	// build message permalinks.
+	workspaceURLMu sync.RWMutex
+	workspaceURL   string
+
+	channelCacheMu sync.RWMutex
+	channelCache   map[string]channel
+
+	// rateLimitResumeTime is when requests may resume after the workspace's
+	// token was rate limited.
+	rateLimitMu         sync.RWMutex
+	rateLimitResumeTime time.Time
+
+	jobPool *errgroup.Group
+	sources.Progress
+	sources.CommonSourceUnitUnmarshaller
+}
+
+// Ensure the Source satisfies the interfaces at compile time.
+var _ sources.Source = (*Source)(nil)
+var _ sources.SourceUnitUnmarshaller = (*Source)(nil)
+var _ sources.SourceUnitEnumChunker = (*Source)(nil)
+var _ sources.Validator = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// Init returns an initialized Slack source.
+func (s *Source) Init(_ context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, concurrency int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+	s.jobPool = &errgroup.Group{}
+	s.jobPool.SetLimit(concurrency)
+	s.channelCache = make(map[string]channel)
+
+	var conn sourcespb.Slack
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.channels = conn.GetChannels()
+	s.ignoreList = conn.GetIgnoreList()
+
+	var token string
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Slack_Token:
+		token = cred.Token
+	case *sourcespb.Slack_Tokens:
+		// The app-level token can't read conversations, so prefer the bot
+		// token and fall back to the user token.
+		token = cred.Tokens.GetBotToken()
+		if token == "" {
+			token = cred.Tokens.GetClientToken()
+		}
+	default:
+		return fmt.Errorf("invalid configuration given for source %q (%s)", name, s.Type().String())
+	}
+	if token == "" {
+		return fmt.Errorf("invalid configuration given for source %q (%s): empty token", name, s.Type().String())
+	}
+	log.RedactGlobally(token)
+
+	endpoint := strings.TrimRight(conn.GetEndpoint(), "/")
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	// Rate limiting is handled by handleRateLimit, so use a client that
+	// doesn't retry 429 responses itself.
+	s.client = &apiClient{
+		httpClient: common.SaneHttpClientTimeOut(60 * time.Second),
+		baseURL:    endpoint,
+		token:      token,
+	}
+
+	return nil
+}
+
+// handleRateLimit waits out a Slack rate limit if errIn is a rate limit
+// error, using the Retry-After header Slack returns. It reports whether the
+// caller should retry the request.
+func (s *Source) handleRateLimit(ctx context.Context, errIn error) bool {
+	var rateLimit *rateLimitError
+	if !errors.As(errIn, &rateLimit) {
+		return false
+	}
+
+	s.rateLimitMu.RLock()
+	resumeTime := s.rateLimitResumeTime
+	s.rateLimitMu.RUnlock()
+
+	var retryAfter time.Duration
+	if resumeTime.IsZero() || time.Now().After(resumeTime) {
+		s.rateLimitMu.Lock()
+		retryAfter = rateLimit.retryAfter
+		if retryAfter <= 0 {
+			// Slack always sends Retry-After, but be conservative if it's missing.
+			retryAfter = 30 * time.Second
+		}
+		s.rateLimitResumeTime = time.Now().Add(retryAfter)
+		ctx.Logger().V(2).Info("exceeded rate limit", "method", rateLimit.method, "retry_after", retryAfter.String(), "resume_time", s.rateLimitResumeTime.Format(time.RFC3339))
+		s.rateLimitMu.Unlock()
+	} else {
+		retryAfter = time.Until(resumeTime)
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(retryAfter):
+	}
+	return true
+}
+
+// call invokes a Web API method, retrying for as long as Slack rate limits
+// the request.
+func (s *Source) call(ctx context.Context, method string, params url.Values, target interface{ envelope() *response }) error {
+	for {
+		err := s.client.call(ctx, method, params, target)
+		if !s.handleRateLimit(ctx, err) {
+			return err
+		}
+	}
+}
+
+// download fetches a private file, retrying for as long as Slack rate limits
+// the request.
+func (s *Source) download(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	for {
+		rc, err := s.client.download(ctx, fileURL)
+		if !s.handleRateLimit(ctx, err) {
+			return rc, err
+		}
+	}
+}
+
+// Validate checks that the configured token is accepted.
+func (s *Source) Validate(ctx context.Context) []error {
+	if err := s.authenticate(ctx); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// authenticate calls auth.test to check the token and discover the
+// workspace URL used for permalinks.
+func (s *Source) authenticate(ctx context.Context) error {
+	var resp authTestResponse
+	if err := s.call(ctx, "auth.test", url.Values{}, &resp); err != nil {
+		return fmt.Errorf("slack authentication failed: %w", err)
+	}
+	s.workspaceURLMu.Lock()
+	s.workspaceURL = strings.TrimRight(resp.URL, "/")
+	s.workspaceURLMu.Unlock()
+	return nil
+}
+
+func (s *Source) getWorkspaceURL() string {
+	s.workspaceURLMu.RLock()
+	defer s.workspaceURLMu.RUnlock()
+	return s.workspaceURL
+}
+
+// Chunks emits chunks of bytes over a channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	var units []sources.SourceUnit
+	visitor := sources.VisitorReporter{
+		VisitUnit: func(ctx context.Context, unit sources.SourceUnit) error {
+			units = append(units, unit)
+			return ctx.Err()
+		},
+	}
+	if err := s.Enumerate(ctx, visitor); err != nil {
+		return err
+	}
+
+	reporter := sources.ChanReporter{Ch: chunksChan}
+	scanErrs := sources.NewScanErrors()
+	for i, unit := range units {
+		s.jobPool.Go(func() error {
+			if common.IsDone(ctx) {
+				return nil
+			}
+			id, _ := unit.SourceUnitID()
+			s.SetProgressComplete(i, len(units), fmt.Sprintf("Channel: %s", id), "")
+			if err := s.ChunkUnit(ctx, unit, reporter); err != nil {
+				scanErrs.Add(err)
+			}
+			return nil
+		})
+	}
+
+	_ = s.jobPool.Wait()
+	if scanErrs.Count() > 0 {
+		ctx.Logger().V(2).Info("encountered errors while scanning", "count", scanErrs.Count(), "errors", scanErrs)
+	}
+	s.SetProgressComplete(len(units), len(units), "Completed Slack scan", "")
+
+	return nil
+}
+
+// Enumerate reports every channel to be scanned. Channels may be configured
+// by ID or name; if none are configured, every public and private channel
+// visible to the token is listed. Channels in the ignore list are skipped.
+func (s *Source) Enumerate(ctx context.Context, reporter sources.UnitReporter) error {
+	if err := s.authenticate(ctx); err != nil {
+		return err
+	}
+
+	params := url.Values{
+		"types":            []string{"public_channel,private_channel"},
+		"exclude_archived": []string{"false"},
+		"limit":            []string{fmt.Sprint(pageLimit)},
+	}
+	for {
+		var resp conversationsListResponse
+		if err := s.call(ctx, "conversations.list", params, &resp); err != nil {
+			return err
+		}
+		for _, ch := range resp.Channels {
+			if len(s.channels) > 0 && !matchesChannel(s.channels, ch) {
+				continue
+			}
+			if matchesChannel(s.ignoreList, ch) {
+				ctx.Logger().V(3).Info("skipping channel", "channel", ch.Name, "reason", "ignored in config")
+				continue
+			}
+			s.channelCacheMu.Lock()
+			s.channelCache[ch.ID] = ch
+			s.channelCacheMu.Unlock()
+			if err := reporter.UnitOk(ctx, sources.CommonSourceUnit{Kind: unitKindChannel, ID: ch.ID}); err != nil {
+				return err
+			}
+		}
+		if resp.ResponseMetadata.NextCursor == "" {
+			return nil
+		}
+		params.Set("cursor", resp.ResponseMetadata.NextCursor)
+	}
+}
+
+// matchesChannel reports whether the channel's ID or name is in the list.
+// Names may be given with or without a leading '#'.
+func matchesChannel(list []string, ch channel) bool {
+	for _, entry := range list {
+		entry = strings.TrimPrefix(entry, "#")
+		if entry == ch.ID || strings.EqualFold(entry, ch.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// getChannel returns the channel from the enumeration cache, falling back
+// to conversations.info for units that weren't enumerated by this source.
+func (s *Source) getChannel(ctx context.Context, id string) (channel, error) {
+	s.channelCacheMu.RLock()
+	ch, ok := s.channelCache[id]
+	s.channelCacheMu.RUnlock()
+	if ok {
+		return ch, nil
+	}
+
+	var resp conversationsInfoResponse
+	if err := s.call(ctx, "conversations.info", url.Values{"channel": []string{id}}, &resp); err != nil {
+		return channel{}, err
+	}
+	s.channelCacheMu.Lock()
+	s.channelCache[id] = resp.Channel
+	s.channelCacheMu.Unlock()
+	return resp.Channel, nil
+}
+
+// ChunkUnit scans the history of a channel, including thread replies and
+// uploaded files.
+func (s *Source) ChunkUnit(ctx context.Context, unit sources.SourceUnit, reporter sources.ChunkReporter) error {
+	id, _ := unit.SourceUnitID()
+	ctx = context.WithValue(ctx, "channel", id)
+
+	if s.getWorkspaceURL() == "" {
+		if err := s.authenticate(ctx); err != nil {
+			return err
+		}
+	}
+	ch, err := s.getChannel(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{
+		"channel": []string{ch.ID},
+		"limit":   []string{fmt.Sprint(pageLimit)},
+	}
+	for {
+		var resp messagesResponse
+		if err := s.call(ctx, "conversations.history", params, &resp); err != nil {
+			return err
+		}
+		for _, msg := range resp.Messages {
+			if err := s.scanMessage(ctx, ch, msg, "", reporter); err != nil {
+				return err
+			}
+			if msg.ReplyCount > 0 && msg.ThreadTS == msg.TS {
+				if err := s.scanReplies(ctx, ch, msg.TS, reporter); err != nil {
+					if err := reporter.ChunkErr(ctx, err); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		if !resp.HasMore || resp.ResponseMetadata.NextCursor == "" {
+			return nil
+		}
+		params.Set("cursor", resp.ResponseMetadata.NextCursor)
+	}
+}
+
+// scanReplies scans every reply in the thread started by threadTS.
+func (s *Source) scanReplies(ctx context.Context, ch channel, threadTS string, reporter sources.ChunkReporter) error {
+	params := url.Values{
+		"channel": []string{ch.ID},
+		"ts":      []string{threadTS},
+		"limit":   []string{fmt.Sprint(pageLimit)},
+	}
+	for {
+		var resp messagesResponse
+		if err := s.call(ctx, "conversations.replies", params, &resp); err != nil {
+			return fmt.Errorf("error fetching replies to %s: %w", threadTS, err)
+		}
+		for _, msg := range resp.Messages {
+			// The parent message is included in every page of replies.
+			if msg.TS == threadTS {
+				continue
+			}
+			if err := s.scanMessage(ctx, ch, msg, threadTS, reporter); err != nil {
+				return err
+			}
+		}
+		if !resp.HasMore || resp.ResponseMetadata.NextCursor == "" {
+			return nil
+		}
+		params.Set("cursor", resp.ResponseMetadata.NextCursor)
+	}
+}
+
+func (s *Source) scanMessage(ctx context.Context, ch channel, msg message, threadTS string, reporter sources.ChunkReporter) error {
+	link := s.permalink(ch.ID, msg.TS, threadTS)
+	if msg.Text != "" {
+		chunk := sources.Chunk{
+			SourceType:     s.Type(),
+			SourceName:     s.name,
+			SourceID:       s.sourceID,
+			JobID:          s.jobID,
+			SourceMetadata: s.metadata(ch, msg.TS, msg.User, link, "", "message"),
+			Data:           []byte(msg.Text),
+			Verify:         s.verify,
+		}
+		if err := reporter.ChunkOk(ctx, chunk); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range msg.Files {
+		if err := s.scanFile(ctx, ch, msg, f, reporter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanFile downloads an uploaded file and passes it through the file
+// handlers. Download and handling errors are reported to the reporter.
+func (s *Source) scanFile(ctx context.Context, ch channel, msg message, f file, reporter sources.ChunkReporter) error {
+	if f.URLPrivateDownload == "" {
+		return nil
+	}
+	fileCtx := context.WithValues(ctx, "file", f.Name)
+
+	rc, err := s.download(fileCtx, f.URLPrivateDownload)
+	if err != nil {
+		return reporter.ChunkErr(fileCtx, fmt.Errorf("error downloading file %q: %w", f.Name, err))
+	}
+	defer rc.Close()
+
+	user := f.User
+	if user == "" {
+		user = msg.User
+	}
+	link := f.Permalink
+	if link == "" {
+		link = s.permalink(ch.ID, msg.TS, msg.ThreadTS)
+	}
+	chunkSkel := &sources.Chunk{
+		SourceType:     s.Type(),
+		SourceName:     s.name,
+		SourceID:       s.sourceID,
+		JobID:          s.jobID,
+		SourceMetadata: s.metadata(ch, msg.TS, user, link, f.Name, "file"),
+		Verify:         s.verify,
+	}
+	if err := handlers.HandleFile(fileCtx, rc, chunkSkel, reporter); err != nil {
+		return reporter.ChunkErr(fileCtx, fmt.Errorf("error handling file %q: %w", f.Name, err))
+	}
+	return nil
+}
+
+// permalink builds a link to a message. Replies link into their thread.
+func (s *Source) permalink(channelID, ts, threadTS string) string {
+	link := fmt.Sprintf("%s/archives/%s/p%s", s.getWorkspaceURL(), channelID, strings.ReplaceAll(ts, ".", ""))
+	if threadTS != "" && threadTS != ts {
+		link += "?" + url.Values{"thread_ts": []string{threadTS}, "cid": []string{channelID}}.Encode()
+	}
+	return link
+}
+
+func (s *Source) metadata(ch channel, ts, userID, link, fileName, location string) *source_metadatapb.MetaData {
+	return &source_metadatapb.MetaData{
+		Data: &source_metadatapb.MetaData_Slack{
+			Slack: &source_metadatapb.Slack{
+				ChannelId:   ch.ID,
+				ChannelName: sanitizer.UTF8(ch.Name),
+				Timestamp:   ts,
+				UserId:      userID,
+				Link:        link,
+				File:        sanitizer.UTF8(fileName),
+				Visibility:  visibility(ch),
+				Location:    location,
+			},
+		},
+	}
+}
+
+func visibility(ch channel) source_metadatapb.Visibility {
+	switch {
+	case ch.IsExtShared:
+		return source_metadatapb.Visibility_shared
+	case ch.IsPrivate:
+		return source_metadatapb.Visibility_private
+	default:
+		return source_metadatapb.Visibility_public
+	}
+}
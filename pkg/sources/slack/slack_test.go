@@ -0,0 +1,277 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// newServer returns a fake Slack API. The first conversations.history call is
+// rate limited.
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var (
+		srv         *httptest.Server
+		rateLimited atomic.Bool
+	)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			sourcestest.WriteJSON(t, w, map[string]any{"ok": false, "error": "invalid_auth"})
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/auth.test":
+			sourcestest.WriteJSON(t, w, map[string]any{"ok": true, "url": "https://acme.slack.com/"})
+		case "/api/conversations.list":
+			if q.Get("cursor") == "" {
+				sourcestest.WriteJSON(t, w, map[string]any{
+					"ok":                true,
+					"channels":          []any{map[string]any{"id": "C1", "name": "general"}},
+					"response_metadata": map[string]any{"next_cursor": "page2"},
+				})
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"ok": true,
+				"channels": []any{
+					map[string]any{"id": "C2", "name": "ops", "is_private": true},
+					map[string]any{"id": "C3", "name": "random"},
+				},
+			})
+		case "/api/conversations.history":
+			if !rateLimited.Swap(true) {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"ok": true,
+				"messages": []any{
+					map[string]any{"ts": "1700000000.000100", "user": "U1", "text": "password: hunter2", "thread_ts": "1700000000.000100", "reply_count": 1},
+					map[string]any{"ts": "1700000001.000200", "user": "U2", "files": []any{map[string]any{
+						"id":                   "F1",
+						"name":                 "creds.env",
+						"url_private_download": srv.URL + "/files/F1/creds.env",
+						"permalink":            "https://acme.slack.com/files/U2/F1/creds.env",
+					}}},
+				},
+			})
+		case "/api/conversations.replies":
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"ok": true,
+				"messages": []any{
+					map[string]any{"ts": "1700000000.000100", "user": "U1", "text": "password: hunter2"},
+					map[string]any{"ts": "1700000005.000300", "user": "U3", "text": "please rotate", "thread_ts": "1700000000.000100"},
+				},
+			})
+		case "/files/F1/creds.env":
+			_, _ = w.Write([]byte("API_KEY=abc123"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func initSource(t *testing.T, conn *sourcespb.Slack) *Source {
+	t.Helper()
+	if conn.Credential == nil {
+		conn.Credential = &sourcespb.Slack_Token{Token: "xoxb-test"}
+	}
+	s := &Source{}
+	sourcestest.InitSource(t, s, conn)
+	return s
+}
+
+func TestSource_Enumerate(t *testing.T) {
+	srv := newServer(t)
+
+	tests := map[string]struct {
+		conn *sourcespb.Slack
+		want []string
+	}{
+		"all channels": {
+			conn: &sourcespb.Slack{},
+			want: []string{"C1", "C2", "C3"},
+		},
+		"ignore list": {
+			conn: &sourcespb.Slack{IgnoreList: []string{"#random", "C1"}},
+			want: []string{"C2"},
+		},
+		"configured channels": {
+			conn: &sourcespb.Slack{Channels: []string{"ops", "C3"}},
+			want: []string{"C2", "C3"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.conn.Endpoint = srv.URL + "/api"
+			s := initSource(t, tt.conn)
+
+			reporter := sourcestest.TestReporter{}
+			require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+			var got []string
+			for _, unit := range reporter.Units {
+				id, _ := unit.SourceUnitID()
+				got = append(got, id)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSource_ChunkUnit(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, &sourcespb.Slack{Endpoint: srv.URL + "/api"})
+	assert.Empty(t, s.Validate(context.Background()))
+
+	reporter := sourcestest.TestReporter{}
+	require.NoError(t, s.Enumerate(context.Background(), &reporter))
+
+	unit := sources.CommonSourceUnit{Kind: unitKindChannel, ID: "C2"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+	require.Len(t, reporter.Chunks, 3)
+
+	var links []string
+	for _, chunk := range reporter.Chunks {
+		meta := chunk.SourceMetadata.GetSlack()
+		require.NotNil(t, meta)
+		assert.Equal(t, "C2", meta.ChannelId)
+		assert.Equal(t, "ops", meta.ChannelName)
+		assert.Equal(t, source_metadatapb.Visibility_private, meta.Visibility)
+		links = append(links, meta.Link)
+	}
+	assert.Equal(t, []string{
+		"https://acme.slack.com/archives/C2/p1700000000000100",
+		"https://acme.slack.com/archives/C2/p1700000005000300?cid=C2&thread_ts=1700000000.000100",
+		"https://acme.slack.com/files/U2/F1/creds.env",
+	}, links)
+
+	assert.Equal(t, "please rotate", string(reporter.Chunks[1].Data))
+	assert.Equal(t, "creds.env", reporter.Chunks[2].SourceMetadata.GetSlack().File)
+	assert.Equal(t, "API_KEY=abc123", string(reporter.Chunks[2].Data))
+}
+
+func TestSource_Validate_BadToken(t *testing.T) {
+	srv := newServer(t)
+	s := initSource(t, &sourcespb.Slack{
+		Endpoint:   srv.URL + "/api",
+		Credential: &sourcespb.Slack_Token{Token: "xoxb-wrong"},
+	})
+	assert.NotEmpty(t, s.Validate(context.Background()))
+}
+
+func TestSource_RateLimitPerSource(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	limited, other := &Source{}, &Source{}
+	err := &rateLimitError{method: "/api/conversations.history", retryAfter: time.Hour}
+	assert.False(t, limited.handleRateLimit(ctx, err))
+
+	// A rate limit on one workspace's token doesn't pause the others.
+	assert.False(t, limited.rateLimitResumeTime.IsZero())
+	assert.True(t, other.rateLimitResumeTime.IsZero())
+}
+
+func TestSource_ChunkUnit_PagedThread(t *testing.T) {
+	var historyCursors, replyCursors []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/auth.test":
+			sourcestest.WriteJSON(t, w, map[string]any{"ok": true, "url": "https://acme.slack.com/"})
+		case "/api/conversations.info":
+			sourcestest.WriteJSON(t, w, map[string]any{"ok": true, "channel": map[string]any{"id": "C1", "name": "general"}})
+		case "/api/conversations.history":
+			historyCursors = append(historyCursors, q.Get("cursor"))
+			if q.Get("cursor") == "" {
+				sourcestest.WriteJSON(t, w, map[string]any{
+					"ok":                true,
+					"has_more":          true,
+					"messages":          []any{map[string]any{"ts": "1.000001", "text": "first"}},
+					"response_metadata": map[string]any{"next_cursor": "h2"},
+				})
+				return
+			}
+			// has_more without a cursor must end the listing.
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"ok":       true,
+				"has_more": true,
+				"messages": []any{map[string]any{"ts": "2.000001", "text": "parent", "thread_ts": "2.000001", "reply_count": 2}},
+			})
+		case "/api/conversations.replies":
+			replyCursors = append(replyCursors, q.Get("cursor"))
+			// Every page of replies starts with the thread's parent.
+			parent := map[string]any{"ts": "2.000001", "text": "parent", "thread_ts": "2.000001"}
+			if q.Get("cursor") == "" {
+				sourcestest.WriteJSON(t, w, map[string]any{
+					"ok":                true,
+					"has_more":          true,
+					"messages":          []any{parent, map[string]any{"ts": "2.000002", "text": "reply 1", "thread_ts": "2.000001"}},
+					"response_metadata": map[string]any{"next_cursor": "r2"},
+				})
+				return
+			}
+			sourcestest.WriteJSON(t, w, map[string]any{
+				"ok":       true,
+				"messages": []any{parent, map[string]any{"ts": "2.000003", "text": "reply 2", "thread_ts": "2.000001"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s := initSource(t, &sourcespb.Slack{Endpoint: srv.URL + "/api"})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindChannel, ID: "C1"}
+	require.NoError(t, s.ChunkUnit(context.Background(), unit, &reporter))
+	require.Empty(t, reporter.ChunkErrs)
+
+	var got []string
+	for _, chunk := range reporter.Chunks {
+		got = append(got, string(chunk.Data))
+	}
+	assert.Equal(t, []string{"first", "parent", "reply 1", "reply 2"}, got)
+	assert.Equal(t, []string{"", "h2"}, historyCursors)
+	assert.Equal(t, []string{"", "r2"}, replyCursors)
+}
+
+func TestSource_ChunkUnit_NotInChannel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth.test":
+			sourcestest.WriteJSON(t, w, map[string]any{"ok": true, "url": "https://acme.slack.com/"})
+		case "/api/conversations.info":
+			sourcestest.WriteJSON(t, w, map[string]any{"ok": true, "channel": map[string]any{"id": "C9", "name": "secret"}})
+		default:
+			// Slack reports API errors with a 200 and ok set to false.
+			sourcestest.WriteJSON(t, w, map[string]any{"ok": false, "error": "not_in_channel"})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	s := initSource(t, &sourcespb.Slack{Endpoint: srv.URL + "/api"})
+
+	reporter := sourcestest.TestReporter{}
+	unit := sources.CommonSourceUnit{Kind: unitKindChannel, ID: "C9"}
+	err := s.ChunkUnit(context.Background(), unit, &reporter)
+	assert.ErrorContains(t, err, "not_in_channel")
+	assert.Empty(t, reporter.Chunks)
+}
@@ -279,6 +279,27 @@ type GitConfig struct {
 	ExcludeGlobs string
 	// SkipBinaries allows skipping binary files from the scan.
 	SkipBinaries bool
+	// LFS enables scanning the objects referenced by Git LFS pointers.
+	LFS bool
+	// LFSEndpoint is the LFS server to download objects that are missing from
+	// the local LFS store from.
+	LFSEndpoint string
+	// Stashes enables scanning every stash entry.
+	Stashes bool
+	// Reflog enables scanning commits that are only referenced by reflogs,
+	// e.g. after a rebase or reset.
+	Reflog bool
+	// Dangling enables scanning dangling commits and blobs, e.g. after a
+	// force-push.
+	Dangling bool
+	// Submodules enables scanning the submodules referenced by the scanned
+	// commits.
+	Submodules bool
+	// Snapshot is a ref whose whole tree is scanned instead of the history.
+	Snapshot string
+	// PushedRevisions are the new tips of the refs updated by a push, in a
+	// pre-receive hook. Only the commits they add are scanned.
+	PushedRevisions []string
 }
 
 // GithubConfig defines the optional configuration for a github source.
@@ -319,6 +340,9 @@ type GithubConfig struct {
 	AuthInUrl bool
 	// CloneDirectory if specified will clone the repositories to a specific place.
 	CloneDirectory string
+	// Submodules enables scanning the submodules referenced by the scanned
+	// commits.
+	Submodules bool
 }
 
 // GitHubExperimentalConfig defines the optional configuration for an experimental GitHub source.
@@ -353,6 +377,9 @@ type GitlabConfig struct {
 	ExcludeRepos []string
 	// AuthInUrl determines wether to use authentication token in repository url or in header.
 	AuthInUrl bool
+	// Submodules enables scanning the submodules referenced by the scanned
+	// commits.
+	Submodules bool
 }
 
 // FilesystemConfig defines the optional configuration for a filesystem source.
@@ -0,0 +1,393 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/log"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+const SourceType = sourcespb.SourceType_SOURCE_TYPE_WEBHOOK
+
+const (
+	// DefaultListenAddress only accepts local connections.
+	DefaultListenAddress = "127.0.0.1:8080"
+
+	// maxRequestSize is the largest request body that is accepted.
+	maxRequestSize = 250 << 20 // 250MB
+
+	shutdownTimeout = 30 * time.Second
+)
+
+// Detector synchronously scans a chunk and returns its findings. It is used
+// to respond to requests that wait for their findings.
+type Detector interface {
+	DetectChunk(ctx context.Context, chunk *sources.Chunk) []detectors.ResultWithMetadata
+}
+
+type Source struct {
+	name     string
+	sourceID sources.SourceID
+	jobID    sources.JobID
+	verify   bool
+
+	listenAddress string
+	// headerKey and headerValue authenticate requests when set.
+	headerKey   string
+	headerValue string
+
+	detector Detector
+
+	sources.Progress
+}
+
+// Ensure the Source satisfies the interface at compile time.
+var _ sources.Source = (*Source)(nil)
+
+// Type returns the type of source.
+// It is used for matching source types in configuration and job input.
+func (s *Source) Type() sourcespb.SourceType {
+	return SourceType
+}
+
+func (s *Source) SourceID() sources.SourceID {
+	return s.sourceID
+}
+
+func (s *Source) JobID() sources.JobID {
+	return s.jobID
+}
+
+// WithDetector enables requests to wait for their findings, which are
+// obtained from the detector.
+func (s *Source) WithDetector(detector Detector) { s.detector = detector }
+
+// Init returns an initialized webhook source.
+func (s *Source) Init(_ context.Context, name string, jobID sources.JobID, sourceID sources.SourceID, verify bool, connection *anypb.Any, _ int) error {
+	s.name = name
+	s.sourceID = sourceID
+	s.jobID = jobID
+	s.verify = verify
+
+	var conn sourcespb.Webhook
+	if err := anypb.UnmarshalTo(connection, &conn, proto.UnmarshalOptions{}); err != nil {
+		return fmt.Errorf("error unmarshalling connection: %w", err)
+	}
+
+	s.listenAddress = conn.GetListenAddress()
+	if s.listenAddress == "" {
+		s.listenAddress = DefaultListenAddress
+	}
+
+	switch cred := conn.GetCredential().(type) {
+	case *sourcespb.Webhook_Header:
+		s.headerKey = http.CanonicalHeaderKey(cred.Header.GetKey())
+		s.headerValue = cred.Header.GetValue()
+		if s.headerKey == "" || s.headerValue == "" {
+			return fmt.Errorf("both the header name and value are required")
+		}
+		log.RedactGlobally(s.headerValue)
+	case nil:
+	default:
+		return fmt.Errorf("unknown credential type: %T", cred)
+	}
+	return nil
+}
+
+// Chunks listens for requests until the context is cancelled and emits the
+// chunks of every request body over the channel.
+func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk, _ ...sources.ChunkingTarget) error {
+	lis, err := net.Listen("tcp", s.listenAddress)
+	if err != nil {
+		return fmt.Errorf("error creating listener: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler:           s.handler(ctx, sources.ChanReporter{Ch: chunksChan}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(lis) }()
+	ctx.Logger().Info("listening for webhook requests", "address", lis.Addr().String())
+
+	select {
+	case <-ctx.Done():
+		// Let in-flight requests finish so that their chunks are scanned.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("error shutting down webhook listener: %w", err)
+		}
+		return nil
+	case err := <-serveErr:
+		return fmt.Errorf("webhook listener failed: %w", err)
+	}
+}
+
+// response is the body of a successful response. Results is null unless the
+// request waited for them.
+type response struct {
+	ID      string
+	Results []result
+}
+
+// result is a finding in a synchronous response. It follows the shape of the
+// JSON output.
+type result struct {
+	File              string
+	DetectorName      string
+	DecoderName       string
+	Verified          bool
+	VerificationError string `json:",omitempty"`
+	Raw               string
+	RawV2             string
+	Redacted          string
+	ExtraData         map[string]string
+}
+
+// handler returns the HTTP handler for webhook requests. Request bodies are
+// reported to the reporter, unless the request asks to wait for its findings
+// with the "sync" query parameter.
+//
+// Bodies are scanned according to their content type:
+//   - multipart/form-data: every part is scanned as a separate file.
+//   - application/json: string values are scanned unescaped, one per line.
+//   - anything else: the raw body is scanned; archives are extracted.
+//
+// The "name" query parameter sets the file name of non-multipart bodies.
+func (s *Source) handler(ctx context.Context, reporter sources.ChunkReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authenticated(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		wait, _ := strconv.ParseBool(r.URL.Query().Get("sync"))
+		if wait && s.detector == nil {
+			http.Error(w, "synchronous results are not supported", http.StatusNotImplemented)
+			return
+		}
+
+		id := uuid.NewString()
+		ctx := context.WithValues(ctx, "request_id", id, "remote_address", r.RemoteAddr)
+		webhookRequestsReceived.WithLabelValues(s.name).Inc()
+
+		var collector *collectingReporter
+		reqReporter := reporter
+		if wait {
+			collector = &collectingReporter{ctx: ctx, detector: s.detector}
+			reqReporter = collector
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		if err := s.scanRequest(ctx, id, r, reqReporter); err != nil {
+			ctx.Logger().Error(err, "error scanning webhook request")
+			status := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		resp := response{ID: id}
+		status := http.StatusAccepted
+		if collector != nil {
+			resp.Results = append([]result{}, collector.results...)
+			status = http.StatusOK
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// authenticated reports whether the request carries the configured header.
+func (s *Source) authenticated(r *http.Request) bool {
+	if s.headerKey == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(s.headerKey)), []byte(s.headerValue)) == 1
+}
+
+// scanRequest scans the request body and reports its chunks.
+func (s *Source) scanRequest(ctx context.Context, id string, r *http.Request, reporter sources.ChunkReporter) error {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	name := r.URL.Query().Get("name")
+
+	switch {
+	case contentType == "multipart/form-data":
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return fmt.Errorf("error reading multipart body: %w", err)
+		}
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("error reading multipart body: %w", err)
+			}
+			partName := part.FileName()
+			if partName == "" {
+				partName = part.FormName()
+			}
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			err = s.scanBody(ctx, part, s.metadata(id, partName, partType, r.RemoteAddr), reporter)
+			_ = part.Close()
+			if err != nil {
+				return err
+			}
+		}
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return fmt.Errorf("error reading body: %w", err)
+		}
+		var doc any
+		if err := json.Unmarshal(body, &doc); err == nil {
+			var buf bytes.Buffer
+			flattenJSON(&buf, "", doc)
+			body = buf.Bytes()
+		} else {
+			ctx.Logger().V(2).Info("invalid JSON body, scanning it as is", "error", err)
+		}
+		return s.scanBody(ctx, bytes.NewReader(body), s.metadata(id, name, contentType, r.RemoteAddr), reporter)
+	default:
+		return s.scanBody(ctx, r.Body, s.metadata(id, name, contentType, r.RemoteAddr), reporter)
+	}
+}
+
+// scanBody passes the body through the file handlers. Only errors reading
+// the body are returned; others are reported to the reporter.
+func (s *Source) scanBody(ctx context.Context, body io.Reader, metadata *source_metadatapb.MetaData, reporter sources.ChunkReporter) error {
+	chunkSkel := &sources.Chunk{
+		SourceType:     s.Type(),
+		SourceName:     s.name,
+		SourceID:       s.sourceID,
+		JobID:          s.jobID,
+		SourceMetadata: metadata,
+		Verify:         s.verify,
+	}
+	if err := handlers.HandleFile(ctx, body, chunkSkel, reporter); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return err
+		}
+		return reporter.ChunkErr(ctx, fmt.Errorf("error handling request body: %w", err))
+	}
+	return nil
+}
+
+func (s *Source) metadata(id, file, contentType, remoteAddress string) *source_metadatapb.MetaData {
+	return &source_metadatapb.MetaData{
+		Data: &source_metadatapb.MetaData_Webhook{
+			Webhook: &source_metadatapb.Webhook{
+				RequestId:     id,
+				File:          sanitizer.UTF8(file),
+				ContentType:   contentType,
+				RemoteAddress: remoteAddress,
+			},
+		},
+	}
+}
+
+// flattenJSON writes every string, number and boolean in the document as a
+// "path: value" line. Unescaping string values lets detectors match secrets
+// that contain escaped characters, such as the newlines of private keys.
+func flattenJSON(buf *bytes.Buffer, path string, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key := k
+			if path != "" {
+				key = path + "." + k
+			}
+			flattenJSON(buf, key, v[k])
+		}
+	case []any:
+		for i, elem := range v {
+			flattenJSON(buf, fmt.Sprintf("%s[%d]", path, i), elem)
+		}
+	case nil:
+	default:
+		fmt.Fprintf(buf, "%s: %v\n", path, v)
+	}
+}
+
+// collectingReporter runs every chunk through the detector and collects the
+// findings for a synchronous response.
+type collectingReporter struct {
+	ctx      context.Context
+	detector Detector
+
+	mu      sync.Mutex
+	results []result
+}
+
+var _ sources.ChunkReporter = (*collectingReporter)(nil)
+
+func (c *collectingReporter) ChunkOk(_ context.Context, chunk sources.Chunk) error {
+	found := c.detector.DetectChunk(c.ctx, &chunk)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range found {
+		var verificationErr string
+		if err := r.VerificationError(); err != nil {
+			verificationErr = err.Error()
+		}
+		c.results = append(c.results, result{
+			File:              r.SourceMetadata.GetWebhook().GetFile(),
+			DetectorName:      r.DetectorType.String(),
+			DecoderName:       r.DecoderType.String(),
+			Verified:          r.Verified,
+			VerificationError: verificationErr,
+			Raw:               string(r.Raw),
+			RawV2:             string(r.RawV2),
+			Redacted:          r.Redacted,
+			ExtraData:         r.ExtraData,
+		})
+	}
+	return c.ctx.Err()
+}
+
+func (c *collectingReporter) ChunkErr(ctx context.Context, err error) error {
+	ctx.Logger().Error(err, "error chunking")
+	return c.ctx.Err()
+}
@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sourcestest"
+)
+
+// fakeDetector finds "hunter2" in chunks.
+type fakeDetector struct{}
+
+func (fakeDetector) DetectChunk(_ context.Context, chunk *sources.Chunk) []detectors.ResultWithMetadata {
+	if !bytes.Contains(chunk.Data, []byte("hunter2")) {
+		return nil
+	}
+	return []detectors.ResultWithMetadata{detectors.CopyMetadata(chunk, detectors.Result{
+		DetectorType: detectorspb.DetectorType_Github,
+		Raw:          []byte("hunter2"),
+	})}
+}
+
+func newSource(t *testing.T, conn *sourcespb.Webhook) *Source {
+	t.Helper()
+	anyConn, err := anypb.New(conn)
+	require.NoError(t, err)
+
+	s := &Source{}
+	require.NoError(t, s.Init(context.Background(), "test source", 0, 0, false, anyConn, 1))
+	return s
+}
+
+func post(t *testing.T, url, contentType string, body []byte, header http.Header) (*http.Response, response) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var out response
+	if resp.Header.Get("Content-Type") == "application/json" {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	}
+	return resp, out
+}
+
+func TestSource_Handler(t *testing.T) {
+	s := newSource(t, &sourcespb.Webhook{
+		Credential: &sourcespb.Webhook_Header{Header: &credentialspb.Header{Key: "x-api-key", Value: "letmein"}},
+	})
+	reporter := sourcestest.TestReporter{}
+	srv := httptest.NewServer(s.handler(context.Background(), &reporter))
+	defer srv.Close()
+	auth := http.Header{"X-Api-Key": []string{"letmein"}}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		resp, _ := post(t, srv.URL, "text/plain", []byte("password=hunter2"), http.Header{"X-Api-Key": []string{"nope"}})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		resp, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+
+	t.Run("sync without detector", func(t *testing.T) {
+		resp, _ := post(t, srv.URL+"?sync=true", "text/plain", []byte("password=hunter2"), auth)
+		assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	})
+
+	t.Run("raw", func(t *testing.T) {
+		reporter.Chunks = nil
+		resp, out := post(t, srv.URL+"?name=app.env", "text/plain", []byte("password=hunter2"), auth)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		assert.NotEmpty(t, out.ID)
+		assert.Nil(t, out.Results)
+
+		require.Len(t, reporter.Chunks, 1)
+		meta := reporter.Chunks[0].SourceMetadata.GetWebhook()
+		assert.Equal(t, out.ID, meta.RequestId)
+		assert.Equal(t, "app.env", meta.File)
+		assert.Equal(t, "text/plain", meta.ContentType)
+		assert.NotEmpty(t, meta.RemoteAddress)
+		assert.Equal(t, "password=hunter2", string(reporter.Chunks[0].Data))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		reporter.Chunks = nil
+		body := `{"key": "-----BEGIN KEY-----\nabc\n-----END KEY-----", "list": [1, true, null]}`
+		resp, _ := post(t, srv.URL, "application/json; charset=utf-8", []byte(body), auth)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		require.Len(t, reporter.Chunks, 1)
+		assert.Equal(t, "key: -----BEGIN KEY-----\nabc\n-----END KEY-----\nlist[0]: 1\nlist[1]: true\n", string(reporter.Chunks[0].Data))
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		reporter.Chunks = nil
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		fw, err := mw.CreateFormFile("file", "config.yml")
+		require.NoError(t, err)
+		_, _ = fw.Write([]byte("token: hunter2"))
+		require.NoError(t, mw.WriteField("comment", "see attached"))
+		require.NoError(t, mw.Close())
+
+		resp, _ := post(t, srv.URL, mw.FormDataContentType(), buf.Bytes(), auth)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		require.Len(t, reporter.Chunks, 2)
+		assert.Equal(t, "config.yml", reporter.Chunks[0].SourceMetadata.GetWebhook().File)
+		assert.Equal(t, "token: hunter2", string(reporter.Chunks[0].Data))
+		assert.Equal(t, "comment", reporter.Chunks[1].SourceMetadata.GetWebhook().File)
+	})
+}
+
+func TestSource_Handler_Sync(t *testing.T) {
+	s := newSource(t, &sourcespb.Webhook{})
+	s.WithDetector(fakeDetector{})
+	reporter := sourcestest.TestReporter{}
+	srv := httptest.NewServer(s.handler(context.Background(), &reporter))
+	defer srv.Close()
+
+	resp, out := post(t, srv.URL+"?sync=true&name=app.env", "text/plain", []byte("password=hunter2"), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, out.Results, 1)
+	assert.Equal(t, "app.env", out.Results[0].File)
+	assert.Equal(t, "Github", out.Results[0].DetectorName)
+	assert.Equal(t, "hunter2", out.Results[0].Raw)
+	// Synchronous requests are not reported to the pipeline a second time.
+	assert.Empty(t, reporter.Chunks)
+
+	resp, out = post(t, srv.URL+"?sync=1", "text/plain", []byte("nothing to see"), nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotNil(t, out.Results)
+	assert.Empty(t, out.Results)
+}
+
+func TestSource_Chunks(t *testing.T) {
+	s := newSource(t, &sourcespb.Webhook{ListenAddress: "127.0.0.1:0"})
+	ctx, cancel := context.WithCancel(context.Background())
+	chunksChan := make(chan *sources.Chunk, 1)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Chunks(ctx, chunksChan) }()
+	cancel()
+	assert.NoError(t, <-errCh)
+}
+
+func TestSource_Init_InvalidHeader(t *testing.T) {
+	anyConn, err := anypb.New(&sourcespb.Webhook{
+		Credential: &sourcespb.Webhook_Header{Header: &credentialspb.Header{Key: "x-api-key"}},
+	})
+	require.NoError(t, err)
+	err = (&Source{}).Init(context.Background(), "test source", 0, 0, false, anyConn, 1)
+	assert.ErrorContains(t, err, "required")
+}
@@ -0,0 +1,33 @@
+package sourcestest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// WriteJSON writes v as the JSON response of a fake API. The Content-Type is
+// application/json, unless the handler already set one.
+func WriteJSON(t testing.TB, w http.ResponseWriter, v any) {
+	t.Helper()
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+// InitSource initializes the source with the connection, without
+// verification and with a concurrency of 1.
+func InitSource(t testing.TB, s sources.Source, conn proto.Message) {
+	t.Helper()
+	anyConn, err := anypb.New(conn)
+	require.NoError(t, err)
+	require.NoError(t, s.Init(context.Background(), "test source", 0, 0, false, anyConn, 1))
+}
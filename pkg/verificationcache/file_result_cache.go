@@ -0,0 +1,300 @@
+package verificationcache
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// fileResultCacheVersion is the version of the on-disk format. Files with a
+// different version are ignored.
+const fileResultCacheVersion = 1
+
+// TTLs are how long results are cached for, by verification outcome. A
+// non-positive TTL means results with that outcome are only cached for the
+// current scan and are never persisted.
+type TTLs struct {
+	Verified   time.Duration
+	Unverified time.Duration
+	// Unknown applies to unverified results with a verification error.
+	Unknown time.Duration
+}
+
+// DefaultTTLs persists verified and unverified results for a day, and retries
+// verification of unknown results on every scan.
+var DefaultTTLs = TTLs{Verified: 24 * time.Hour, Unverified: 24 * time.Hour}
+
+// ParseTTLs parses a comma separated list of outcome=duration pairs, e.g.
+// "verified=24h,unverified=6h,unknown=0s". A single duration applies to every
+// outcome. Outcomes that are not listed keep their DefaultTTLs value.
+func ParseTTLs(s string) (TTLs, error) {
+	ttls := DefaultTTLs
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ttls, nil
+	}
+	if !strings.Contains(s, "=") {
+		ttl, err := time.ParseDuration(s)
+		if err != nil {
+			return ttls, fmt.Errorf("invalid verification cache TTL %q: %w", s, err)
+		}
+		return TTLs{Verified: ttl, Unverified: ttl, Unknown: ttl}, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		outcome, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return ttls, fmt.Errorf("invalid verification cache TTL %q: expected outcome=duration", pair)
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return ttls, fmt.Errorf("invalid verification cache TTL %q: %w", pair, err)
+		}
+		switch strings.ToLower(strings.TrimSpace(outcome)) {
+		case "verified":
+			ttls.Verified = ttl
+		case "unverified":
+			ttls.Unverified = ttl
+		case "unknown":
+			ttls.Unknown = ttl
+		default:
+			return ttls, fmt.Errorf("invalid verification cache TTL %q: outcome must be verified, unverified or unknown", pair)
+		}
+	}
+	return ttls, nil
+}
+
+// ttl returns the TTL of the result's verification outcome.
+func (t TTLs) ttl(result detectors.Result) time.Duration {
+	switch {
+	case result.Verified:
+		return t.Verified
+	case result.VerificationError() != nil:
+		return t.Unknown
+	default:
+		return t.Unverified
+	}
+}
+
+// fileCacheEntry is a cached verification outcome. Only the verification
+// information of a result is kept, never the secret itself.
+type fileCacheEntry struct {
+	Verified          bool      `json:"verified"`
+	VerificationError string    `json:"verificationError,omitempty"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+}
+
+func (e fileCacheEntry) result() detectors.Result {
+	result := detectors.Result{Verified: e.Verified}
+	if e.VerificationError != "" {
+		result.SetVerificationError(errors.New(e.VerificationError))
+	}
+	return result
+}
+
+type fileCacheContents struct {
+	Version int `json:"version"`
+	// Entries are keyed by the base64 encoded result cache key.
+	Entries map[string]fileCacheEntry `json:"entries"`
+}
+
+// FileResultCache is a ResultCache that is loaded from a local file and can be
+// saved back to it, so that verification results are shared across scans.
+// Entries expire according to the TTL of their verification outcome.
+type FileResultCache struct {
+	path string
+	ttls TTLs
+	now  func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]fileCacheEntry
+}
+
+var _ ResultCache = (*FileResultCache)(nil)
+
+// NewFileResultCache creates a FileResultCache backed by the file at path,
+// loading any unexpired entries it already contains. The file does not need
+// to exist.
+func NewFileResultCache(path string, ttls TTLs) (*FileResultCache, error) {
+	c := &FileResultCache{
+		path:    path,
+		ttls:    ttls,
+		now:     time.Now,
+		entries: make(map[string]fileCacheEntry),
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *FileResultCache) load() error {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading verification cache: %w", err)
+	}
+
+	var contents fileCacheContents
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return fmt.Errorf("error decoding verification cache %s: %w", c.path, err)
+	}
+	if contents.Version != fileResultCacheVersion {
+		return nil
+	}
+
+	now := c.now()
+	for encodedKey, entry := range contents.Entries {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil || !entry.ExpiresAt.After(now) {
+			continue
+		}
+		c.entries[string(key)] = entry
+	}
+	return nil
+}
+
+// Save writes the unexpired entries with a persistent TTL to the file. The
+// file is replaced atomically, so a concurrent scan never reads a partial
+// cache.
+func (c *FileResultCache) Save() error {
+	c.mu.RLock()
+	now := c.now()
+	contents := fileCacheContents{
+		Version: fileResultCacheVersion,
+		Entries: make(map[string]fileCacheEntry, len(c.entries)),
+	}
+	for key, entry := range c.entries {
+		if entry.ExpiresAt.After(now) {
+			contents.Entries[base64.StdEncoding.EncodeToString([]byte(key))] = entry
+		}
+	}
+	c.mu.RUnlock()
+
+	data, err := json.Marshal(contents)
+	if err != nil {
+		return fmt.Errorf("error encoding verification cache: %w", err)
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("error creating verification cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(c.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating verification cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("error writing verification cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing verification cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("error writing verification cache: %w", err)
+	}
+	return nil
+}
+
+// Set caches the verification outcome of the result. Results whose outcome
+// has a non-positive TTL are cached until the end of the scan only.
+func (c *FileResultCache) Set(key string, val detectors.Result) {
+	entry := fileCacheEntry{Verified: val.Verified}
+	if err := val.VerificationError(); err != nil {
+		entry.VerificationError = err.Error()
+	}
+	if ttl := c.ttls.ttl(val); ttl > 0 {
+		entry.ExpiresAt = c.now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// Get returns the cached verification outcome for the key.
+func (c *FileResultCache) Get(key string) (detectors.Result, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok || c.expired(entry) {
+		return detectors.Result{}, false
+	}
+	return entry.result(), true
+}
+
+// expired reports whether a persistent entry's TTL has elapsed. Entries that
+// are not persisted never expire during a scan.
+func (c *FileResultCache) expired(entry fileCacheEntry) bool {
+	return !entry.ExpiresAt.IsZero() && !entry.ExpiresAt.After(c.now())
+}
+
+// Exists returns true if the given key exists in the cache.
+func (c *FileResultCache) Exists(key string) bool {
+	_, ok := c.Get(key)
+	return ok
+}
+
+// Delete removes the key from the cache.
+func (c *FileResultCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Clear removes all entries from the cache.
+func (c *FileResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]fileCacheEntry)
+}
+
+// Count returns the number of unexpired entries in the cache.
+func (c *FileResultCache) Count() int {
+	return len(c.Keys())
+}
+
+// Keys returns the keys of all unexpired entries in the cache.
+func (c *FileResultCache) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if !c.expired(entry) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Values returns the verification outcomes of all unexpired entries in the
+// cache.
+func (c *FileResultCache) Values() []detectors.Result {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	values := make([]detectors.Result, 0, len(c.entries))
+	for _, entry := range c.entries {
+		if !c.expired(entry) {
+			values = append(values, entry.result())
+		}
+	}
+	return values
+}
+
+// Contents returns a comma-separated string containing all keys in the cache.
+func (c *FileResultCache) Contents() string {
+	return strings.Join(c.Keys(), ",")
+}
@@ -0,0 +1,130 @@
+package verificationcache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logContext "github.com/trufflesecurity/trufflehog/v3/pkg/context"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+func TestParseTTLs(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    TTLs
+		wantErr bool
+	}{
+		{input: "", want: DefaultTTLs},
+		{input: "1h", want: TTLs{Verified: time.Hour, Unverified: time.Hour, Unknown: time.Hour}},
+		{input: "unknown=5m", want: TTLs{Verified: 24 * time.Hour, Unverified: 24 * time.Hour, Unknown: 5 * time.Minute}},
+		{input: "verified=48h, Unverified=1h,unknown=0s", want: TTLs{Verified: 48 * time.Hour, Unverified: time.Hour}},
+		{input: "forever", wantErr: true},
+		{input: "verified=1h,unverified", wantErr: true},
+		{input: "revoked=1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTTLs(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFileResultCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "verification.json")
+	ttls := TTLs{Verified: time.Hour, Unverified: 10 * time.Minute}
+
+	c, err := NewFileResultCache(path, ttls)
+	require.NoError(t, err)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	unknown := detectors.Result{}
+	unknown.SetVerificationError(errors.New("unexpected HTTP response status 503"))
+	c.Set("verified", detectors.Result{Verified: true, Raw: []byte("hunter2")})
+	c.Set("unverified", detectors.Result{})
+	c.Set("unknown", unknown)
+
+	// Unknown results are cached for the current scan only.
+	assert.ElementsMatch(t, []string{"verified", "unverified", "unknown"}, c.Keys())
+	got, ok := c.Get("unknown")
+	require.True(t, ok)
+	assert.EqualError(t, got.VerificationError(), "unexpected HTTP response status 503")
+	require.NoError(t, c.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+
+	// A later scan loads the persisted results that have not expired.
+	c, err = NewFileResultCache(path, ttls)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Count())
+	got, ok = c.Get("verified")
+	require.True(t, ok)
+	assert.True(t, got.Verified)
+	assert.Nil(t, got.Raw)
+	got, ok = c.Get("unverified")
+	require.True(t, ok)
+	assert.False(t, got.Verified)
+	assert.NoError(t, got.VerificationError())
+	assert.False(t, c.Exists("unknown"))
+
+	// Entries expire during a scan too.
+	c.now = func() time.Time { return now.Add(30 * time.Minute) }
+	assert.ElementsMatch(t, []string{"verified"}, c.Keys())
+
+	c.now = func() time.Time { return now.Add(2 * time.Hour) }
+	assert.False(t, c.Exists("verified"))
+	assert.Empty(t, c.Values())
+}
+
+func TestFileResultCache_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "verification.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+	_, err := NewFileResultCache(path, DefaultTTLs)
+	assert.Error(t, err)
+
+	// Files written by other versions are ignored.
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 0, "entries": {"a2V5": {"verified": true}}}`), 0o600))
+	c, err := NewFileResultCache(path, DefaultTTLs)
+	require.NoError(t, err)
+	assert.Zero(t, c.Count())
+}
+
+func TestVerificationCache_FromData_FileResultCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "verification.json")
+	detector := testDetector{results: []detectors.Result{
+		{Redacted: "hello", Raw: []byte("hello"), RawV2: []byte("helloV2"), Verified: true},
+	}}
+
+	resultCache, err := NewFileResultCache(path, DefaultTTLs)
+	require.NoError(t, err)
+	_, err = New(resultCache, nil).FromData(logContext.Background(), &detector, true, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, detector.fromDataCallCount)
+	require.NoError(t, resultCache.Save())
+
+	// A new scan is served from the persisted cache.
+	resultCache, err = NewFileResultCache(path, DefaultTTLs)
+	require.NoError(t, err)
+	metrics := InMemoryMetrics{}
+	results, err := New(resultCache, &metrics).FromData(logContext.Background(), &detector, true, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, detector.fromDataCallCount)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Verified)
+	assert.True(t, results[0].VerificationFromCache)
+	assert.Equal(t, int32(1), metrics.CredentialVerificationsSaved.Load())
+}